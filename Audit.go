@@ -0,0 +1,131 @@
+package godbc
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+//Audit actions
+const (
+	//AuditSubmit - a captcha was submitted
+	AuditSubmit = "submit"
+	//AuditResult - WaitCaptcha returned, with the answer or the error
+	AuditResult = "result"
+	//AuditReport - a captcha was reported
+	AuditReport = "report"
+)
+
+//AuditRecord is an entry of the audit log
+type AuditRecord struct {
+	At        time.Time `json:"at"`
+	Action    string    `json:"action"`
+	CaptchaID int64     `json:"captcha_id,omitempty"`
+	//Tenant - the tenant of the call's context, see WithTenant
+	Tenant string `json:"tenant,omitempty"`
+	//CorrelationID - the correlation ID of the captcha, see WithCorrelationID. Not inserted by SQLAuditInsert
+	CorrelationID string `json:"correlation_id,omitempty"`
+	//ContentHash - the sha256 of the submitted image, in hex
+	ContentHash string `json:"content_hash,omitempty"`
+	//Content - the submitted image, only recorded when ClientOptions.AuditRawContent is set and Privacy is not
+	Content []byte `json:"content,omitempty"`
+	Text    string `json:"text,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+//AuditSink stores the audit log of the client, errors are dropped so auditing never fails a solve
+type AuditSink interface {
+	Record(ctx context.Context, record AuditRecord) error
+}
+
+//JSONAuditSink writes each record as a line of JSON, e.g. to os.Stdout
+type JSONAuditSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+//NewJSONAuditSink returns a sink writing to w
+func NewJSONAuditSink(w io.Writer) *JSONAuditSink {
+	return &JSONAuditSink{w: w}
+}
+
+//FileAuditSink appends records to a file as lines of JSON
+type FileAuditSink struct {
+	*JSONAuditSink
+	file *os.File
+}
+
+//NewFileAuditSink opens, or creates, the file records are appended to
+func NewFileAuditSink(path string) (*FileAuditSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileAuditSink{JSONAuditSink: NewJSONAuditSink(file), file: file}, nil
+}
+
+//Close closes the file
+func (s *FileAuditSink) Close() error {
+	return s.file.Close()
+}
+
+//Record writes a record
+func (s *JSONAuditSink) Record(ctx context.Context, record AuditRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
+
+//SQLAuditInsert is the default statement of SQLAuditSink, with the columns in the order they are bound
+const SQLAuditInsert = "INSERT INTO godbc_audit (at, action, captcha_id, tenant, content_hash, content, text, error) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+
+//SQLAuditSink inserts records in a database table
+type SQLAuditSink struct {
+	DB *sql.DB
+	//Insert - the insert statement, SQLAuditInsert if empty. Drivers using other placeholders, e.g. $1, need their own
+	Insert string
+}
+
+//Record inserts a record
+func (s *SQLAuditSink) Record(ctx context.Context, record AuditRecord) error {
+	insert := s.Insert
+	if insert == "" {
+		insert = SQLAuditInsert
+	}
+	_, err := s.DB.ExecContext(ctx, insert, record.At, record.Action, record.CaptchaID, record.Tenant, record.ContentHash, record.Content, record.Text, record.Error)
+	return err
+}
+
+//audit records an entry when the client has an AuditSink
+func (c *Client) audit(ctx context.Context, action string, ressource *CaptchaResponse, content []byte, err error) {
+	sink := c.opts().AuditSink
+	if sink == nil {
+		return
+	}
+
+	record := AuditRecord{At: time.Now(), Action: action, Tenant: TenantFrom(ctx), CorrelationID: CorrelationIDFrom(ctx)}
+	if ressource != nil {
+		record.CaptchaID = ressource.ID
+		record.Text = ressource.Text
+	}
+	if content != nil {
+		record.ContentHash = uploadKey(content)
+		if c.opts().AuditRawContent && !c.opts().Privacy {
+			record.Content = content
+		}
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	sink.Record(ctx, record)
+}
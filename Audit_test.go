@@ -0,0 +1,59 @@
+package godbc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileAuditSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := NewFileAuditSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewClient("user", "password", &ClientOptions{Sandbox: &SandboxConfig{Answer: "abcdef"}, AuditSink: sink, AuditRawContent: true})
+	ctx := WithTenant(context.Background(), "tenant")
+	content := benchmarkImage(t)
+	ressource, err := client.CaptchaWithOptions(ctx, content, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolved, err := client.WaitCaptchaWithContext(ctx, ressource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.ReportCaptchaWithContext(ctx, resolved); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var records []AuditRecord
+	for scanner := bufio.NewScanner(file); scanner.Scan(); {
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 3 || records[0].Action != AuditSubmit || records[1].Action != AuditResult || records[2].Action != AuditReport {
+		t.Fatalf("unexpected audit log %+v", records)
+	}
+	submit := records[0]
+	if submit.CaptchaID != ressource.ID || submit.Tenant != "tenant" || submit.ContentHash != uploadKey(content) || len(submit.Content) != len(content) {
+		t.Errorf("unexpected submission record %+v", submit)
+	}
+	if records[1].Text != "abcdef" || records[1].Error != "" {
+		t.Errorf("unexpected result record %+v", records[1])
+	}
+}
@@ -0,0 +1,85 @@
+package godbc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//uploadChunk is the most bytes a throttled upload sends at once
+const uploadChunk = 4096
+
+//uploadBucket is the token bucket of the upload bandwidth cap, shared by the client's calls
+type uploadBucket struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+//take waits until n bytes may be sent at the given rate, in bytes per second. The bucket holds a second of traffic
+func (b *uploadBucket) take(ctx context.Context, n int, rate int) error {
+	b.mu.Lock()
+	now := time.Now()
+	if b.last.IsZero() {
+		b.tokens = float64(rate)
+	} else {
+		b.tokens += now.Sub(b.last).Seconds() * float64(rate)
+	}
+	if b.tokens > float64(rate) {
+		b.tokens = float64(rate)
+	}
+	b.last = now
+	b.tokens -= float64(n)
+	wait := time.Duration(0)
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / float64(rate) * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if wait == 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+//meteredBody counts the bytes of an upload, pacing them under the client's UploadRate
+type meteredBody struct {
+	io.ReadCloser
+	ctx    context.Context
+	client *Client
+}
+
+func (b *meteredBody) Read(p []byte) (int, error) {
+	rate := b.client.opts().UploadRate
+	if rate > 0 && len(p) > uploadChunk {
+		p = p[:uploadChunk]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		atomic.AddInt64(&b.client.counters.uploaded, int64(n))
+		if rate > 0 {
+			if waitErr := b.client.upload.take(b.ctx, n, rate); waitErr != nil {
+				return n, waitErr
+			}
+		}
+	}
+	return n, err
+}
+
+//meter wraps the body of a request to count and pace it
+func (c *Client) meter(ctx context.Context, body io.ReadCloser) io.ReadCloser {
+	if body == nil || body == http.NoBody {
+		return body
+	}
+	return &meteredBody{ReadCloser: body, ctx: ctx, client: c}
+}
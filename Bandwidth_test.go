@@ -0,0 +1,28 @@
+package godbc
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBandwidth(t *testing.T) {
+	client, transport := newMockClient(&ClientOptions{UploadRate: 20000}, func(req *http.Request, body []byte) *http.Response {
+		return mockResponse(200, `{"captcha": 1, "is_correct": true, "text": "", "status": 0}`)
+	})
+	content := append(benchmarkImage(t), make([]byte, 30000)...)
+	start := time.Now()
+	if _, err := client.CaptchaWithOptions(context.Background(), content, nil); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Fatalf("the upload was not throttled: %s", elapsed)
+	}
+
+	_, body := transport.last(t)
+	stats := client.StatsSnapshot()
+	if stats.BytesUploaded != int64(len(body)) || stats.BytesDownloaded == 0 {
+		t.Fatalf("unexpected stats %+v for a %d bytes upload", stats, len(body))
+	}
+}
@@ -0,0 +1,44 @@
+package godbc
+
+import (
+	"context"
+	"testing"
+)
+
+var benchmarkCaptchaBody = []byte(`{"captcha": 123456789, "is_correct": true, "text": "abcdef", "status": 0}`)
+
+func BenchmarkParseCaptchaResponse(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseCaptchaResponse(benchmarkCaptchaBody); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParsePollResponse(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParsePollResponse(benchmarkCaptchaBody); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+//BenchmarkPollCaptcha measures a poll round trip against the sandbox
+func BenchmarkPollCaptcha(b *testing.B) {
+	client := NewClient("user", "password", &ClientOptions{Sandbox: &SandboxConfig{Answer: "abcdef"}})
+	ctx := context.Background()
+	ressource, err := client.CaptchaWithOptions(ctx, benchmarkImage(b), nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.PollCaptchaWithContext(ctx, ressource); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+//go:build !race
+
+package godbc
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+/*TestAllocationBudgets fails when a hot path allocates much more than it used to
+  The budgets leave about half again the allocations counted with Go 1.21, as counts vary with the Go version. The race detector allocates on its own, hence the build tag
+*/
+func TestAllocationBudgets(t *testing.T) {
+	body := benchmarkBody()
+	client := NewClient("user", "password", &ClientOptions{Sandbox: &SandboxConfig{Answer: "abcdef"}})
+	ctx := context.Background()
+	content := benchmarkImage(t)
+	ressource, err := client.CaptchaWithOptions(ctx, content, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	budgets := []struct {
+		name   string
+		budget float64
+		run    func()
+	}{
+		{"ParseCaptchaResponse", 4, func() { ParseCaptchaResponse(benchmarkCaptchaBody) }},
+		{"readBody", 4, func() { readBody(bytes.NewReader(body)) }},
+		{"BuildCaptchaRequest", 200, func() {
+			req, _ := client.BuildCaptchaRequest(ctx, content, nil)
+			io.Copy(ioutil.Discard, req.Body)
+			req.Body.Close()
+		}},
+		{"PollCaptcha", 110, func() { client.PollCaptchaWithContext(ctx, ressource) }},
+	}
+	for _, b := range budgets {
+		if allocs := testing.AllocsPerRun(100, b.run); allocs > b.budget {
+			t.Errorf("%s allocates %.0f times per run, the budget is %.0f", b.name, allocs, b.budget)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package godbc
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+func benchmarkBody() []byte {
+	//a captcha status response padded to a typical image size
+	return bytes.Repeat([]byte(`{"captcha": 123456789, "is_correct": true, "text": "abcdef", "status": 0}`), 1000)
+}
+
+func BenchmarkReadBody(b *testing.B) {
+	body := benchmarkBody()
+	b.ReportAllocs()
+	b.SetBytes(int64(len(body)))
+	for i := 0; i < b.N; i++ {
+		if _, err := readBody(bytes.NewReader(body)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadAll(b *testing.B) {
+	body := benchmarkBody()
+	b.ReportAllocs()
+	b.SetBytes(int64(len(body)))
+	for i := 0; i < b.N; i++ {
+		if _, err := ioutil.ReadAll(bytes.NewReader(body)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+//benchmarkImage returns a noisy png, of about the size of a real captcha
+func benchmarkImage(tb testing.TB) []byte {
+	img := image.NewGray(image.Rect(0, 0, 200, 70))
+	for i := range img.Pix {
+		img.Pix[i] = byte(i * 7919 % 251)
+	}
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, img); err != nil {
+		tb.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func benchmarkCaptchaRequest(b *testing.B, options *ClientOptions) {
+	client := NewClient("user", "password", options)
+	content := benchmarkImage(b)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(content)))
+	for i := 0; i < b.N; i++ {
+		req, err := client.BuildCaptchaRequest(context.Background(), content, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err = io.Copy(ioutil.Discard, req.Body); err != nil {
+			b.Fatal(err)
+		}
+		req.Body.Close()
+	}
+}
+
+func BenchmarkBuildCaptchaRequest(b *testing.B) {
+	benchmarkCaptchaRequest(b, nil)
+}
+
+func BenchmarkBuildCaptchaRequestGzip(b *testing.B) {
+	benchmarkCaptchaRequest(b, &ClientOptions{CompressRequests: true, CompressMinSize: 1})
+}
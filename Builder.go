@@ -0,0 +1,146 @@
+package godbc
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+//ErrNothingToSolve - The builder was run without Image, Token or Video
+var ErrNothingToSolve = errors.New("Nothing to solve")
+
+//SolveBuilder composes a submission step by step, e.g. client.NewSolve().Image(content).CaseSensitive().MaxWait(90*time.Second).Run(ctx)
+type SolveBuilder struct {
+	client *Client
+
+	content     []byte
+	isVideo     bool
+	video       VideoFormat
+	captchaType CaptchaType
+	params      interface{}
+	options     CaptchaOptions
+
+	maxWait       time.Duration
+	progress      func(attempt int, elapsed time.Duration)
+	validate      func(text string) error
+	correlationID string
+	tenant        string
+}
+
+//NewSolve starts a submission, it is sent by Run
+func (c *Client) NewSolve() *SolveBuilder {
+	return &SolveBuilder{client: c, captchaType: TypeImage}
+}
+
+//Image solves an image captcha
+func (b *SolveBuilder) Image(content []byte) *SolveBuilder {
+	b.content, b.captchaType, b.params, b.isVideo = content, TypeImage, nil, false
+	return b
+}
+
+//Token solves a token captcha of any type, see SolveToken. The image hints are ignored
+func (b *SolveBuilder) Token(captchaType CaptchaType, params interface{}) *SolveBuilder {
+	b.content, b.captchaType, b.params, b.isVideo = nil, captchaType, params, false
+	return b
+}
+
+//Video solves a video captcha, see CaptchaFromVideo. The image hints are ignored
+func (b *SolveBuilder) Video(content []byte, format VideoFormat) *SolveBuilder {
+	b.content, b.captchaType, b.params, b.isVideo, b.video = content, TypeImage, nil, true, format
+	return b
+}
+
+//CaseSensitive tells the solvers the answer is case sensitive
+func (b *SolveBuilder) CaseSensitive() *SolveBuilder {
+	b.options.CaseSensitive = true
+	return b
+}
+
+//Math tells the solvers the captcha is a math operation, the answer is its result
+func (b *SolveBuilder) Math() *SolveBuilder {
+	b.options.IsMath = true
+	return b
+}
+
+//Phrase tells the solvers the answer contains several words
+func (b *SolveBuilder) Phrase() *SolveBuilder {
+	b.options.IsPhrase = true
+	return b
+}
+
+//Length bounds the length of the answer, 0 for no bound
+func (b *SolveBuilder) Length(min, max int) *SolveBuilder {
+	b.options.MinLength, b.options.MaxLength = min, max
+	return b
+}
+
+//Language sets the language of the captcha text, e.g. "en"
+func (b *SolveBuilder) Language(language string) *SolveBuilder {
+	b.options.Language = language
+	return b
+}
+
+//MaxWait bounds the whole solve, submission included
+func (b *SolveBuilder) MaxWait(d time.Duration) *SolveBuilder {
+	b.maxWait = d
+	return b
+}
+
+//OnProgress is called before each poll, see WaitCaptchaWithProgress
+func (b *SolveBuilder) OnProgress(fn func(attempt int, elapsed time.Duration)) *SolveBuilder {
+	b.progress = fn
+	return b
+}
+
+//Validate checks the answer of an image captcha, a rejected answer is reported and the captcha uploaded again, see Solve
+func (b *SolveBuilder) Validate(fn func(text string) error) *SolveBuilder {
+	b.validate = fn
+	return b
+}
+
+//CorrelationID traces the captcha with the given ID, see WithCorrelationID
+func (b *SolveBuilder) CorrelationID(id string) *SolveBuilder {
+	b.correlationID = id
+	return b
+}
+
+//Tenant charges the captcha to the tenant, see WithTenant
+func (b *SolveBuilder) Tenant(tenant string) *SolveBuilder {
+	b.tenant = tenant
+	return b
+}
+
+//Run sends the captcha and waits for its answer
+func (b *SolveBuilder) Run(ctx context.Context) (*CaptchaResponse, error) {
+	if b.maxWait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.maxWait)
+		defer cancel()
+	}
+	if b.correlationID != "" {
+		ctx = WithCorrelationID(ctx, b.correlationID)
+	}
+	if b.tenant != "" {
+		ctx = WithTenant(ctx, b.tenant)
+	}
+
+	c := b.client
+	switch {
+	case b.isVideo:
+		ressource, err := c.CaptchaFromVideo(ctx, b.content, b.video)
+		if err != nil {
+			return nil, err
+		}
+		return c.WaitCaptchaWithProgress(ctx, ressource, b.progress)
+	case b.captchaType != TypeImage:
+		ressource, err := c.submitToken(ctx, b.captchaType, b.params)
+		if err != nil {
+			return nil, err
+		}
+		return c.WaitCaptchaWithProgress(ctx, ressource, b.progress)
+	case len(b.content) == 0:
+		return nil, ErrNothingToSolve
+	}
+	options := b.options
+	return c.solve(ctx, b.content, &options, b.validate, b.progress)
+}
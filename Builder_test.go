@@ -0,0 +1,30 @@
+package godbc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSolveBuilder(t *testing.T) {
+	client := newSandboxClient(SandboxConfig{Answer: "abcdef"})
+	polls := 0
+	resolved, err := client.NewSolve().
+		Image(benchmarkImage(t)).
+		CaseSensitive().
+		Length(6, 6).
+		MaxWait(30 * time.Second).
+		CorrelationID("builder").
+		OnProgress(func(attempt int, elapsed time.Duration) { polls++ }).
+		Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.Text != "abcdef" || resolved.CorrelationID != "builder" || polls == 0 {
+		t.Fatalf("unexpected response %+v after %d polls", resolved, polls)
+	}
+
+	if _, err := client.NewSolve().Run(context.Background()); err != ErrNothingToSolve {
+		t.Fatalf("got %v, want ErrNothingToSolve", err)
+	}
+}
@@ -0,0 +1,65 @@
+package godbc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+type forceRefreshKey struct{}
+
+//ForceRefresh returns a context whose User and Status calls bypass the client's cache, stale responses included, see ClientOptions.CacheTTL
+func ForceRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceRefreshKey{}, true)
+}
+
+func isForceRefresh(ctx context.Context) bool {
+	force, _ := ctx.Value(forceRefreshKey{}).(bool)
+	return force
+}
+
+//cacheEntry is the last response of an endpoint
+type cacheEntry struct {
+	mu    sync.Mutex
+	value interface{}
+	at    time.Time
+}
+
+//get returns the cached value when it is younger than ttl
+func (e *cacheEntry) get(ctx context.Context, ttl time.Duration) interface{} {
+	if ttl <= 0 || isForceRefresh(ctx) {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.value == nil || time.Since(e.at) > ttl {
+		return nil
+	}
+	return e.value
+}
+
+//stale returns the cached value, whatever its age under maxAge, to be served in place of a failed call. Rejected credentials and done contexts are never hidden
+func (e *cacheEntry) stale(ctx context.Context, err error, maxAge time.Duration) interface{} {
+	if maxAge <= 0 || isForceRefresh(ctx) || ctx.Err() != nil || errors.Is(err, ErrCredentialsRejected) {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.value == nil || time.Since(e.at) > maxAge {
+		return nil
+	}
+	return e.value
+}
+
+func (e *cacheEntry) put(value interface{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.value, e.at = value, time.Now()
+}
+
+//responseCache holds the responses of the account endpoints
+type responseCache struct {
+	user   cacheEntry
+	status cacheEntry
+}
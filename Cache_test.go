@@ -0,0 +1,87 @@
+package godbc
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCacheTTL(t *testing.T) {
+	client, transport := newMockClient(&ClientOptions{CacheTTL: time.Minute}, func(req *http.Request, body []byte) *http.Response {
+		return mockResponse(200, `{"user": 1, "rate": 0.139, "balance": 12.5, "is_banned": false, "status": 0}`)
+	})
+	ctx := context.Background()
+	first, err := client.UserWithContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Balance = 0
+	second, err := client.UserWithContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transport.requests) != 1 || second.Balance != 12.5 {
+		t.Fatalf("got %+v after %d requests, want an unchanged copy of the cached response", second, len(transport.requests))
+	}
+
+	if _, err := client.UserWithContext(ForceRefresh(ctx)); err != nil {
+		t.Fatal(err)
+	}
+	if len(transport.requests) != 2 {
+		t.Fatal("ForceRefresh was served from the cache")
+	}
+
+	client.cache.user.at = time.Now().Add(-2 * time.Minute)
+	if _, err := client.UserWithContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(transport.requests) != 3 {
+		t.Fatal("an expired response was served from the cache")
+	}
+}
+
+func TestStaleFor(t *testing.T) {
+	failing := false
+	client, _ := newMockClient(&ClientOptions{StaleFor: time.Hour, CaptchaRetries: 1}, func(req *http.Request, body []byte) *http.Response {
+		if failing {
+			return mockResponse(500, "")
+		}
+		return mockResponse(200, `{"todays_accuracy": 0.9, "solved_in": 10, "is_service_overloaded": false, "status": 0}`)
+	})
+	ctx := context.Background()
+	if _, err := client.StatusWithContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	failing = true
+	status, err := client.StatusWithContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Stale || status.SolvedIn != 10 {
+		t.Fatalf("got %+v, want the last good response flagged stale", status)
+	}
+	if _, err := client.StatusWithContext(ForceRefresh(ctx)); err == nil {
+		t.Fatal("ForceRefresh was served a stale response")
+	}
+	client.cache.status.at = time.Now().Add(-2 * time.Hour)
+	if _, err := client.StatusWithContext(ctx); err == nil {
+		t.Fatal("a response older than StaleFor was served")
+	}
+
+	client, _ = newMockClient(&ClientOptions{StaleFor: time.Hour}, func(req *http.Request, body []byte) *http.Response {
+		if failing {
+			return mockResponse(403, "")
+		}
+		return mockResponse(200, `{"user": 1, "rate": 0.139, "balance": 12.5, "is_banned": false, "status": 0}`)
+	})
+	failing = false
+	if _, err := client.UserWithContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	failing = true
+	if _, err := client.UserWithContext(ctx); err != ErrCredentialsRejected {
+		t.Fatalf("got %v, want rejected credentials never hidden", err)
+	}
+}
@@ -0,0 +1,106 @@
+package godbc
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+//apiStatus is implemented by the api responses, so their status is checked without decoding them twice
+type apiStatus interface {
+	apiStatus() (StatusCode, string)
+}
+
+func (r *CaptchaResponse) apiStatus() (StatusCode, string) { return r.StatusCode(), r.Error }
+func (r *UserResponse) apiStatus() (StatusCode, string)    { return r.StatusCode(), r.Error }
+func (r *StatusResponse) apiStatus() (StatusCode, string)  { return r.StatusCode(), r.Error }
+func (r *recentResponse) apiStatus() (StatusCode, string)  { return r.Status, r.Error }
+
+//statusEnvelope is the status of a response type not implementing apiStatus
+type statusEnvelope struct {
+	Status StatusCode `json:"status"`
+	Error  string     `json:"error"`
+}
+
+func (e *statusEnvelope) apiStatus() (StatusCode, string) { return e.Status, e.Error }
+
+/*parse decodes the body of an api response in its Content-Type, a status other than 0 is returned as a *ServiceError
+  Bodies of a content type the response does not know, e.g. JSON served as text/plain, are decoded as JSON
+*/
+func parse[T any](body []byte, contentType string) (*T, error) {
+	response := new(T)
+	if hydrator, ok := any(response).(Hydrator); ok {
+		err := hydrator.Hydrate(body, contentType)
+		if err == ErrUnsupportedContentType {
+			err = hydrator.Hydrate(body, jsonContentType)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return response, nil
+	}
+	if err := decodeResponse(body, response); err != nil {
+		return nil, err
+	}
+	checked, ok := any(response).(apiStatus)
+	if !ok {
+		envelope := &statusEnvelope{}
+		if err := decodeResponse(body, envelope); err != nil {
+			return nil, err
+		}
+		checked = envelope
+	}
+	if status, message := checked.apiStatus(); status != StatusOK {
+		return nil, newServiceError(status, message)
+	}
+	return response, nil
+}
+
+//do sends an api request and decodes its response, errors are reported to the ErrorSink
+func do[T any](c *Client, req *http.Request) (*T, error) {
+	header, body, err := c.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	response, err := parse[T](body, header.Get("Content-Type"))
+	if err != nil {
+		return nil, c.reportError(req.Context(), err)
+	}
+	return response, nil
+}
+
+/*Call sends a request to a custom endpoint and decodes its JSON response into a T, with the checks, errors and events of the built-in calls
+  A status other than 0 in the response is returned as a *ServiceError
+  method: `GET` sends the form as the query, other methods as an url-encoded body
+  route: the endpoint, e.g. registered with RegisterRoute
+  captchaID: replaces the %d of the route's template, 0 if it has none
+  form: the parameters, the credentials are added. May be nil
+*/
+func Call[T any](ctx context.Context, c *Client, method string, route *Route, captchaID int64, form url.Values) (*T, error) {
+	ctx, urlReq, err := c.route(ctx, route, captchaID)
+	if err != nil {
+		return nil, err
+	}
+	v := url.Values{}
+	for k, values := range form {
+		v[k] = values
+	}
+	v.Set("username", c.username)
+	v.Set("password", c.password)
+
+	var req *http.Request
+	if method == `GET` {
+		urlReq.RawQuery = v.Encode()
+		req, err = http.NewRequestWithContext(ctx, method, urlReq.String(), nil)
+	} else {
+		req, err = http.NewRequestWithContext(ctx, method, urlReq.String(), strings.NewReader(v.Encode()))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return do[T](c, req)
+}
@@ -0,0 +1,60 @@
+package godbc
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestCall(t *testing.T) {
+	type invoice struct {
+		Total float64 `json:"total"`
+	}
+	route := &Route{Name: "invoice", Template: "invoice/%d"}
+	answers := []string{`{"total": 12.5, "status": 0}`, `{"status": 255, "error": "not-logged-in"}`}
+	client, transport := newMockClient(nil, func(req *http.Request, body []byte) *http.Response {
+		answer := answers[0]
+		answers = answers[1:]
+		return mockResponse(200, answer)
+	})
+
+	ctx := context.Background()
+	response, err := Call[invoice](ctx, client, `GET`, route, 7, url.Values{"month": {"2026-10"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Total != 12.5 {
+		t.Fatalf("unexpected response %+v", response)
+	}
+	req, _ := transport.last(t)
+	if req.URL.Path != "/api/invoice/7" || req.URL.Query().Get("month") != "2026-10" || req.URL.Query().Get("username") != "user" {
+		t.Fatalf("unexpected request %s", req.URL)
+	}
+
+	_, err = Call[invoice](ctx, client, `POST`, route, 7, nil)
+	if !IsCredentialError(err) {
+		t.Fatalf("got %v, want a credential error", err)
+	}
+}
+
+func TestCallContentType(t *testing.T) {
+	client, _ := newMockClient(nil, func(req *http.Request, body []byte) *http.Response {
+		return mockResponse(200, "status=0&user=7&balance=12.5", "Content-Type", "application/x-www-form-urlencoded")
+	})
+	user, err := Call[UserResponse](context.Background(), client, `GET`, RouteUser, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != 7 || user.Balance != 12.5 {
+		t.Fatalf("unexpected response %+v", user)
+	}
+
+	//JSON served with another content type is still decoded
+	client, _ = newMockClient(nil, func(req *http.Request, body []byte) *http.Response {
+		return mockResponse(200, `{"status": 0, "user": 7}`, "Content-Type", "text/plain; charset=utf-8")
+	})
+	if user, err := client.UserWithContext(context.Background()); err != nil || user.ID != 7 {
+		t.Fatalf("got %+v, %v", user, err)
+	}
+}
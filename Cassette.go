@@ -0,0 +1,325 @@
+package godbc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//ErrCassetteMismatch - a replayed request has no recorded interaction left to answer it
+var ErrCassetteMismatch = errors.New("No recorded interaction matches the request")
+
+//CassetteMode is how a Cassette handles requests
+type CassetteMode int
+
+//Cassette modes
+const (
+	//CassetteAuto - replays the cassette file when it exists, records it otherwise
+	CassetteAuto CassetteMode = iota
+	//CassetteReplay - answers requests from the cassette file, nothing reaches the network
+	CassetteReplay
+	//CassetteRecord - sends requests to the service and records them, overwriting the cassette file on Save
+	CassetteRecord
+)
+
+//cassetteRedacted replaces the credentials of recorded requests
+const cassetteRedacted = "REDACTED"
+
+//cassetteHeaders are the response headers kept in cassettes
+var cassetteHeaders = []string{"Content-Type", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Location"}
+
+//CassetteRequest is a recorded request, its credentials, proxy, cookies and user agent redacted and its files replaced by their sha256
+type CassetteRequest struct {
+	Method string              `json:"method"`
+	Path   string              `json:"path"`
+	Form   map[string][]string `json:"form,omitempty"`
+}
+
+//CassetteResponse is a recorded response, or the transport error the request failed with
+type CassetteResponse struct {
+	StatusCode int               `json:"status_code,omitempty"`
+	Header     map[string]string `json:"header,omitempty"`
+	Body       string            `json:"body,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+//CassetteInteraction is a recorded api exchange
+type CassetteInteraction struct {
+	Request  CassetteRequest  `json:"request"`
+	Response CassetteResponse `json:"response"`
+}
+
+/*Cassette is an http.RoundTripper recording api exchanges to a file, to replay them in tests without credentials or network
+  Requests are replayed in the order they were recorded, among the interactions of the same method and path, so polling sequences, overload retries and error paths play out as they did
+*/
+type Cassette struct {
+	path string
+	mode CassetteMode
+	next http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []CassetteInteraction
+	used         []bool
+}
+
+/*LoadCassette returns a cassette backed by the file at path
+  mode: CassetteAuto to replay the file when it exists, and record it otherwise
+  next: the transport recorded requests are sent with, http.DefaultTransport if nil
+*/
+func LoadCassette(path string, mode CassetteMode, next http.RoundTripper) (*Cassette, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if mode == CassetteAuto {
+		mode = CassetteRecord
+		if _, err := os.Stat(path); err == nil {
+			mode = CassetteReplay
+		}
+	}
+
+	c := &Cassette{path: path, mode: mode, next: next}
+	if mode == CassetteReplay {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(content, &c.interactions)
+		if err != nil {
+			return nil, err
+		}
+		c.used = make([]bool, len(c.interactions))
+	}
+
+	return c, nil
+}
+
+//Recording returns true when the cassette sends requests to the service
+func (c *Cassette) Recording() bool {
+	return c.mode == CassetteRecord
+}
+
+//Interactions returns the interactions recorded, or loaded, so far
+func (c *Cassette) Interactions() []CassetteInteraction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]CassetteInteraction(nil), c.interactions...)
+}
+
+//Save writes the recorded interactions to the cassette file, it does nothing when replaying
+func (c *Cassette) Save() error {
+	if !c.Recording() {
+		return nil
+	}
+	c.mu.Lock()
+	content, err := json.MarshalIndent(c.interactions, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.path, append(content, '\n'), 0600)
+}
+
+//RoundTrip replays, or records, an api exchange
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	if c.mode == CassetteReplay {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return c.replay(req)
+	}
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	recorded := CassetteInteraction{Request: CassetteRequest{Method: req.Method, Path: req.URL.Path, Form: sanitizeForm(req.Header, body)}}
+
+	resp, err := c.next.RoundTrip(req)
+	if err != nil {
+		recorded.Response.Error = err.Error()
+		c.record(recorded)
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	recorded.Response.StatusCode = resp.StatusCode
+	recorded.Response.Body = sanitizeBody(resp.Header, respBody)
+	recorded.Response.Header = map[string]string{}
+	for _, key := range cassetteHeaders {
+		if value := resp.Header.Get(key); value != "" {
+			recorded.Response.Header[key] = value
+		}
+	}
+	c.record(recorded)
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+	return resp, nil
+}
+
+func (c *Cassette) record(interaction CassetteInteraction) {
+	c.mu.Lock()
+	c.interactions = append(c.interactions, interaction)
+	c.mu.Unlock()
+}
+
+func (c *Cassette) replay(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, interaction := range c.interactions {
+		if c.used[i] || interaction.Request.Method != req.Method || interaction.Request.Path != req.URL.Path {
+			continue
+		}
+		c.used[i] = true
+
+		recorded := interaction.Response
+		if recorded.Error != "" {
+			return nil, errors.New(recorded.Error)
+		}
+		header := http.Header{}
+		for key, value := range recorded.Header {
+			header.Set(key, value)
+		}
+		return &http.Response{
+			StatusCode:    recorded.StatusCode,
+			Status:        strconv.Itoa(recorded.StatusCode) + " " + http.StatusText(recorded.StatusCode),
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(bytes.NewBufferString(recorded.Body)),
+			ContentLength: int64(len(recorded.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, ErrCassetteMismatch
+}
+
+//sanitizeBody returns a response body as recorded, the secret fields of JSON bodies redacted
+func sanitizeBody(header http.Header, body []byte) string {
+	if mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type")); mediaType != "application/json" || !json.Valid(body) {
+		return string(body)
+	}
+	return string(sanitizeJSON(body))
+}
+
+//sanitizeForm returns the fields of a request body, with the credentials and token sessions redacted and the files replaced by their sha256
+func sanitizeForm(header http.Header, body []byte) map[string][]string {
+	if len(body) == 0 {
+		return nil
+	}
+	if header.Get("Content-Encoding") == "gzip" {
+		reader, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil
+		}
+		if body, err = ioutil.ReadAll(reader); err != nil {
+			return nil
+		}
+	}
+
+	form := map[string][]string{}
+	mediaType, params, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil
+		}
+		form = values
+	case "multipart/form-data":
+		reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil
+			}
+			content, _ := ioutil.ReadAll(part)
+			if part.FileName() != "" {
+				hash := sha256.Sum256(content)
+				content = []byte("sha256:" + hex.EncodeToString(hash[:]))
+			}
+			form[part.FormName()] = append(form[part.FormName()], string(content))
+		}
+	default:
+		return nil
+	}
+
+	for key, values := range form {
+		switch {
+		case cassetteSecret(key):
+			form[key] = []string{cassetteRedacted}
+		case strings.HasSuffix(key, "_params"):
+			//token_params and the parameters of the other types carry the proxy, cookies and user agent of the target session
+			for i, value := range values {
+				values[i] = string(sanitizeJSON([]byte(value)))
+			}
+		}
+	}
+	return form
+}
+
+//cassetteSecret returns true for the fields redacted from cassettes: the credentials, and the proxy and session of token captchas
+func cassetteSecret(key string) bool {
+	switch strings.ToLower(key) {
+	case "username", "password", "authtoken", "proxy", "cookies", "cookie", "useragent", "user_agent":
+		return true
+	}
+	return false
+}
+
+//sanitizeJSON returns a JSON document with the values of its secret fields redacted at any depth, or redacted whole when it is not JSON
+func sanitizeJSON(content []byte) []byte {
+	var document interface{}
+	if err := json.Unmarshal(content, &document); err != nil {
+		return []byte(cassetteRedacted)
+	}
+	sanitized, err := json.Marshal(redactJSON(document))
+	if err != nil {
+		return []byte(cassetteRedacted)
+	}
+	return sanitized
+}
+
+func redactJSON(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			if cassetteSecret(key) {
+				value[key] = cassetteRedacted
+			} else {
+				value[key] = redactJSON(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = redactJSON(item)
+		}
+	}
+	return value
+}
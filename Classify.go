@@ -0,0 +1,53 @@
+package godbc
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+//retryableErrors are the errors that may go away when the call is made again
+var retryableErrors = []error{
+	ErrOverloadedServer,
+	ErrRateLimited,
+	ErrUnexpectedServerError,
+	ErrUnexpectedServerResponse,
+	ErrServiceBug,
+	context.DeadlineExceeded,
+}
+
+/*IsRetryable returns true when err may go away by making the call again: overloads, rate limits, timeouts, network failures
+  ErrCaptchaTimeout and ErrCaptchaInvalid are not: the captcha was paid for and may still be solved, an invalid captcha is submitted again
+  by the client itself, see ClientOptions.InvalidRetries
+*/
+func IsRetryable(err error) bool {
+	if err == nil || IsCredentialError(err) {
+		return false
+	}
+	for _, retryable := range retryableErrors {
+		if errors.Is(err, retryable) {
+			return true
+		}
+	}
+
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Retryable
+	}
+	var downloadErr *DownloadError
+	if errors.As(err, &downloadErr) {
+		return downloadErr.StatusCode >= 500 || downloadErr.StatusCode == 429
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+//IsPermanent returns true when making the call again will fail the same way, e.g. invalid images or insufficient funds
+func IsPermanent(err error) bool {
+	return err != nil && !IsRetryable(err)
+}
+
+//IsCredentialError returns true when the account can not be used: rejected credentials or banned user. Such errors are permanent
+func IsCredentialError(err error) bool {
+	return errors.Is(err, ErrCredentialsRejected) || errors.Is(err, ErrBanned)
+}
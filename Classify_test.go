@@ -0,0 +1,45 @@
+package godbc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	for _, tc := range []struct {
+		err       error
+		retryable bool
+	}{
+		{ErrOverloadedServer, true},
+		{fmt.Errorf("poll: %w", ErrRateLimited), true},
+		{ErrCaptchaTimeout, false},
+		{ErrCaptchaInvalid, false},
+		{ErrCredentialsRejected, false},
+		{ErrInvalidFormat, false},
+	} {
+		if IsRetryable(tc.err) != tc.retryable || IsPermanent(tc.err) == tc.retryable {
+			t.Errorf("%v: retryable is %t, want %t", tc.err, IsRetryable(tc.err), tc.retryable)
+		}
+	}
+}
+
+func TestPoolRetriesInvalid(t *testing.T) {
+	uploads := 0
+	client, _ := newMockClient(&ClientOptions{CaptchaRetries: 2}, func(req *http.Request, body []byte) *http.Response {
+		if req.Method == `POST` && !strings.HasSuffix(req.URL.Path, "/report") {
+			uploads++
+		}
+		return mockResponse(200, `{"captcha": 1, "is_correct": false, "text": "", "status": 0}`)
+	})
+	pool := NewPool(client, PoolOptions{Retries: 2})
+	defer pool.Close()
+	if _, err := pool.Solve(context.Background(), benchmarkImage(t), nil); err == nil {
+		t.Fatal("the invalid captcha was solved")
+	}
+	if uploads != 1 {
+		t.Fatalf("the pool uploaded the invalid captcha %d times", uploads)
+	}
+}
@@ -4,17 +4,16 @@ Package godbc implements deathbycaptcha's API
 package godbc
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"errors"
-	"fmt"
 	"io/ioutil"
+	"math"
 	"mime/multipart"
 	"net"
 	"net/http"
 	"net/url"
-	"regexp"
-	"strings"
+	"strconv"
+	"sync/atomic"
 	"time"
 )
 
@@ -38,6 +37,10 @@ var (
 	ErrUnexpectedServerResponse = errors.New("Unexpected output from server")
 	//ErrOverloadedServer - Server is overloaded and cannot take our query
 	ErrOverloadedServer = errors.New("Server is overloaded - try again later")
+	//ErrRateLimited - The service rate limited our queries (429), see RetryAfter
+	ErrRateLimited = errors.New("Rate limited by service - slow down")
+	//ErrPayloadTooLarge - The service refused the size of our query (413)
+	ErrPayloadTooLarge = errors.New("Payload was too large for the service")
 	//ErrReportRejected - Our captcha reporting was rejected, either the captcha id is incorrect, our user is banned or we are reporting it too late (1 hour max)
 	ErrReportRejected = errors.New("Report was rejected - Bad captcha id, user banned or captcha too old (1h max)")
 	//ErrCaptchaDoesNotExist - The captcha id provided is non-existent
@@ -55,15 +58,101 @@ type Client struct {
 	HTTPClient *http.Client
 	username   string
 	password   string
-	options    *ClientOptions
+	options    atomic.Value
+	events     *eventLog
+	uploads    *pendingUploads
+	reports    *reportBudget
+	cache      *responseCache
+	submitted  *submissionLog
+	consumed   *tokenLedger
+	counters   counters
+
+	endpointHealth *endpointSet
+	upload         *uploadBucket
 }
 
 //ClientOptions is the client's options struct to be sent in the constructor
 type ClientOptions struct {
-	Endpoint            *url.URL
+	Endpoint *url.URL
+	//Mirrors - other endpoints of the api, e.g. in other regions. Calls go to the fastest healthy endpoint, and fail over to the next one when it times out, see Client.CheckEndpoints
+	Mirrors             []*url.URL
 	HTTPTimeout         *time.Duration
 	TLSHandshakeTimeout *time.Duration
-	CaptchaRetries      int
+	//Resolver - resolves the api hosts, the system resolver if nil
+	Resolver *net.Resolver
+	//DNSCacheTTL - how long resolved addresses are kept in-process, and served past it while the resolver fails. 0 disables the cache
+	DNSCacheTTL time.Duration
+	//IPFamily - the address families the api is dialed over, both by default
+	IPFamily IPFamily
+	//FallbackDelay - how long the first family is given before the other one is raced with IPAny, 300ms if 0, negative to disable the race
+	FallbackDelay time.Duration
+	//LocalAddr - the local address connections are bound to, e.g. one of several public IPs of the host, may be nil
+	LocalAddr      *net.TCPAddr
+	CaptchaRetries int
+	//AdaptivePolling - wait for the service's reported average solve time before the first poll
+	AdaptivePolling bool
+	//IdempotentSubmit - return the pending captcha instead of uploading the same image again, and when the outcome of an upload is ambiguous look for it in RecentCaptchas before sending it once more, see sendReconciled
+	IdempotentSubmit bool
+	//ReportPolicy - limits the reports sent by ReportCaptcha, defaults to always reporting
+	ReportPolicy ReportPolicy
+	//AutoResolve - Consume solves expired tokens again instead of returning ErrTokenExpired
+	AutoResolve bool
+	//Profiles - per-site solver profiles used by SolveFor, may be nil
+	Profiles *ProfileRegistry
+	//Sandbox - if set, the client runs against an in-process fake of the API instead of the service, see SandboxConfig
+	Sandbox *SandboxConfig
+	//FaultInjector - if set, requests fail at random, for integration tests, see FaultInjector
+	FaultInjector *FaultInjector
+	//PreSolver - attempts image captchas locally first, the service is only used when its confidence is under PreSolverMinConfidence (0.9 by default). May be nil
+	PreSolver              PreSolver
+	PreSolverMinConfidence float64
+	//Preprocessors - applied in order to image captchas before they are uploaded, e.g. NormalizeOrientation then FitSize, see Preprocessor
+	Preprocessors []Preprocessor
+	//PostProcessors - applied in order to the text of solved image captchas before WaitCaptcha returns, see PostProcessor
+	PostProcessors []PostProcessor
+	//ValidationRetries - how many times a captcha rejected by a PostProcessor is reported and submitted again
+	ValidationRetries int
+	//InvalidRetries - how many times a captcha the service could not solve is reported and submitted again, see also Solve
+	InvalidRetries int
+	//PropagateDeadline - sends the time left before the context's deadline with each submission, so the service stops working on captchas nobody waits for anymore
+	PropagateDeadline bool
+	//CompressRequests - gzips request bodies of at least CompressMinSize bytes (8KB by default), for endpoints accepting Content-Encoding: gzip
+	CompressRequests bool
+	CompressMinSize  int
+	//CacheTTL - how long User and Status responses are reused, 0 disables caching. See ForceRefresh
+	CacheTTL time.Duration
+	//StaleFor - how old a cached User or Status response can be to be served, flagged Stale, when the service fails. 0 disables it
+	StaleFor time.Duration
+	//Limiter - waited on before every api call, may be nil
+	Limiter Limiter
+	//AuditSink - records every submission, result and report, may be nil
+	AuditSink AuditSink
+	//AuditRawContent - records submitted images in the audit log, only their hash is recorded otherwise
+	AuditRawContent bool
+	//Privacy - images are never kept or logged, only their hash, and the request bodies holding their bytes are zeroed once sent. The slice given by the caller is left as-is for the caller to retry with and wipe. Rejected answers are not submitted again
+	Privacy bool
+	//Clock - the time source of submission timestamps, of the report window and of ReportMaxPerHour, the system clock if nil
+	Clock Clock
+	//NewCorrelationID - generates the correlation ID of submissions made without one, see WithCorrelationID. Random IDs are used if nil
+	NewCorrelationID func() string
+	//CorrelateErrors - submission and WaitCaptcha errors are returned as a *CorrelationError, to be matched with errors.Is instead of ==
+	CorrelateErrors bool
+	//UploadRate - caps the upload bandwidth of the client's calls, in bytes per second, e.g. on a metered link. 0 is unlimited
+	UploadRate int
+	//ErrorSink - receives the classified errors of api calls, may be nil
+	ErrorSink ErrorSink
+	//StatusPolicy - overrides the handling of HTTP statuses of the api, may be nil. It must not be modified once given to the client
+	StatusPolicy StatusPolicy
+	//OnRequestTrace - called with the trace of every api call, may be nil. See also WithRequestTrace
+	OnRequestTrace func(RequestTrace)
+	//TraceErrors - errors of api calls are returned as a *TracedError, to be matched with errors.Is instead of ==
+	TraceErrors bool
+	//JobStore - persists the state of the captchas waited for at each transition of their lifecycle, may be nil
+	JobStore JobStore
+	//OnTransition - called at each transition of the lifecycle of the captchas waited for, see SolveState. May be nil
+	OnTransition func(Transition)
+	//OnEvent - called for every event of the client's event stream, may be nil
+	OnEvent func(Event)
 }
 
 //CaptchaResponse is returned as API response for all captcha related calls
@@ -73,14 +162,142 @@ type CaptchaResponse struct {
 	Text      string `json:"text"`
 	Status    int    `json:"status"`
 	Error     string `json:"error"`
+	//SolveTime - time spent by the solver on the captcha in seconds, when returned by the service
+	SolveTime float64 `json:"solve_time,omitempty"`
+	//Confidence - solver confidence between 0 and 1, when returned by the service
+	Confidence float64 `json:"confidence,omitempty"`
+	//SubmittedAt - when the captcha was submitted by this client, zero if unknown
+	SubmittedAt time.Time `json:"-"`
+	//ExpiresAt - when a solved token stops being valid, zero for non-token captchas
+	ExpiresAt time.Time `json:"-"`
+	//Local - the captcha was solved locally by the client's PreSolver, the service was not called
+	Local bool `json:"-"`
+	//Format - the detected format of an uploaded image
+	Format Format `json:"-"`
+	//SolvedAt - when WaitCaptcha saw the captcha solved
+	SolvedAt time.Time `json:"-"`
+	//Attempts - how many times the captcha was submitted before it was solved, more than 1 when it was resubmitted
+	Attempts int `json:"-"`
+	//TotalWait - the time WaitCaptcha spent waiting for the captcha, resubmissions included
+	TotalWait time.Duration `json:"-"`
+	//Provider - the name of the provider a Router sent the captcha to
+	Provider string `json:"-"`
+	//CorrelationID - traces the captcha through events, audit records, errors and the service's logs, see WithCorrelationID
+	CorrelationID string `json:"-"`
+	//PollURL - where the captcha is polled, as given by the service in a redirect or built from RoutePoll. Empty for local captchas
+	PollURL string `json:"-"`
+	//ReportURL - where the captcha is reported, built from RouteReport. Empty for local captchas
+	ReportURL string `json:"-"`
+
+	client      *Client
+	token       *tokenSubmission
+	resubmit    func(ctx context.Context) (*CaptchaResponse, error)
+	captchaType string
+	//retries - the poll budget of the captcha when it needs more than CaptchaRetries
+	retries int
+	//validation - the answer validators of the profile the captcha was solved with, may be nil
+	validation *answerValidation
+	//rawAnswer - the answer is not the text of an image, e.g. a rotation angle, PostProcessors and validators are skipped
+	rawAnswer bool
+}
+
+//ReportWindow is how long after submission a captcha can still be reported as incorrectly solved
+const ReportWindow = time.Hour
+
+//Age returns the time elapsed since the captcha was submitted according to the client's Clock, 0 if unknown
+func (r *CaptchaResponse) Age() time.Duration {
+	if r.SubmittedAt.IsZero() {
+		return 0
+	}
+
+	now := time.Now()
+	if r.client != nil {
+		now = r.client.now()
+	}
+	return now.Sub(r.SubmittedAt)
+}
+
+//CanStillReport returns true if the captcha is still in the report window. Captchas with an unknown submission time are considered reportable
+func (r *CaptchaResponse) CanStillReport() bool {
+	return r.Age() < ReportWindow
 }
 
 //RecaptchaRequestPayload is a payload that goes in a request for recaptcha by token api
 type RecaptchaRequestPayload struct {
 	PageURL   string `json:"pageurl"`
 	GoogleKey string `json:"googlekey"`
+	//Proxy - address of the proxy, in a form accepted by ParseProxy (a ProxyConfig's String())
 	Proxy     string `json:"proxy,omitempty"`
 	ProxyType string `json:"proxytype,omitempty"`
+	//Cookie - cookies of the target session, as sent in a Cookie header, so the solution validates against the target site
+	Cookie string `json:"cookies,omitempty"`
+	//UserAgent - user agent of the target session
+	UserAgent string `json:"useragent,omitempty"`
+	//DataS - the data-s token of Google Enterprise v2 challenges, single use
+	DataS string `json:"data-s,omitempty"`
+	//Enterprise - the challenge is a reCAPTCHA Enterprise one
+	Enterprise bool `json:"enterprise,omitempty"`
+	//Invisible - the challenge is an invisible reCAPTCHA (no checkbox, triggered by the page)
+	Invisible bool `json:"invisible,omitempty"`
+}
+
+//HcaptchaRequestPayload is a payload that goes in a request for hcaptcha api
+type HcaptchaRequestPayload struct {
+	PageURL string `json:"pageurl"`
+	SiteKey string `json:"sitekey"`
+	//Proxy - address of the proxy, in a form accepted by ParseProxy (a ProxyConfig's String())
+	Proxy     string `json:"proxy,omitempty"`
+	ProxyType string `json:"proxytype,omitempty"`
+	//Cookie - cookies of the target session, as sent in a Cookie header, so the solution validates against the target site
+	Cookie string `json:"cookies,omitempty"`
+	//UserAgent - user agent of the target session
+	UserAgent string `json:"useragent,omitempty"`
+}
+
+//CaptchaOptions are solving hints sent along with an image captcha, so solvers return more accurate answers
+type CaptchaOptions struct {
+	//CaseSensitive - the answer is case sensitive
+	CaseSensitive bool
+	//IsMath - the captcha is a math operation, the answer is its result
+	IsMath bool
+	//IsPhrase - the answer contains several words
+	IsPhrase bool
+	//MinLength - minimum length of the answer, 0 for no minimum
+	MinLength int
+	//MaxLength - maximum length of the answer, 0 for no maximum
+	MaxLength int
+	//Language - language of the captcha text, e.g. "en"
+	Language string
+}
+
+func (o *CaptchaOptions) writeFields(writer *multipart.Writer) error {
+	fields := [][2]string{}
+	if o.CaseSensitive {
+		fields = append(fields, [2]string{"case_sensitive", "1"})
+	}
+	if o.IsMath {
+		fields = append(fields, [2]string{"is_math", "1"})
+	}
+	if o.IsPhrase {
+		fields = append(fields, [2]string{"is_phrase", "1"})
+	}
+	if o.MinLength > 0 {
+		fields = append(fields, [2]string{"min_len", strconv.Itoa(o.MinLength)})
+	}
+	if o.MaxLength > 0 {
+		fields = append(fields, [2]string{"max_len", strconv.Itoa(o.MaxLength)})
+	}
+	if o.Language != "" {
+		fields = append(fields, [2]string{"language", o.Language})
+	}
+
+	for _, field := range fields {
+		err := writer.WriteField(field[0], field[1])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 //StatusResponse  is returned as API response for the `status` call
@@ -90,6 +307,8 @@ type StatusResponse struct {
 	IsServiceOverloaded bool    `json:"is_service_overloaded"`
 	Status              int     `json:"status"`
 	Error               string  `json:"error"`
+	//Stale - the response is the last good one, served from the cache because the service is failing, see ClientOptions.StaleFor
+	Stale bool `json:"-"`
 }
 
 //UserResponse  is returned as API response for the `user` call
@@ -100,6 +319,8 @@ type UserResponse struct {
 	IsBanned bool    `json:"is_banned"`
 	Status   int     `json:"status"`
 	Error    string  `json:"error"`
+	//Stale - the response is the last good one, served from the cache because the service is failing, see ClientOptions.StaleFor
+	Stale bool `json:"-"`
 }
 
 //HasCreditLeft returns true is user has enough credit to solve one captcha
@@ -125,23 +346,75 @@ func DefaultClient(username, password string) *Client {
 //NewClient returns a DBC client. Options not specified will take default values, see DefaultClient
 func NewClient(username, password string, options *ClientOptions) *Client {
 	options = setDefaultOptions(options)
-	return &Client{
+	c := &Client{
 		HTTPClient: &http.Client{
-			Timeout: *options.HTTPTimeout,
-			Transport: &http.Transport{
-				Dial: (&net.Dialer{
-					Timeout: *options.HTTPTimeout,
-				}).Dial,
-				TLSHandshakeTimeout: *options.TLSHandshakeTimeout,
-			},
-			CheckRedirect: func(req *http.Request, via []*http.Request) error {
-				return nil
-			},
+			Timeout:       *options.HTTPTimeout,
+			Transport:     newTransport(options),
+			CheckRedirect: checkRedirect,
 		},
-		username: username,
-		password: password,
-		options:  options,
+		username:  username,
+		password:  password,
+		events:    &eventLog{},
+		uploads:   &pendingUploads{},
+		reports:   &reportBudget{},
+		cache:     &responseCache{},
+		submitted: &submissionLog{},
+		consumed:  &tokenLedger{},
+
+		endpointHealth: &endpointSet{},
+		upload:         &uploadBucket{},
+	}
+	if options.Sandbox != nil {
+		c.HTTPClient.Transport = NewSandboxTransport(*options.Sandbox)
 	}
+	if options.FaultInjector != nil {
+		c.HTTPClient.Transport = options.FaultInjector.Wrap(c.HTTPClient.Transport)
+	}
+	c.options.Store(options)
+	return c
+}
+
+//opts returns the current options of the client
+func (c *Client) opts() *ClientOptions {
+	return c.options.Load().(*ClientOptions)
+}
+
+func newTransport(options *ClientOptions) *http.Transport {
+	return &http.Transport{
+		DialContext:         newDialContext(options),
+		TLSHandshakeTimeout: *options.TLSHandshakeTimeout,
+	}
+}
+
+/*WithHTTPClient makes the client use the given http client, e.g. one with an instrumented transport
+  The library's settings are layered only where the given client leaves them unset: timeout, redirect policy, transport, and for an *http.Transport its dialer and TLS handshake timeout.
+  The given client and transport are copied, not modified
+*/
+func (c *Client) WithHTTPClient(httpClient *http.Client) *Client {
+	layered := *httpClient
+	if layered.Timeout == 0 {
+		layered.Timeout = *c.opts().HTTPTimeout
+	}
+	if layered.CheckRedirect == nil {
+		layered.CheckRedirect = checkRedirect
+	}
+
+	switch transport := layered.Transport.(type) {
+	case nil:
+		layered.Transport = newTransport(c.opts())
+	case *http.Transport:
+		transport = transport.Clone()
+		if transport.Dial == nil && transport.DialContext == nil {
+			transport.DialContext = newDialContext(c.opts())
+		}
+		if transport.TLSHandshakeTimeout == 0 {
+			transport.TLSHandshakeTimeout = *c.opts().TLSHandshakeTimeout
+		}
+		layered.Transport = transport
+	}
+
+	c.HTTPClient = &layered
+	return c
 }
 
 func setDefaultOptions(options *ClientOptions) *ClientOptions {
@@ -157,6 +430,12 @@ func setDefaultOptions(options *ClientOptions) *ClientOptions {
 	} else {
 		newOptions.Endpoint = options.Endpoint
 	}
+	newOptions.Mirrors = options.Mirrors
+	newOptions.Resolver = options.Resolver
+	newOptions.DNSCacheTTL = options.DNSCacheTTL
+	newOptions.IPFamily = options.IPFamily
+	newOptions.FallbackDelay = options.FallbackDelay
+	newOptions.LocalAddr = options.LocalAddr
 
 	if options.HTTPTimeout == nil {
 		d := time.Second * 30
@@ -178,28 +457,70 @@ func setDefaultOptions(options *ClientOptions) *ClientOptions {
 		newOptions.CaptchaRetries = options.CaptchaRetries
 	}
 
+	newOptions.AdaptivePolling = options.AdaptivePolling
+	newOptions.IdempotentSubmit = options.IdempotentSubmit
+	newOptions.ReportPolicy = options.ReportPolicy
+	newOptions.AutoResolve = options.AutoResolve
+	newOptions.Profiles = options.Profiles
+	newOptions.Sandbox = options.Sandbox
+	newOptions.FaultInjector = options.FaultInjector
+	newOptions.PreSolver = options.PreSolver
+	newOptions.PreSolverMinConfidence = options.PreSolverMinConfidence
+	newOptions.Preprocessors = options.Preprocessors
+	newOptions.PostProcessors = options.PostProcessors
+	newOptions.ValidationRetries = options.ValidationRetries
+	newOptions.InvalidRetries = options.InvalidRetries
+	newOptions.PropagateDeadline = options.PropagateDeadline
+	newOptions.CompressRequests = options.CompressRequests
+	newOptions.CompressMinSize = options.CompressMinSize
+	newOptions.CacheTTL = options.CacheTTL
+	newOptions.StaleFor = options.StaleFor
+	newOptions.Limiter = options.Limiter
+	newOptions.AuditSink = options.AuditSink
+	newOptions.AuditRawContent = options.AuditRawContent
+	newOptions.Privacy = options.Privacy
+	newOptions.Clock = options.Clock
+	newOptions.NewCorrelationID = options.NewCorrelationID
+	newOptions.CorrelateErrors = options.CorrelateErrors
+	newOptions.UploadRate = options.UploadRate
+	newOptions.ErrorSink = options.ErrorSink
+	newOptions.StatusPolicy = options.StatusPolicy
+	newOptions.OnRequestTrace = options.OnRequestTrace
+	newOptions.TraceErrors = options.TraceErrors
+	newOptions.JobStore = options.JobStore
+	newOptions.OnTransition = options.OnTransition
+	newOptions.OnEvent = options.OnEvent
+
 	return newOptions
 }
 
 //CaptchaFromURL will make a captcha call from an image url
 func (c *Client) CaptchaFromURL(url string) (*CaptchaResponse, error) {
+	return c.CaptchaFromURLWithOptions(url, nil)
+}
+
+/*CaptchaFromURLWithOptions will make a captcha call from an image url
+  download: cookie jar, redirect limit and referer of the download, may be nil
+*/
+func (c *Client) CaptchaFromURLWithOptions(url string, download *DownloadOptions) (*CaptchaResponse, error) {
 	request, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return c.CaptchaFromHTTPRequest(request)
+	return c.CaptchaFromHTTPRequestWithOptions(request, download)
 }
 
-//CaptchaFromHTTPRequest will make a captcha call from an http request
+//CaptchaFromHTTPRequest will make a captcha call from an http request, failed downloads are returned as a *DownloadError
 func (c *Client) CaptchaFromHTTPRequest(request *http.Request) (*CaptchaResponse, error) {
-	response, err := c.HTTPClient.Do(request)
-	if err != nil {
-		return nil, err
-	}
+	return c.CaptchaFromHTTPRequestWithOptions(request, nil)
+}
 
-	defer response.Body.Close()
-	body, err := ioutil.ReadAll(response.Body)
+/*CaptchaFromHTTPRequestWithOptions will make a captcha call from an http request, failed downloads are returned as a *DownloadError
+  download: cookie jar, redirect limit and referer of the download, may be nil
+*/
+func (c *Client) CaptchaFromHTTPRequestWithOptions(request *http.Request, download *DownloadOptions) (*CaptchaResponse, error) {
+	body, err := c.download(request, download)
 	if err != nil {
 		return nil, err
 	}
@@ -219,55 +540,113 @@ func (c *Client) CaptchaFromFile(filepath string) (*CaptchaResponse, error) {
 
 //Captcha will make a captcha call from a byte slice
 func (c *Client) Captcha(content []byte) (*CaptchaResponse, error) {
-	if !c.isValidFormat(content) {
-		return nil, ErrInvalidFormat
+	return c.CaptchaWithOptions(context.Background(), content, nil)
+}
+
+//CaptchaWithOptions will make a captcha call from a byte slice, sending solving hints along. Options may be nil
+func (c *Client) CaptchaWithOptions(ctx context.Context, content []byte, options *CaptchaOptions) (*CaptchaResponse, error) {
+	if local := c.preSolve(ctx, content); local != nil {
+		return local, nil
 	}
+	return c.submitImage(ctx, content, nil, options)
+}
 
-	urlReq, err := c.options.Endpoint.Parse(`captcha`)
+//submitImage preprocesses and uploads an image captcha, see Preprocessors
+func (c *Client) submitImage(ctx context.Context, content []byte, fields url.Values, options *CaptchaOptions) (*CaptchaResponse, error) {
+	processed, err := c.preprocess(content)
 	if err != nil {
 		return nil, err
 	}
+	if c.opts().Privacy && len(processed) > 0 && len(content) > 0 && &processed[0] != &content[0] {
+		//the processed copy is ours, the caller keeps the original to retry with
+		defer wipe(processed)
+	}
+	return c.uploadImage(ctx, processed, fields, options)
+}
 
-	postBody := &bytes.Buffer{}
-	writer := multipart.NewWriter(postBody)
-	err = writer.WriteField("username", c.username)
-	if err != nil {
-		return nil, err
+//uploadImage uploads an image captcha, the response remembers how to upload it again
+func (c *Client) uploadImage(ctx context.Context, content []byte, fields url.Values, options *CaptchaOptions) (*CaptchaResponse, error) {
+	ctx = withCaptchaType(c.correlate(ctx, ""), TypeImage.String())
+	if captchaType := fields.Get("type"); captchaType != "" {
+		ctx = withCaptchaType(ctx, captchaType)
 	}
-	err = writer.WriteField("password", c.password)
+	response, err := c.sendImage(ctx, content, fields, options)
 	if err != nil {
-		return nil, err
+		return nil, c.correlateError(ctx, 0, err)
 	}
-	w, err := writer.CreateFormFile("captchafile", "captcha")
-	if err != nil {
-		return nil, err
+	response.Format = DetectFormat(content)
+	c.audit(ctx, AuditSubmit, response, content, nil)
+	if c.opts().Privacy {
+		return response, nil
 	}
-	_, err = w.Write(content)
-	if err != nil {
-		return nil, err
+	response.resubmit = func(ctx context.Context) (*CaptchaResponse, error) {
+		return c.uploadImage(ctx, content, fields, options)
 	}
-	err = writer.Close()
+
+	return response, nil
+}
+
+func (c *Client) sendImage(ctx context.Context, content []byte, fields url.Values, options *CaptchaOptions) (*CaptchaResponse, error) {
+	if !c.opts().IdempotentSubmit || isDuplicate(ctx) {
+		req, err := c.buildImageRequest(ctx, content, fields, options)
+		if err != nil {
+			return nil, err
+		}
+		return c.submit(ctx, req)
+	}
+
+	key := uploadKey(content)
+	if pending := c.uploads.get(key, c.now()); pending != nil {
+		return pending, nil
+	}
+	response, err := c.sendReconciled(ctx, c.now(), func(ctx context.Context) (*http.Request, error) {
+		return c.buildImageRequest(ctx, content, fields, options)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(`POST`, urlReq.String(), postBody)
+	c.uploads.put(key, response, c.now())
+	return response, nil
+}
+
+//submit sends a captcha submission request
+func (c *Client) submit(ctx context.Context, req *http.Request) (*CaptchaResponse, error) {
+	submittedAt := c.now()
+	header, body, err := c.doRequest(req)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", writer.FormDataContentType())
 
-	body, err := c.makeRequest(req)
-	response := &CaptchaResponse{}
-	err = json.Unmarshal(body, &response)
-	if err != nil {
-		return nil, ErrUnexpectedServerResponse
+	return c.parseSubmission(req.Context(), header, body, submittedAt)
+}
+
+//parseSubmission decodes a submission response, a redirect to the poll url is read from its Location when its body does not carry the captcha
+func (c *Client) parseSubmission(ctx context.Context, header http.Header, body []byte, submittedAt time.Time) (*CaptchaResponse, error) {
+	response, err := parse[CaptchaResponse](body, header.Get("Content-Type"))
+	if id, ok := c.captchaIDFromLocation(header.Get("Location")); ok {
+		if err == ErrUnexpectedServerResponse {
+			response, err = &CaptchaResponse{ID: id, IsCorrect: true, Status: int(StatusOK)}, nil
+		} else if err == nil && response.ID == 0 {
+			response.ID = id
+		}
 	}
-	if response.Status == 255 {
-		return nil, fmt.Errorf("Generic error from service: %s", response.Error)
+	if err != nil {
+		return nil, c.reportError(ctx, err)
 	}
+	return c.registerSubmission(ctx, response, header.Get("Location"), submittedAt), nil
+}
 
-	return response, nil
+//registerSubmission records a captcha submitted by the client, location being the Location header of its submission response
+func (c *Client) registerSubmission(ctx context.Context, response *CaptchaResponse, location string, submittedAt time.Time) *CaptchaResponse {
+	response.SubmittedAt = submittedAt
+	response.CorrelationID = CorrelationIDFrom(ctx)
+	response.captchaType, _ = ctx.Value(captchaTypeKey{}).(string)
+	c.describe(response, location)
+	c.submitted.submit(response.ID, submittedAt)
+
+	c.emit(ctx, EventSubmitted, response.ID, nil)
+	return response
 }
 
 /*RecaptchaWithoutProxy will make a recaptcha by token call, without providing a proxy
@@ -285,129 +664,313 @@ func (c *Client) RecaptchaWithoutProxy(pageurl, googlekey string) (*CaptchaRespo
   proxyType: type of the proxy
 */
 func (c *Client) Recaptcha(pageurl, googlekey, proxy, proxyType string) (*CaptchaResponse, error) {
-	urlReq, err := c.options.Endpoint.Parse(`captcha`)
+	return c.RecaptchaWithPayload(context.Background(), RecaptchaRequestPayload{
+		PageURL:   pageurl,
+		GoogleKey: googlekey,
+		Proxy:     proxy,
+		ProxyType: proxyType,
+	})
+}
+
+//RecaptchaWithPayload will make a recaptcha by token call with a full payload, e.g. to pass the target session's cookies and user agent
+func (c *Client) RecaptchaWithPayload(ctx context.Context, payload RecaptchaRequestPayload) (*CaptchaResponse, error) {
+	var err error
+	payload.Proxy, payload.ProxyType, err = normalizeProxy(payload.Proxy, payload.ProxyType)
 	if err != nil {
 		return nil, err
 	}
+	return c.submitToken(ctx, TypeRecaptchaV2, payload)
+}
 
-	v := url.Values{}
-	v.Set("username", c.username)
-	v.Set("password", c.password)
-	v.Set("type", "4")
-
-	payload := RecaptchaRequestPayload{
-		PageURL:   pageurl,
-		GoogleKey: googlekey,
+/*RecaptchaEnterprise will make a recaptcha by token call for a Google Enterprise v2 challenge (Gmail, YouTube style)
+  dataS: the data-s token found next to the sitekey, may be empty
+*/
+func (c *Client) RecaptchaEnterprise(ctx context.Context, payload RecaptchaRequestPayload, dataS string) (*CaptchaResponse, error) {
+	payload.Enterprise = true
+	if dataS != "" {
+		payload.DataS = dataS
 	}
+	return c.RecaptchaWithPayload(ctx, payload)
+}
 
-	if proxy != "" {
-		payload.Proxy = proxy
-		if proxyType == "" {
-			payload.ProxyType = RecaptchaProxyTypeHTTP
-		} else {
-			payload.ProxyType = proxyType
-		}
-	}
+//RecaptchaInvisible will make a recaptcha by token call for an invisible reCAPTCHA challenge
+func (c *Client) RecaptchaInvisible(ctx context.Context, payload RecaptchaRequestPayload) (*CaptchaResponse, error) {
+	payload.Invisible = true
+	return c.RecaptchaWithPayload(ctx, payload)
+}
 
-	payloadBytes, err := json.Marshal(payload)
+//Hcaptcha will make a hcaptcha call
+func (c *Client) Hcaptcha(ctx context.Context, payload HcaptchaRequestPayload) (*CaptchaResponse, error) {
+	var err error
+	payload.Proxy, payload.ProxyType, err = normalizeProxy(payload.Proxy, payload.ProxyType)
 	if err != nil {
 		return nil, err
 	}
+	return c.submitToken(ctx, TypeHcaptcha, payload)
+}
 
-	v.Set("token_params", string(payloadBytes))
+/*TextCaptcha will solve a text captcha (a plain question such as "What is 2+2?") and return its answer
+  question: the question to be answered
+  lang: language of the question, e.g. "en", may be empty
+*/
+func (c *Client) TextCaptcha(ctx context.Context, question, lang string) (string, error) {
+	v := url.Values{}
+	v.Set("username", c.username)
+	v.Set("password", c.password)
+	v.Set("type", TypeText.String())
+	v.Set("textcaptcha", question)
+	if lang != "" {
+		v.Set("language", lang)
+	}
 
-	req, err := http.NewRequest(`POST`, urlReq.String(), strings.NewReader(v.Encode()))
+	ressource, err := c.submitForm(ctx, v)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	req.Header.Add("content-type", "application/x-www-form-urlencoded")
-
-	body, err := c.makeRequest(req)
-	response := &CaptchaResponse{}
-	err = json.Unmarshal(body, &response)
+	resolved, err := c.WaitCaptchaWithContext(ctx, ressource)
 	if err != nil {
-		return nil, ErrUnexpectedServerResponse
+		return "", err
 	}
-	if response.Status == 255 {
-		return nil, fmt.Errorf("Generic error from service: %s", response.Error)
+
+	return resolved.Text, nil
+}
+
+func (c *Client) submitForm(ctx context.Context, v url.Values) (*CaptchaResponse, error) {
+	ctx = withCaptchaType(c.correlate(ctx, ""), v.Get("type"))
+	req, err := c.buildFormRequest(ctx, v)
+	if err != nil {
+		return nil, c.correlateError(ctx, 0, err)
 	}
 
-	return response, nil
+	response, err := c.submit(ctx, req)
+	return response, c.correlateError(ctx, 0, err)
 }
 
 //PollCaptcha will make a captcha poll call
 func (c *Client) PollCaptcha(ressource *CaptchaResponse) (*CaptchaResponse, error) {
-	urlReq, err := c.options.Endpoint.Parse(fmt.Sprintf(`captcha/%d`, ressource.ID))
+	return c.PollCaptchaWithContext(context.Background(), ressource)
+}
+
+//PollCaptchaWithContext will make a captcha poll call, bound to the given context
+func (c *Client) PollCaptchaWithContext(ctx context.Context, ressource *CaptchaResponse) (*CaptchaResponse, error) {
+	ctx, urlReq, err := c.route(ctx, RoutePoll, ressource.ID)
 	if err != nil {
 		return nil, err
 	}
-	req, err := http.NewRequest(`GET`, urlReq.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, `GET`, urlReq.String(), nil)
 	if err != nil {
 		return nil, err
 	}
 
-	body, err := c.makeRequest(req)
-	response := &CaptchaResponse{}
-	err = json.Unmarshal(body, &response)
+	response, err := do[CaptchaResponse](c, req)
 	if err != nil {
-		return nil, ErrUnexpectedServerResponse
+		return nil, err
 	}
-	if response.Status == 255 {
-		return nil, fmt.Errorf("Generic error from service: %s", response.Error)
+	if err := checkPoll(response); err != nil {
+		return nil, c.reportError(ctx, err)
 	}
-
-	if !response.IsCorrect {
-		return nil, ErrCaptchaInvalid
+	response.SubmittedAt = ressource.SubmittedAt
+	response.token = ressource.token
+	response.resubmit = ressource.resubmit
+	response.Format = ressource.Format
+	response.retries = ressource.retries
+	response.validation = ressource.validation
+	response.CorrelationID = ressource.CorrelationID
+	response.captchaType = ressource.captchaType
+	response.rawAnswer = ressource.rawAnswer
+	response.PollURL, response.ReportURL = ressource.PollURL, ressource.ReportURL
+	if response.PollURL == "" {
+		c.describe(response, "")
 	}
+	response.client = c
 
 	return response, nil
 }
 
 //WaitCaptcha will wait for a captcha to be solved
 func (c *Client) WaitCaptcha(ressource *CaptchaResponse) (*CaptchaResponse, error) {
-	for i := 1; i <= c.options.CaptchaRetries; i++ {
-		time.Sleep(time.Duration(i) * time.Second)
-		response, err := c.PollCaptcha(ressource)
+	return c.WaitCaptchaWithProgress(context.Background(), ressource, nil)
+}
+
+//WaitCaptchaWithContext will wait for a captcha to be solved, or for the context to be done
+func (c *Client) WaitCaptchaWithContext(ctx context.Context, ressource *CaptchaResponse) (*CaptchaResponse, error) {
+	return c.WaitCaptchaWithProgress(ctx, ressource, nil)
+}
+
+/*WaitCaptchaWithProgress will wait for a captcha to be solved, or for the context to be done
+  progress: called before each poll with the attempt number (starting at 1) and the time elapsed since the wait started, may be nil
+*/
+func (c *Client) WaitCaptchaWithProgress(ctx context.Context, ressource *CaptchaResponse, progress func(attempt int, elapsed time.Duration)) (*CaptchaResponse, error) {
+	ctx = withCaptchaType(c.correlate(ctx, ressource.CorrelationID), ressource.captchaType)
+	solved, err := c.waitCaptcha(ctx, ressource, progress)
+	if err != nil {
+		c.audit(ctx, AuditResult, ressource, nil, err)
+		return nil, c.correlateError(ctx, ressource.ID, err)
+	}
+	c.audit(ctx, AuditResult, solved, nil, nil)
+	return solved, nil
+}
+
+//waitCaptcha waits for a captcha to be solved, submitting it again when it is invalid or its answer is rejected, see SolveState
+func (c *Client) waitCaptcha(ctx context.Context, ressource *CaptchaResponse, progress func(attempt int, elapsed time.Duration)) (*CaptchaResponse, error) {
+	return c.newSolveMachine(ctx, ressource).run(progress)
+}
+
+//waitSolved polls a captcha until it is solved
+func (c *Client) waitSolved(ctx context.Context, ressource *CaptchaResponse, progress func(attempt int, elapsed time.Duration)) (*CaptchaResponse, error) {
+	if ressource.Local {
+		return ressource, nil
+	}
+
+	start := time.Now()
+	firstDelay := time.Second
+	if c.opts().AdaptivePolling {
+		firstDelay = c.firstPollDelay(ctx, ressource)
+	}
+	retryAfter := time.Duration(0)
+	retries := c.opts().CaptchaRetries
+	if ressource.retries > retries {
+		retries = ressource.retries
+	}
+	for i := 1; i <= retries; i++ {
+		delay := time.Duration(i) * time.Second
+		if i == 1 {
+			delay = firstDelay
+		}
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		if progress != nil {
+			progress(i, time.Since(start))
+		}
+		response, err := c.PollCaptchaWithContext(withAttempt(ctx, i), ressource)
+		retryAfter = RetryAfter(err)
 		if err != nil {
 			if err == ErrCaptchaInvalid {
+				c.emit(ctx, EventFailed, ressource.ID, err)
 				return nil, err
 			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
 			continue
 		}
 		if response.IsCorrect && response.Text != "" {
+			if response.token != nil {
+				response.ExpiresAt = time.Now().Add(TokenLifetime)
+			}
+			if !ressource.SubmittedAt.IsZero() {
+				atomic.AddInt64(&c.counters.solveLatency, int64(time.Since(ressource.SubmittedAt)))
+			}
+			c.emit(ctx, EventSolved, ressource.ID, nil)
 			return response, nil
 		}
 	}
+	c.emit(ctx, EventFailed, ressource.ID, ErrCaptchaTimeout)
 	return nil, ErrCaptchaTimeout
 }
 
+//firstPollDelay returns how long to wait before polling a captcha for the first time, based on the service's average solve time
+func (c *Client) firstPollDelay(ctx context.Context, ressource *CaptchaResponse) time.Duration {
+	status, err := c.StatusWithContext(ctx)
+	if err != nil || status.SolvedIn <= 0 {
+		return time.Second
+	}
+
+	delay := time.Duration(status.SolvedIn*float64(time.Second)) - ressource.Age()
+	if delay < time.Second {
+		return time.Second
+	}
+	return delay
+}
+
 //ReportCaptcha will report a captcha as incorrectly solved
 func (c *Client) ReportCaptcha(ressource *CaptchaResponse) (*CaptchaResponse, error) {
-	urlReq, err := c.options.Endpoint.Parse(fmt.Sprintf(`captcha/%d/report`, ressource.ID))
+	return c.ReportCaptchaWithContext(context.Background(), ressource)
+}
+
+//ReportCaptchaWithContext will report a captcha as incorrectly solved, bound to the given context
+func (c *Client) ReportCaptchaWithContext(ctx context.Context, ressource *CaptchaResponse) (*CaptchaResponse, error) {
+	return c.reportCaptcha(ctx, ressource, false)
+}
+
+//reportCaptcha reports a captcha if the report policy allows it. fromValidator tells the report comes from the client's answer validation
+func (c *Client) reportCaptcha(ctx context.Context, ressource *CaptchaResponse, fromValidator bool) (*CaptchaResponse, error) {
+	if ressource.Local {
+		return ressource, nil
+	}
+	ctx = withCaptchaType(c.correlate(ctx, ressource.CorrelationID), ressource.captchaType)
+	if !c.inReportWindow(ressource) {
+		return nil, ErrReportWindowExpired
+	}
+	if !c.reports.allow(c.opts().ReportPolicy, fromValidator, c.now()) {
+		return nil, ErrReportRefused
+	}
+
+	response, err := c.sendReport(ctx, ressource)
+	if err != nil {
+		c.reports.release()
+		return nil, err
+	}
+
+	c.emit(ctx, EventReported, ressource.ID, nil)
+	c.audit(ctx, AuditReport, ressource, nil, nil)
+	return response, nil
+}
+
+func (c *Client) sendReport(ctx context.Context, ressource *CaptchaResponse) (*CaptchaResponse, error) {
+	ctx, urlReq, err := c.route(ctx, RouteReport, ressource.ID)
 	if err != nil {
 		return nil, err
 	}
-	req, err := http.NewRequest(`GET`, urlReq.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, `GET`, urlReq.String(), nil)
 	if err != nil {
 		return nil, err
 	}
 
-	body, err := c.makeRequest(req)
-	response := &CaptchaResponse{}
-	err = json.Unmarshal(body, &response)
+	response, err := do[CaptchaResponse](c, req)
 	if err != nil {
-		return nil, ErrUnexpectedServerResponse
+		return nil, err
+	}
+	return c.describe(response, ""), nil
+}
+
+//User will retrieve user information
+func (c *Client) User() (*UserResponse, error) {
+	return c.UserWithContext(context.Background())
+}
+
+//UserWithContext will retrieve user information, bound to the given context
+func (c *Client) UserWithContext(ctx context.Context) (*UserResponse, error) {
+	if cached, ok := c.cache.user.get(ctx, c.opts().CacheTTL).(*UserResponse); ok {
+		response := *cached
+		return &response, nil
 	}
-	if response.Status == 255 {
-		return nil, fmt.Errorf("Generic error from service: %s", response.Error)
+
+	response, err := c.fetchUser(ctx)
+	if err != nil {
+		if stale, ok := c.cache.user.stale(ctx, err, c.opts().StaleFor).(*UserResponse); ok {
+			response := *stale
+			response.Stale = true
+			return &response, nil
+		}
+		return nil, err
 	}
 
+	cached := *response
+	c.cache.user.put(&cached)
 	return response, nil
 }
 
-//User will retrieve user information
-func (c *Client) User() (*UserResponse, error) {
-	urlReq, err := c.options.Endpoint.Parse(`user`)
+func (c *Client) fetchUser(ctx context.Context) (*UserResponse, error) {
+	ctx, urlReq, err := c.route(ctx, RouteUser, 0)
 	if err != nil {
 		return nil, err
 	}
@@ -415,88 +978,133 @@ func (c *Client) User() (*UserResponse, error) {
 	v.Set("username", c.username)
 	v.Set("password", c.password)
 	urlReq.RawQuery = v.Encode()
-	req, err := http.NewRequest(`GET`, urlReq.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, `GET`, urlReq.String(), nil)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Add("content-type", "application/x-www-form-urlencoded")
 
-	body, err := c.makeRequest(req)
-	response := &UserResponse{}
-	err = json.Unmarshal(body, &response)
+	response, err := do[UserResponse](c, req)
 	if err != nil {
-		return nil, ErrUnexpectedServerResponse
-	}
-	if response.Status == 255 {
-		return nil, fmt.Errorf("Generic error from service: %s", response.Error)
+		return nil, err
 	}
 
+	atomic.StoreUint64(&c.counters.rateBits, math.Float64bits(response.Rate))
 	return response, nil
 }
 
 //Status will retrieve status information
 func (c *Client) Status() (*StatusResponse, error) {
-	urlReq, err := c.options.Endpoint.Parse(`status`)
-	if err != nil {
-		return nil, err
+	return c.StatusWithContext(context.Background())
+}
+
+//StatusWithContext will retrieve status information, bound to the given context
+func (c *Client) StatusWithContext(ctx context.Context) (*StatusResponse, error) {
+	if cached, ok := c.cache.status.get(ctx, c.opts().CacheTTL).(*StatusResponse); ok {
+		response := *cached
+		return &response, nil
 	}
-	req, err := http.NewRequest(`GET`, urlReq.String(), nil)
+
+	response, err := c.fetchStatus(ctx)
 	if err != nil {
+		if stale, ok := c.cache.status.stale(ctx, err, c.opts().StaleFor).(*StatusResponse); ok {
+			response := *stale
+			response.Stale = true
+			return &response, nil
+		}
 		return nil, err
 	}
 
-	body, err := c.makeRequest(req)
-	response := &StatusResponse{}
-	err = json.Unmarshal(body, &response)
+	cached := *response
+	c.cache.status.put(&cached)
+	return response, nil
+}
+
+func (c *Client) fetchStatus(ctx context.Context) (*StatusResponse, error) {
+	req, err := c.statusRequest(ctx)
 	if err != nil {
-		return nil, ErrUnexpectedServerResponse
-	}
-	if response.Status == 255 {
-		return nil, fmt.Errorf("Generic error from service: %s", response.Error)
+		return nil, err
 	}
 
-	return response, nil
+	return do[StatusResponse](c, req)
 }
 
-func (c *Client) makeRequest(request *http.Request) ([]byte, error) {
-	request.Header.Add(`Accept`, `application/json`)
-	resp, err := c.HTTPClient.Do(request)
+func (c *Client) statusRequest(ctx context.Context) (*http.Request, error) {
+	ctx, urlReq, err := c.route(ctx, RouteStatus, 0)
 	if err != nil {
 		return nil, err
 	}
+	return http.NewRequestWithContext(ctx, `GET`, urlReq.String(), nil)
+}
 
-	defer resp.Body.Close()
-
-	if resp.StatusCode == 403 {
-		return nil, ErrCredentialsRejected
+//doRequest sends an api request, returning the response headers along the body. Errors are reported to the ErrorSink
+func (c *Client) doRequest(request *http.Request) (http.Header, []byte, error) {
+	if len(c.opts().Mirrors) == 0 {
+		header, body, err := c.attempt(request)
+		return header, body, c.reportError(request.Context(), err)
 	}
-	if resp.StatusCode == 400 {
-		return nil, ErrCaptchaRejected
+
+	var header http.Header
+	var body []byte
+	var err error
+	for _, target := range c.failoverTargets(request) {
+		start := time.Now()
+		header, body, err = c.attempt(target)
+		if endpoint := c.endpointOf(target.URL); endpoint != nil {
+			c.endpointHealth.observe(endpoint, time.Since(start), endpointFailed(target, err))
+		}
+		if !failsOverEndpoint(target, err) {
+			break
+		}
 	}
-	if resp.StatusCode == 500 {
-		return nil, ErrUnexpectedServerError
+	return header, body, c.reportError(request.Context(), err)
+}
+
+//attempt sends an api request once, tracing it
+func (c *Client) attempt(request *http.Request) (http.Header, []byte, error) {
+	request, tracer := c.traceRequest(request)
+	resp, body, err := c.sendRequest(request)
+	var header http.Header
+	statusCode := 0
+	if resp != nil {
+		header, statusCode = resp.Header, resp.StatusCode
 	}
-	if resp.StatusCode == 503 {
-		if regexp.MustCompile(`captcha$`).MatchString(request.URL.Path) {
-			return nil, ErrOverloadedServer
-		}
-		if regexp.MustCompile(`report$`).MatchString(request.URL.Path) {
-			return nil, ErrReportRejected
+	return header, body, tracer.finish(c, request, statusCode, header, err)
+}
+
+//sendRequest sends an api request, the response is returned with its body read and closed
+func (c *Client) sendRequest(request *http.Request) (*http.Response, []byte, error) {
+	if limiter := c.opts().Limiter; limiter != nil {
+		if err := limiter.Wait(request.Context()); err != nil {
+			return nil, nil, err
 		}
-		return nil, ErrUnexpectedServerResponse
 	}
 
-	body, err := ioutil.ReadAll(resp.Body)
+	ctx, cancel := context.WithTimeout(request.Context(), *c.opts().HTTPTimeout)
+	defer cancel()
+	request = request.WithContext(ctx)
+
+	request.Header.Add(`Accept`, `application/json`)
+	if id := CorrelationIDFrom(request.Context()); id != "" {
+		request.Header.Set(CorrelationHeader, id)
+	}
+	request.Body = c.meter(ctx, request.Body)
+	resp, err := c.HTTPClient.Do(request)
 	if err != nil {
-		return nil, err
+		return nil, nil, redactURLError(err)
 	}
 
-	return body, nil
-}
+	defer resp.Body.Close()
 
-func (c *Client) isValidFormat(content []byte) bool {
-	if bytes.Compare(content[0:3], []byte{255, 216, 255}) == 0 /*jpg*/ || bytes.Compare(content[0:8], []byte{137, 80, 78, 71, 13, 10, 26, 10}) == 0 /*png*/ || bytes.Compare(content[0:3], []byte{71, 73, 70}) == 0 /*gif*/ || bytes.Compare(content[0:2], []byte{66, 77}) == 0 /*bmp*/ {
-		return true
+	if err := c.statusError(request, resp); err != nil {
+		return resp, nil, err
+	}
+
+	body, err := readBody(resp.Body)
+	if err != nil {
+		return resp, nil, err
 	}
-	return false
+	atomic.AddInt64(&c.counters.downloaded, int64(len(body)))
+
+	return resp, body, nil
 }
@@ -0,0 +1,22 @@
+package godbc
+
+import "time"
+
+//Clock is the time source of the client's report window and report policy, so tests and replays can move time forward
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+//now returns the time of the client's Clock
+func (c *Client) now() time.Time {
+	if clock := c.opts().Clock; clock != nil {
+		return clock.Now()
+	}
+	return systemClock{}.Now()
+}
@@ -0,0 +1,87 @@
+package godbc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+)
+
+//defaultCompressMinSize is the body size from which requests are compressed when CompressMinSize is unset
+const defaultCompressMinSize = 8 * 1024
+
+//multipartOverhead is roughly the size of a submission body without its image, to size the body buffer once
+const multipartOverhead = 1024
+
+//newPostRequest returns an api request posting body, gzipped when the client compresses requests
+func (c *Client) newPostRequest(ctx context.Context, url string, body []byte, contentType string) (*http.Request, error) {
+	compressed, err := c.compress(body)
+	if err != nil {
+		return nil, err
+	}
+	if compressed != nil {
+		if c.opts().Privacy {
+			wipe(body)
+		}
+		body = compressed
+	}
+
+	req, err := http.NewRequestWithContext(ctx, `POST`, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if compressed != nil {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if c.opts().Privacy {
+		req.Body, req.GetBody = newWipingBody(body), nil
+	}
+
+	return req, nil
+}
+
+//compresses returns true when a body of the given size is sent gzipped
+func (c *Client) compresses(size int) bool {
+	minSize := c.opts().CompressMinSize
+	if minSize <= 0 {
+		minSize = defaultCompressMinSize
+	}
+	return c.opts().CompressRequests && size >= minSize
+}
+
+//compress returns the gzipped body, or nil when it should be sent as is
+func (c *Client) compress(body []byte) ([]byte, error) {
+	if !c.compresses(len(body)) {
+		return nil, nil
+	}
+
+	compressed := getBuffer()
+	defer putBuffer(compressed)
+	err := gzipped(func(w io.Writer) error {
+		_, err := w.Write(body)
+		return err
+	})(compressed)
+	if err != nil {
+		return nil, err
+	}
+	if compressed.Len() >= len(body) {
+		//keep the smaller body
+		return nil, nil
+	}
+	return append([]byte(nil), compressed.Bytes()...), nil
+}
+
+//gzipped returns write, compressing what it writes
+func gzipped(write func(w io.Writer) error) func(w io.Writer) error {
+	return func(w io.Writer) error {
+		writer := gzipPool.Get().(*gzip.Writer)
+		defer gzipPool.Put(writer)
+		writer.Reset(w)
+		if err := write(writer); err != nil {
+			return err
+		}
+		return writer.Close()
+	}
+}
@@ -0,0 +1,51 @@
+package godbc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestCompressRequests(t *testing.T) {
+	for _, tc := range []struct {
+		options *ClientOptions
+		pageURL string
+		gzipped bool
+	}{
+		{&ClientOptions{CompressRequests: true}, "https://example.com/" + strings.Repeat("a", 10000), true},
+		{&ClientOptions{CompressRequests: true}, "https://example.com/", false},
+		{&ClientOptions{CompressRequests: true, CompressMinSize: 100}, "https://example.com/" + strings.Repeat("a", 200), true},
+		{&ClientOptions{}, "https://example.com/" + strings.Repeat("a", 10000), false},
+	} {
+		client, transport := newMockClient(tc.options, func(req *http.Request, body []byte) *http.Response {
+			return mockResponse(200, `{"captcha": 7, "is_correct": true, "text": "", "status": 0}`)
+		})
+		if _, err := client.Hcaptcha(context.Background(), HcaptchaRequestPayload{PageURL: tc.pageURL, SiteKey: "key"}); err != nil {
+			t.Fatal(err)
+		}
+
+		req, body := transport.last(t)
+		if gzipped := req.Header.Get("Content-Encoding") == "gzip"; gzipped != tc.gzipped {
+			t.Errorf("%d bytes url: gzipped is %t, want %t", len(tc.pageURL), gzipped, tc.gzipped)
+			continue
+		}
+		if tc.gzipped {
+			reader, err := gzip.NewReader(bytes.NewReader(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if body, err = ioutil.ReadAll(reader); err != nil {
+				t.Fatal(err)
+			}
+		}
+		form, err := url.ParseQuery(string(body))
+		if err != nil || !strings.Contains(form.Get("hcaptcha_params"), tc.pageURL) {
+			t.Errorf("%d bytes url: unexpected form %.100v, %v", len(tc.pageURL), form, err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package godbc
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+//ErrNoConsensus - The duplicate submissions of SolveWithConsensus did not agree on a majority answer
+var ErrNoConsensus = errors.New("Submissions did not agree on an answer")
+
+//Consensus is the majority answer of the duplicate submissions of a captcha, see SolveWithConsensus
+type Consensus struct {
+	//Captcha - the first submission solved with the majority answer
+	Captcha *CaptchaResponse
+	//Votes - how many submissions were solved with the majority answer
+	Votes int
+	//Submissions - every submission, nil for the ones that failed
+	Submissions []*CaptchaResponse
+	//Reported - the IDs of the minority submissions reported as incorrect
+	Reported []int64
+}
+
+/*SolveWithConsensus submits the same image captcha n times, and returns the answer more than half of the solved submissions agree on
+  The minority submissions are reported as incorrect. ErrNoConsensus is returned, and nothing reported, when no answer has a majority
+  This costs n captchas, for workflows where a wrong answer costs more. The pre-solver is not used, and IdempotentSubmit does not merge the submissions
+*/
+func (c *Client) SolveWithConsensus(ctx context.Context, content []byte, n int) (*Consensus, error) {
+	if n < 1 {
+		n = 1
+	}
+	content, err := c.preprocess(content)
+	if err != nil {
+		return nil, err
+	}
+
+	consensus := &Consensus{Submissions: make([]*CaptchaResponse, n)}
+	errs := make([]error, n)
+	//the submissions, and their resubmissions, are independent votes IdempotentSubmit must not merge into one captcha
+	duplicates := withDuplicates(ctx)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		//each submission uploads its own copy, so nothing another one does to its bytes is seen by the others
+		go func(i int, submission []byte) {
+			defer wg.Done()
+			ressource, err := c.uploadImage(duplicates, submission, nil, nil)
+			if err == nil {
+				ressource, err = c.WaitCaptchaWithContext(duplicates, ressource)
+			}
+			consensus.Submissions[i], errs[i] = ressource, err
+		}(i, append([]byte(nil), content...))
+	}
+	wg.Wait()
+
+	solved := 0
+	for _, resolved := range consensus.Submissions {
+		if resolved != nil {
+			solved++
+		}
+	}
+	if solved == 0 {
+		return nil, errs[0]
+	}
+	consensus.Captcha, consensus.Votes = tally(consensus.Submissions)
+	if 2*consensus.Votes <= solved {
+		return nil, ErrNoConsensus
+	}
+
+	for _, resolved := range consensus.Submissions {
+		if resolved == nil || resolved.Text == consensus.Captcha.Text {
+			continue
+		}
+		if _, err := c.reportCaptcha(ctx, resolved, true); err == nil {
+			consensus.Reported = append(consensus.Reported, resolved.ID)
+		}
+	}
+	return consensus, nil
+}
+
+//tally returns the first captcha solved with the most common answer, and how many were. Ties go to the earliest answer. Nil captchas are skipped
+func tally(captchas []*CaptchaResponse) (*CaptchaResponse, int) {
+	votes := map[string]int{}
+	for _, resolved := range captchas {
+		if resolved != nil {
+			votes[resolved.Text]++
+		}
+	}
+	var winner *CaptchaResponse
+	best := 0
+	for _, resolved := range captchas {
+		if resolved != nil && votes[resolved.Text] > best {
+			winner, best = resolved, votes[resolved.Text]
+		}
+	}
+	return winner, best
+}
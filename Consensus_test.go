@@ -0,0 +1,71 @@
+package godbc
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestSolveWithConsensus(t *testing.T) {
+	var mu sync.Mutex
+	answers := []string{"abc", "abd", "abc"}
+	client := newSandboxClient(SandboxConfig{Solve: func(content []byte) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		answer := answers[0]
+		answers = append(answers[1:], answer)
+		return answer, nil
+	}})
+	ctx := context.Background()
+
+	consensus, err := client.SolveWithConsensus(ctx, benchmarkImage(t), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if consensus.Captcha.Text != "abc" || consensus.Votes != 2 || len(consensus.Submissions) != 3 {
+		t.Fatalf("unexpected consensus %+v", consensus)
+	}
+	if len(consensus.Reported) != 1 {
+		t.Fatalf("reported %v, want the minority submission", consensus.Reported)
+	}
+	for _, submission := range consensus.Submissions {
+		if (submission.Text == "abd") != (submission.ID == consensus.Reported[0]) {
+			t.Errorf("submission %d answered %q", submission.ID, submission.Text)
+		}
+	}
+
+	answers = []string{"abc", "abd"}
+	if _, err := client.SolveWithConsensus(ctx, benchmarkImage(t), 2); err != ErrNoConsensus {
+		t.Fatalf("got %v, want ErrNoConsensus", err)
+	}
+}
+
+func TestConsensusDuplicates(t *testing.T) {
+	image := benchmarkImage(t)
+	for _, options := range []ClientOptions{{Privacy: true}, {IdempotentSubmit: true}, {Privacy: true, IdempotentSubmit: true}} {
+		var mu sync.Mutex
+		uploads := 0
+		options.Sandbox = &SandboxConfig{Solve: func(content []byte) (string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			uploads++
+			if !bytes.Equal(content, image) {
+				return "zeroed", nil
+			}
+			return "abc", nil
+		}}
+		client := NewClient("user", "password", &options)
+		consensus, err := client.SolveWithConsensus(context.Background(), append([]byte(nil), image...), 3)
+		if err != nil {
+			t.Fatalf("privacy %t, idempotent %t: %v", options.Privacy, options.IdempotentSubmit, err)
+		}
+		ids := map[int64]bool{}
+		for _, submission := range consensus.Submissions {
+			ids[submission.ID] = true
+		}
+		if uploads != 3 || len(ids) != 3 || consensus.Votes != 3 || consensus.Captcha.Text != "abc" {
+			t.Errorf("privacy %t, idempotent %t: %d uploads, %d captchas and %d votes for %q", options.Privacy, options.IdempotentSubmit, uploads, len(ids), consensus.Votes, consensus.Captcha.Text)
+		}
+	}
+}
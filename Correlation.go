@@ -0,0 +1,82 @@
+package godbc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+//CorrelationHeader is the request header carrying the correlation ID of api calls, to match a solve with the service's logs in support tickets
+const CorrelationHeader = "X-Correlation-ID"
+
+type correlationKey struct{}
+
+//WithCorrelationID returns a context whose submissions are tagged with the given correlation ID, instead of a generated one
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+//CorrelationIDFrom returns the correlation ID of a context, empty if none was set
+func CorrelationIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
+
+//CorrelationError tags an error with the correlation ID of the solve that failed, see ClientOptions.CorrelateErrors
+type CorrelationError struct {
+	CorrelationID string
+	CaptchaID     int64
+	Err           error
+}
+
+func (e *CorrelationError) Error() string {
+	return fmt.Sprintf("%s (correlation ID %s)", e.Err.Error(), e.CorrelationID)
+}
+
+//Unwrap returns the error of the solve
+func (e *CorrelationError) Unwrap() error {
+	return e.Err
+}
+
+//CorrelationIDOf returns the correlation ID an error is tagged with, empty if none
+func CorrelationIDOf(err error) string {
+	var correlated *CorrelationError
+	if errors.As(err, &correlated) {
+		return correlated.CorrelationID
+	}
+	return ""
+}
+
+//newCorrelationID returns a random ID of 16 hex characters
+func newCorrelationID() string {
+	random := make([]byte, 8)
+	rand.Read(random)
+	return hex.EncodeToString(random)
+}
+
+/*correlate returns a context carrying a correlation ID
+  The ID already in the context prevails, then the one of the captcha, otherwise a new one is generated by ClientOptions.NewCorrelationID
+*/
+func (c *Client) correlate(ctx context.Context, known string) context.Context {
+	if CorrelationIDFrom(ctx) != "" {
+		return ctx
+	}
+	if known == "" {
+		if generate := c.opts().NewCorrelationID; generate != nil {
+			known = generate()
+		} else {
+			known = newCorrelationID()
+		}
+	}
+	return WithCorrelationID(ctx, known)
+}
+
+//correlateError tags an error with the context's correlation ID when the client has CorrelateErrors set
+func (c *Client) correlateError(ctx context.Context, captchaID int64, err error) error {
+	if err == nil || !c.opts().CorrelateErrors || CorrelationIDOf(err) != "" {
+		return err
+	}
+	return &CorrelationError{CorrelationID: CorrelationIDFrom(ctx), CaptchaID: captchaID, Err: err}
+}
@@ -0,0 +1,48 @@
+package godbc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestCorrelationID(t *testing.T) {
+	var events []Event
+	var mu sync.Mutex
+	client, transport := newMockClient(&ClientOptions{CorrelateErrors: true, CaptchaRetries: 1, OnEvent: func(e Event) {
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+	}}, func(req *http.Request, body []byte) *http.Response {
+		if req.Method == `POST` {
+			return mockResponse(200, `{"captcha": 42, "is_correct": true, "text": "", "status": 0}`)
+		}
+		return mockResponse(200, `{"captcha": 42, "is_correct": false, "text": "", "status": 0}`)
+	})
+
+	ctx := WithCorrelationID(context.Background(), "job-1")
+	ressource, err := client.CaptchaWithOptions(ctx, benchmarkImage(t), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ressource.CorrelationID != "job-1" {
+		t.Fatalf("unexpected correlation ID %q", ressource.CorrelationID)
+	}
+	_, err = client.WaitCaptchaWithContext(context.Background(), ressource)
+	if !errors.Is(err, ErrCaptchaInvalid) || CorrelationIDOf(err) != "job-1" {
+		t.Fatalf("got %v, want ErrCaptchaInvalid tagged job-1", err)
+	}
+
+	for _, req := range transport.requests {
+		if req.Header.Get(CorrelationHeader) != "job-1" {
+			t.Errorf("%s %s sent without the correlation ID", req.Method, req.URL.Path)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 2 || events[0].CorrelationID != "job-1" || events[1].CorrelationID != "job-1" {
+		t.Fatalf("unexpected events %+v", events)
+	}
+}
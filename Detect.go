@@ -0,0 +1,70 @@
+package godbc
+
+import (
+	"context"
+	"regexp"
+)
+
+/*Detection is a captcha found in a page by Detect
+  Type: the api type of the captcha, TypeRecaptchaV2, TypeRecaptchaV3, TypeHcaptcha, TypeTurnstile or TypeFuncaptcha
+  SiteKey: the site key of the captcha, the public key for FunCaptcha
+  PageURL: the url of the page it was found in
+*/
+type Detection struct {
+	Type    CaptchaType
+	SiteKey string
+	PageURL string
+}
+
+//sitekeyPattern finds the site key of a captcha type in its first non-empty group
+type sitekeyPattern struct {
+	captchaType CaptchaType
+	re          *regexp.Regexp
+}
+
+var sitekeyPatterns = []sitekeyPattern{
+	{TypeRecaptchaV2, regexp.MustCompile(`class=["'][^"']*g-recaptcha[^"']*["'][^>]*data-sitekey=["']([\w-]+)["']|data-sitekey=["']([\w-]+)["'][^>]*class=["'][^"']*g-recaptcha`)},
+	{TypeRecaptchaV2, regexp.MustCompile(`recaptcha/(?:api2|enterprise)/anchor\?[^"']*\bk=([\w-]+)`)},
+	{TypeRecaptchaV3, regexp.MustCompile(`recaptcha/(?:api|enterprise)\.js\?[^"']*\brender=([\w-]{20,})`)},
+	{TypeRecaptchaV3, regexp.MustCompile(`grecaptcha(?:\.enterprise)?\.execute\(\s*["']([\w-]{20,})["']`)},
+	{TypeHcaptcha, regexp.MustCompile(`class=["'][^"']*h-captcha[^"']*["'][^>]*data-sitekey=["']([\w-]+)["']|data-sitekey=["']([\w-]+)["'][^>]*class=["'][^"']*h-captcha`)},
+	{TypeHcaptcha, regexp.MustCompile(`hcaptcha\.com/[^"']*[?#&]sitekey=([\w-]+)`)},
+	{TypeTurnstile, regexp.MustCompile(`class=["'][^"']*cf-turnstile[^"']*["'][^>]*data-sitekey=["']([\w-]+)["']|data-sitekey=["']([\w-]+)["'][^>]*class=["'][^"']*cf-turnstile`)},
+	{TypeTurnstile, regexp.MustCompile(`turnstile\.render\([^)]*sitekey["']?\s*:\s*["'](0x[\w-]+)["']`)},
+	{TypeFuncaptcha, regexp.MustCompile(`data-pkey=["']([\w-]+)["']`)},
+	{TypeFuncaptcha, regexp.MustCompile(`(?:arkoselabs|funcaptcha)\.com/v2/([\w-]{36})/api\.js`)},
+	{TypeFuncaptcha, regexp.MustCompile(`public_?key["']?\s*[:=]\s*["']([A-F0-9]{8}-[A-F0-9]{4}-[A-F0-9]{4}-[A-F0-9]{4}-[A-F0-9]{12})["']`)},
+}
+
+//Detect returns the captchas found in the html or javascript of a page, each site key once
+func Detect(body []byte, pageurl string) []Detection {
+	var detections []Detection
+	seen := map[Detection]bool{}
+	for _, pattern := range sitekeyPatterns {
+		for _, match := range pattern.re.FindAllSubmatch(body, -1) {
+			for _, group := range match[1:] {
+				if len(group) == 0 {
+					continue
+				}
+				detection := Detection{Type: pattern.captchaType, SiteKey: string(group), PageURL: pageurl}
+				if !seen[detection] {
+					seen[detection] = true
+					detections = append(detections, detection)
+				}
+				break
+			}
+		}
+	}
+	return detections
+}
+
+//Profile returns the profile solving the detected captcha, to register it for the page urls of the site
+func (d Detection) Profile() Profile {
+	return Profile{Type: int(d.Type), SiteKey: d.SiteKey}
+}
+
+//SolveDetection solves a captcha found by Detect, and waits for the solution
+func (c *Client) SolveDetection(ctx context.Context, detection Detection) (*CaptchaResponse, error) {
+	profile := detection.Profile()
+	return c.solveProfile(ctx, &profile, detection.PageURL)
+}
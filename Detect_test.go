@@ -0,0 +1,43 @@
+package godbc
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	page := `<html><body>
+<div class="g-recaptcha" data-sitekey="6LeIxAcTAAAAAJcZVRqyHh71UMIEGNQ_MXjiZKhI"></div>
+<div data-sitekey="10000000-ffff-ffff-ffff-000000000001" class="h-captcha"></div>
+<div class="cf-turnstile" data-sitekey="0x4AAAAAAAC3DHQFLr1GavRN"></div>
+<div id="arkose" data-pkey="476068BF-9607-4799-B53D-966BE91E9B5C"></div>
+<script src="https://www.google.com/recaptcha/api.js?render=6LdyC2cUAAAAACGuDKpXeDorzUDWXmdqeg-xy696"></script>
+<script>turnstile.render('#widget', {sitekey: '0x4AAAAAAAC3DHQFLr1GavRN'});</script>
+</body></html>`
+	want := []Detection{
+		{Type: TypeRecaptchaV2, SiteKey: "6LeIxAcTAAAAAJcZVRqyHh71UMIEGNQ_MXjiZKhI", PageURL: "https://example.com"},
+		{Type: TypeRecaptchaV3, SiteKey: "6LdyC2cUAAAAACGuDKpXeDorzUDWXmdqeg-xy696", PageURL: "https://example.com"},
+		{Type: TypeHcaptcha, SiteKey: "10000000-ffff-ffff-ffff-000000000001", PageURL: "https://example.com"},
+		{Type: TypeTurnstile, SiteKey: "0x4AAAAAAAC3DHQFLr1GavRN", PageURL: "https://example.com"},
+		{Type: TypeFuncaptcha, SiteKey: "476068BF-9607-4799-B53D-966BE91E9B5C", PageURL: "https://example.com"},
+	}
+	got := Detect([]byte(page), "https://example.com")
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	if detections := Detect([]byte(`<form><input name="q"></form>`), ""); len(detections) != 0 {
+		t.Fatalf("unexpected detections %+v", detections)
+	}
+
+	client := newSandboxClient(SandboxConfig{})
+	for _, detection := range got {
+		resolved, err := client.SolveDetection(context.Background(), detection)
+		if err != nil {
+			t.Fatalf("%d: %v", detection.Type, err)
+		}
+		if resolved.Text == "" {
+			t.Fatalf("%d: no token", detection.Type)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package godbc
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+//IPFamily selects the address families the client dials the api over
+type IPFamily int
+
+//IP families
+const (
+	//IPAny - both families, raced as in RFC 6555 with ClientOptions.FallbackDelay
+	IPAny IPFamily = iota
+	//IPPreferV4 - IPv4 first, IPv6 when no IPv4 address could be dialed
+	IPPreferV4
+	//IPPreferV6 - IPv6 first, IPv4 when no IPv6 address could be dialed
+	IPPreferV6
+	//IPv4Only - IPv4 only, e.g. when the host's IPv6 path to the api is broken
+	IPv4Only
+	//IPv6Only - IPv6 only
+	IPv6Only
+)
+
+//dnsCache keeps the addresses of the hosts the client dials, see ClientOptions.DNSCacheTTL
+type dnsCache struct {
+	mu      sync.Mutex
+	entries map[string]dnsEntry
+}
+
+type dnsEntry struct {
+	addrs      []string
+	resolvedAt time.Time
+}
+
+//lookup returns the addresses of a host, from the cache while they are fresh. When the resolver fails, the last addresses are returned however old
+func (c *dnsCache) lookup(ctx context.Context, resolver *net.Resolver, host string, ttl time.Duration) ([]string, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[host]
+	c.mu.Unlock()
+	if ok && time.Since(entry.resolvedAt) < ttl {
+		return entry.addrs, nil
+	}
+
+	addrs, err := resolver.LookupHost(ctx, host)
+	if err != nil {
+		if ok {
+			return entry.addrs, nil
+		}
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[string]dnsEntry{}
+	}
+	c.entries[host] = dnsEntry{addrs: addrs, resolvedAt: time.Now()}
+	return addrs, nil
+}
+
+/*newDialContext returns the dialer of the client's transport, resolving hosts with the options' Resolver and DNS cache, over the options' IPFamily
+  Lookup failures are returned as a *net.DNSError, apart from the dial timeout
+*/
+func newDialContext(options *ClientOptions) func(ctx context.Context, network, address string) (net.Conn, error) {
+	dialer := &net.Dialer{
+		Timeout:       *options.HTTPTimeout,
+		Resolver:      options.Resolver,
+		FallbackDelay: options.FallbackDelay,
+	}
+	if options.LocalAddr != nil {
+		dialer.LocalAddr = options.LocalAddr
+	}
+	dial := dialer.DialContext
+	if options.DNSCacheTTL > 0 {
+		dial = cachedDial(dialer, options)
+	}
+
+	switch options.IPFamily {
+	case IPv4Only:
+		return func(ctx context.Context, network, address string) (net.Conn, error) {
+			return dial(ctx, family(network, "4"), address)
+		}
+	case IPv6Only:
+		return func(ctx context.Context, network, address string) (net.Conn, error) {
+			return dial(ctx, family(network, "6"), address)
+		}
+	case IPPreferV4:
+		return preferFamily(dial, "4", "6")
+	case IPPreferV6:
+		return preferFamily(dial, "6", "4")
+	}
+	return dial
+}
+
+//preferFamily returns a dial trying the preferred family first, then the other one
+func preferFamily(dial func(ctx context.Context, network, address string) (net.Conn, error), preferred, other string) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		conn, err := dial(ctx, family(network, preferred), address)
+		if err == nil || ctx.Err() != nil || family(network, preferred) == network {
+			return conn, err
+		}
+		return dial(ctx, family(network, other), address)
+	}
+}
+
+//family returns the network restricted to an IP version, e.g. tcp4 for tcp and 4. Networks already restricted are kept
+func family(network, version string) string {
+	if network == "tcp" || network == "udp" {
+		return network + version
+	}
+	return network
+}
+
+//cachedDial returns a dial resolving hosts through the DNS cache
+func cachedDial(dialer *net.Dialer, options *ClientOptions) func(ctx context.Context, network, address string) (net.Conn, error) {
+	resolver := options.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	cache := &dnsCache{}
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil || net.ParseIP(host) != nil {
+			return dialer.DialContext(ctx, network, address)
+		}
+		addrs, err := cache.lookup(ctx, resolver, host, options.DNSCacheTTL)
+		if err != nil {
+			return nil, err
+		}
+
+		return dialAddrs(ctx, dialer, network, host, port, addrs)
+	}
+}
+
+//defaultFallbackDelay is the delay before the other family is raced when the dialer's FallbackDelay is 0, as net.Dialer
+const defaultFallbackDelay = 300 * time.Millisecond
+
+/*dialAddrs dials the cached addresses of a host as net.Dialer dials the addresses it resolves: the family of the first address first,
+  and the other family raced after the dialer's FallbackDelay as in RFC 6555, so the DNS cache keeps IPAny's fallback
+*/
+func dialAddrs(ctx context.Context, dialer *net.Dialer, network, host, port string, addrs []string) (net.Conn, error) {
+	var primaries, fallbacks []string
+	for _, addr := range addrs {
+		if !dialable(network, addr) {
+			continue
+		}
+		if len(primaries) == 0 || isIPv4(addr) == isIPv4(primaries[0]) {
+			primaries = append(primaries, addr)
+		} else {
+			fallbacks = append(fallbacks, addr)
+		}
+	}
+	if len(primaries) == 0 {
+		return nil, &net.DNSError{Err: "no address for " + network, Name: host, IsNotFound: true}
+	}
+	if len(fallbacks) == 0 || dialer.FallbackDelay < 0 {
+		return dialSerial(ctx, dialer, network, port, append(primaries, fallbacks...))
+	}
+	delay := dialer.FallbackDelay
+	if delay == 0 {
+		delay = defaultFallbackDelay
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type dialed struct {
+		conn    net.Conn
+		err     error
+		primary bool
+	}
+	results := make(chan dialed, 2)
+	race := func(addrs []string, primary bool) {
+		go func() {
+			conn, err := dialSerial(ctx, dialer, network, port, addrs)
+			results <- dialed{conn: conn, err: err, primary: primary}
+		}()
+	}
+	race(primaries, true)
+	fallback := time.NewTimer(delay)
+	defer fallback.Stop()
+
+	racing, fellBack := 1, false
+	var primaryErr, fallbackErr error
+	for {
+		select {
+		case <-fallback.C:
+			if !fellBack {
+				race(fallbacks, false)
+				racing, fellBack = racing+1, true
+			}
+		case result := <-results:
+			racing--
+			if result.err == nil {
+				if racing > 0 {
+					//the other family lost the race, its connection is closed if it still comes
+					go func() {
+						if late := <-results; late.conn != nil {
+							late.conn.Close()
+						}
+					}()
+				}
+				return result.conn, nil
+			}
+			if result.primary {
+				primaryErr = result.err
+			} else {
+				fallbackErr = result.err
+			}
+			if !fellBack {
+				fallback.Stop()
+				race(fallbacks, false)
+				racing, fellBack = racing+1, true
+			}
+			if racing == 0 {
+				if primaryErr != nil {
+					return nil, primaryErr
+				}
+				return nil, fallbackErr
+			}
+		}
+	}
+}
+
+//dialSerial dials addresses one after the other, returning the first connection or the first error
+func dialSerial(ctx context.Context, dialer *net.Dialer, network, port string, addrs []string) (net.Conn, error) {
+	var firstErr error
+	for _, addr := range addrs {
+		conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(addr, port))
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, firstErr
+}
+
+func isIPv4(addr string) bool {
+	return net.ParseIP(addr).To4() != nil
+}
+
+//dialable returns true when the address is of the family of the network
+func dialable(network, addr string) bool {
+	ip := net.ParseIP(addr)
+	switch network {
+	case "tcp4":
+		return ip != nil && ip.To4() != nil
+	case "tcp6":
+		return ip != nil && ip.To4() == nil
+	}
+	return ip != nil
+}
@@ -0,0 +1,101 @@
+package godbc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestDNSCache(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status": 0, "is_service_overloaded": false}`))
+	}))
+	defer server.Close()
+	endpoint, _ := url.Parse(strings.Replace(server.URL, "127.0.0.1", "localhost", 1) + "/api/")
+
+	//the resolver dials from concurrent goroutines, one per address family
+	var lookups int32
+	resolver := &net.Resolver{PreferGo: true, Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+		atomic.AddInt32(&lookups, 1)
+		return nil, errors.New("resolver is down")
+	}}
+	client := NewClient("user", "password", &ClientOptions{Endpoint: endpoint, Resolver: resolver, DNSCacheTTL: time.Minute})
+	if _, err := client.StatusWithContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	cache := &dnsCache{entries: map[string]dnsEntry{"api.example.com": {addrs: []string{"192.0.2.1"}, resolvedAt: time.Now().Add(-time.Hour)}}}
+	addrs, err := cache.lookup(context.Background(), resolver, "api.example.com", time.Minute)
+	if err != nil || len(addrs) != 1 || addrs[0] != "192.0.2.1" {
+		t.Fatalf("the stale addresses were not served while the resolver fails: %v, %v", addrs, err)
+	}
+	if atomic.LoadInt32(&lookups) == 0 {
+		t.Fatal("the expired entry was not resolved again")
+	}
+	var dnsErr *net.DNSError
+	if _, err := cache.lookup(context.Background(), resolver, "other.example.com", time.Minute); !errors.As(err, &dnsErr) {
+		t.Fatalf("got %v, want a *net.DNSError", err)
+	}
+}
+
+func TestIPFamily(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status": 0, "is_service_overloaded": false}`))
+	}))
+	defer server.Close()
+	endpoint, _ := url.Parse(server.URL + "/api/")
+
+	for _, tc := range []struct {
+		family IPFamily
+		ok     bool
+	}{
+		{IPAny, true},
+		{IPv4Only, true},
+		{IPPreferV6, true},
+		{IPv6Only, false},
+	} {
+		client := NewClient("user", "password", &ClientOptions{Endpoint: endpoint, IPFamily: tc.family, LocalAddr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}})
+		_, err := client.StatusWithContext(context.Background())
+		if (err == nil) != tc.ok {
+			t.Errorf("family %d: unexpected error %v", tc.family, err)
+		}
+	}
+}
+
+func TestCachedDialFallback(t *testing.T) {
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	//the IPv6 address hangs as a black-holed route would, the IPv4 address must be raced after the fallback delay
+	dialer := &net.Dialer{FallbackDelay: 20 * time.Millisecond, Control: func(network, address string, c syscall.RawConn) error {
+		if strings.HasPrefix(address, "[") {
+			time.Sleep(time.Second)
+		}
+		return nil
+	}}
+	start := time.Now()
+	conn, err := dialAddrs(context.Background(), dialer, "tcp", "api.example.com", port, []string{"::1", "127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("the IPv4 address was dialed after %s", elapsed)
+	}
+
+	if _, err := dialAddrs(context.Background(), dialer, "tcp6", "api.example.com", port, []string{"127.0.0.1"}); err == nil {
+		t.Fatal("an IPv4 address was dialed over tcp6")
+	}
+}
@@ -0,0 +1,118 @@
+package godbc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+//MaxContentSize is the size limit of an image, see ErrContentTooBig
+const MaxContentSize = 180 * 1024
+
+//maxPreprocessedSize is the size limit of a downloaded image when Preprocessors may shrink it under MaxContentSize
+const maxPreprocessedSize = 8 * 1024 * 1024
+
+//ErrDownloadFailed is matched by every DownloadError, with errors.Is
+var ErrDownloadFailed = errors.New("Captcha download failed")
+
+//DownloadError is returned when a captcha image could not be downloaded, so that error pages are never sent to the service
+type DownloadError struct {
+	//StatusCode - the status code of the upstream response
+	StatusCode int
+	//ContentType - the content type of the upstream response
+	ContentType string
+	//Reason - what was wrong with the response
+	Reason string
+}
+
+func (e *DownloadError) Error() string {
+	return fmt.Sprintf("%s: %s (status %d, content type %q)", ErrDownloadFailed, e.Reason, e.StatusCode, e.ContentType)
+}
+
+//Unwrap returns ErrDownloadFailed
+func (e *DownloadError) Unwrap() error {
+	return ErrDownloadFailed
+}
+
+//ErrTooManyRedirects is returned when a captcha download is redirected more than DownloadOptions.MaxRedirects times
+var ErrTooManyRedirects = errors.New("Captcha download was redirected too many times")
+
+//DownloadOptions are settings for downloading captcha images, many captcha images are only served along with the session of the page they are on
+type DownloadOptions struct {
+	//Jar - cookies sent with the download and its redirects, may be nil
+	Jar http.CookieJar
+	//MaxRedirects - how many redirects are followed, 10 if 0. When negative, any redirect fails the download with ErrTooManyRedirects
+	MaxRedirects int
+	//Referer - the page the captcha is on, may be empty
+	Referer string
+}
+
+//client returns the http client to download with
+func (o *DownloadOptions) client(base *http.Client) *http.Client {
+	client := *base
+	max := maxRedirects
+	if o != nil {
+		if o.Jar != nil {
+			client.Jar = o.Jar
+		}
+		if o.MaxRedirects != 0 {
+			max = o.MaxRedirects
+		}
+	}
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) > max {
+			return ErrTooManyRedirects
+		}
+		return nil
+	}
+	return &client
+}
+
+//download fetches a captcha image, checking it is a non empty image under MaxContentSize, or 8MB when Preprocessors are set
+func (c *Client) download(request *http.Request, options *DownloadOptions) ([]byte, error) {
+	if options != nil && options.Referer != "" {
+		request = request.Clone(request.Context())
+		request.Header.Set("Referer", options.Referer)
+	}
+
+	response, err := options.client(c.HTTPClient).Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	contentType := response.Header.Get("Content-Type")
+	fail := func(reason string) error {
+		return &DownloadError{StatusCode: response.StatusCode, ContentType: contentType, Reason: reason}
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return nil, fail("unexpected status")
+	}
+	if contentType != "" && !strings.HasPrefix(contentType, "image/") && !strings.HasPrefix(contentType, "application/octet-stream") {
+		return nil, fail("not an image")
+	}
+
+	maxSize := MaxContentSize
+	if len(c.opts().Preprocessors) > 0 {
+		//FitSize or another preprocessor brings the image under MaxContentSize before the upload
+		maxSize = maxPreprocessedSize
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(response.Body, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) == 0 {
+		return nil, fail("empty body")
+	}
+	if len(body) > maxSize {
+		return nil, ErrContentTooBig
+	}
+	if DetectFormat(body) == FormatUnknown {
+		return nil, fail("not an image")
+	}
+
+	return body, nil
+}
@@ -0,0 +1,48 @@
+package godbc
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestDownloadRedirects(t *testing.T) {
+	image := noiseImage(t, 16, 16)
+	//the image is served after as many redirects as the path asks for
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if left, _ := strconv.Atoi(r.URL.Path[1:]); left > 0 {
+			http.Redirect(w, r, "/"+strconv.Itoa(left-1), http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(image)
+	}))
+	defer server.Close()
+	client := NewClient("user", "password", nil)
+
+	cases := []struct {
+		redirects int
+		options   *DownloadOptions
+		ok        bool
+	}{
+		{10, nil, true},
+		{11, nil, false},
+		{10, &DownloadOptions{}, true},
+		{2, &DownloadOptions{MaxRedirects: 2}, true},
+		{3, &DownloadOptions{MaxRedirects: 2}, false},
+		{0, &DownloadOptions{MaxRedirects: -1}, true},
+		{1, &DownloadOptions{MaxRedirects: -1}, false},
+	}
+	for _, c := range cases {
+		request, _ := http.NewRequest(`GET`, server.URL+"/"+strconv.Itoa(c.redirects), nil)
+		_, err := client.download(request, c.options)
+		if c.ok && err != nil {
+			t.Errorf("%d redirects with %+v: %v", c.redirects, c.options, err)
+		}
+		if !c.ok && !errors.Is(err, ErrTooManyRedirects) {
+			t.Errorf("%d redirects with %+v: got %v, want ErrTooManyRedirects", c.redirects, c.options, err)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package godbc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+//endpointCooldown is how long an endpoint that timed out or refused connections is skipped
+const endpointCooldown = 30 * time.Second
+
+//EndpointHealth is what the client observed of an endpoint, see Client.Endpoints
+type EndpointHealth struct {
+	URL string
+	//Latency - the moving average of the endpoint's response time, 0 until it answered
+	Latency time.Duration
+	//FailedUntil - the endpoint is skipped until then after a timeout or a connection failure, zero when healthy
+	FailedUntil time.Time
+}
+
+//endpointSet keeps the health of the api endpoints, by url
+type endpointSet struct {
+	mu     sync.Mutex
+	health map[string]*EndpointHealth
+}
+
+func (s *endpointSet) get(endpoint *url.URL) *EndpointHealth {
+	if s.health == nil {
+		s.health = map[string]*EndpointHealth{}
+	}
+	key := endpoint.String()
+	if _, ok := s.health[key]; !ok {
+		s.health[key] = &EndpointHealth{URL: key}
+	}
+	return s.health[key]
+}
+
+//observe blends the latency of a call into the endpoint's health, or puts it aside when it failed
+func (s *endpointSet) observe(endpoint *url.URL, latency time.Duration, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	health := s.get(endpoint)
+	if failed {
+		health.FailedUntil = time.Now().Add(endpointCooldown)
+		return
+	}
+	health.FailedUntil = time.Time{}
+	if health.Latency == 0 {
+		health.Latency = latency
+	} else {
+		health.Latency = (health.Latency*4 + latency) / 5
+	}
+}
+
+//candidates returns the configured endpoints: the primary Endpoint, then the Mirrors
+func (c *Client) candidates() []*url.URL {
+	return append([]*url.URL{c.opts().Endpoint}, c.opts().Mirrors...)
+}
+
+/*endpoints returns the endpoints in the order they are tried
+  Healthy endpoints come first, the fastest first. Endpoints not measured yet keep their configured order ahead of measured ones, so they get measured
+*/
+func (c *Client) endpoints() []*url.URL {
+	candidates := c.candidates()
+	if len(candidates) == 1 {
+		return candidates
+	}
+	c.endpointHealth.mu.Lock()
+	defer c.endpointHealth.mu.Unlock()
+	now := time.Now()
+	healthy, failed := []*url.URL{}, []*url.URL{}
+	for _, endpoint := range candidates {
+		if now.Before(c.endpointHealth.get(endpoint).FailedUntil) {
+			failed = append(failed, endpoint)
+		} else {
+			healthy = append(healthy, endpoint)
+		}
+	}
+	for i := 1; i < len(healthy); i++ {
+		for j := i; j > 0 && c.endpointHealth.get(healthy[j]).Latency < c.endpointHealth.get(healthy[j-1]).Latency; j-- {
+			healthy[j], healthy[j-1] = healthy[j-1], healthy[j]
+		}
+	}
+	return append(healthy, failed...)
+}
+
+//endpoint returns the endpoint new calls are sent to
+func (c *Client) endpoint() *url.URL {
+	if len(c.opts().Mirrors) == 0 {
+		return c.opts().Endpoint
+	}
+	return c.endpoints()[0]
+}
+
+//Endpoints returns the health of the primary endpoint and of the mirrors, see ClientOptions.Mirrors
+func (c *Client) Endpoints() []EndpointHealth {
+	c.endpointHealth.mu.Lock()
+	defer c.endpointHealth.mu.Unlock()
+	health := []EndpointHealth{}
+	for _, endpoint := range c.candidates() {
+		health = append(health, *c.endpointHealth.get(endpoint))
+	}
+	return health
+}
+
+//CheckEndpoints measures every endpoint with a `status` call, so the next calls go to the fastest healthy one. The first error is returned, after all endpoints were checked
+func (c *Client) CheckEndpoints(ctx context.Context) error {
+	var first error
+	for _, endpoint := range c.candidates() {
+		urlReq, err := endpoint.Parse(RouteStatus.Template)
+		var req *http.Request
+		if err == nil {
+			req, err = http.NewRequestWithContext(withEndpoint(withRoute(ctx, RouteStatus, 0), endpoint), `GET`, urlReq.String(), nil)
+		}
+		if err == nil {
+			_, err = do[StatusResponse](c, req)
+		}
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+type endpointKey struct{}
+
+//withEndpoint returns a context pinning its calls to one endpoint, without failover
+func withEndpoint(ctx context.Context, endpoint *url.URL) context.Context {
+	return context.WithValue(ctx, endpointKey{}, endpoint)
+}
+
+//endpointOf returns the candidate endpoint a request url is under, nil if none
+func (c *Client) endpointOf(u *url.URL) *url.URL {
+	for _, endpoint := range c.candidates() {
+		if u.Host == endpoint.Host && strings.HasPrefix(u.Path, endpoint.Path) {
+			return endpoint
+		}
+	}
+	return nil
+}
+
+//retargetURL moves an url from under an endpoint to under another
+func retargetURL(u, from, to *url.URL) (*url.URL, bool) {
+	if u.Host != from.Host || !strings.HasPrefix(u.Path, from.Path) {
+		return u, false
+	}
+	moved, err := to.Parse(strings.TrimPrefix(u.Path, from.Path))
+	if err != nil {
+		return u, false
+	}
+	moved.RawQuery = u.RawQuery
+	return moved, true
+}
+
+/*failoverTargets returns the requests to try in turn for an api call: the request, then copies sent to the other endpoints
+  Only requests whose body can be sent again fail over, and a request pinned with withEndpoint does not
+*/
+func (c *Client) failoverTargets(request *http.Request) []*http.Request {
+	from := c.endpointOf(request.URL)
+	if _, pinned := request.Context().Value(endpointKey{}).(*url.URL); pinned || from == nil || len(c.opts().Mirrors) == 0 {
+		return []*http.Request{request}
+	}
+	if request.Body != nil && request.Body != http.NoBody && request.GetBody == nil {
+		return []*http.Request{request}
+	}
+
+	targets := []*http.Request{request}
+	for _, endpoint := range c.endpoints() {
+		if endpoint.String() == from.String() {
+			continue
+		}
+		moved, ok := retargetURL(request.URL, from, endpoint)
+		if !ok {
+			continue
+		}
+		retargeted := request.Clone(request.Context())
+		retargeted.URL, retargeted.Host = moved, ""
+		if request.GetBody != nil {
+			body, err := request.GetBody()
+			if err != nil {
+				break
+			}
+			retargeted.Body = body
+		}
+		targets = append(targets, retargeted)
+	}
+	return targets
+}
+
+//endpointFailed returns true when err shows the endpoint of the request is unreachable or too slow: a connection failure, or a timeout the caller did not cause
+func endpointFailed(request *http.Request, err error) bool {
+	if err == nil || request.Context().Err() != nil {
+		return false
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout() || errors.Is(err, context.DeadlineExceeded)
+}
+
+/*failsOverEndpoint returns true when the request should be sent to another endpoint after err
+  Timeouts fail over GET requests only, as the endpoint may have received a submission it is still processing. Connection failures fail over all requests
+*/
+func failsOverEndpoint(request *http.Request, err error) bool {
+	if !endpointFailed(request, err) {
+		return false
+	}
+	var opErr *net.OpError
+	return request.Method == `GET` || errors.As(err, &opErr) && opErr.Op == "dial"
+}
@@ -0,0 +1,40 @@
+package godbc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestEndpointFailover(t *testing.T) {
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"captcha": 5, "is_correct": true, "text": "", "status": 0}`))
+	}))
+	defer mirror.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	primary, _ := url.Parse(closed.URL + "/api/")
+	secondary, _ := url.Parse(mirror.URL + "/api/")
+	client := NewClient("user", "password", &ClientOptions{Endpoint: primary, Mirrors: []*url.URL{secondary}})
+
+	ctx := context.Background()
+	response, err := client.CaptchaWithOptions(ctx, benchmarkImage(t), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.ID != 5 || !strings.HasPrefix(response.PollURL, mirror.URL) {
+		t.Fatalf("unexpected response %+v", response)
+	}
+	health := client.Endpoints()
+	if health[0].FailedUntil.IsZero() || !health[1].FailedUntil.IsZero() || health[1].Latency == 0 {
+		t.Fatalf("unexpected endpoint health %+v", health)
+	}
+	if err := client.CheckEndpoints(ctx); err == nil {
+		t.Fatal("the unreachable endpoint passed the check")
+	}
+}
@@ -0,0 +1,105 @@
+package godbc
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+)
+
+//Error classes of ErrorReport
+const (
+	//ErrorClassCredentials - the account can not be used, see IsCredentialError
+	ErrorClassCredentials = "credentials"
+	//ErrorClassRetryable - the call may succeed when made again, see IsRetryable
+	ErrorClassRetryable = "retryable"
+	//ErrorClassPermanent - the call will fail the same way, see IsPermanent
+	ErrorClassPermanent = "permanent"
+)
+
+//ErrorReport is an api call failure, with what the client knew of the call
+type ErrorReport struct {
+	At  time.Time
+	Err error
+	//Class - ErrorClassCredentials, ErrorClassRetryable or ErrorClassPermanent
+	Class string
+	//Endpoint - the name of the route called, e.g. "upload", see Route
+	Endpoint  string
+	CaptchaID int64
+	//CaptchaType - the type field of the captcha's submission, empty if unknown
+	CaptchaType string
+	//Attempt - the poll attempt of polls, the submission attempt of submissions, 0 if unknown
+	Attempt       int
+	CorrelationID string
+	Tenant        string
+}
+
+//ErrorSink aggregates the failures of the client, e.g. in Sentry with the `sentry` build tag, see SentrySink
+type ErrorSink interface {
+	Report(ctx context.Context, report ErrorReport)
+}
+
+type captchaTypeKey struct{}
+
+type attemptKey struct{}
+
+//withCaptchaType returns a context whose calls are about a captcha of the given type, as sent in the type field
+func withCaptchaType(ctx context.Context, captchaType string) context.Context {
+	if captchaType == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, captchaTypeKey{}, captchaType)
+}
+
+//withAttempt returns a context whose calls are the given attempt
+func withAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+//classify returns the class of an error
+func classify(err error) string {
+	switch {
+	case IsCredentialError(err):
+		return ErrorClassCredentials
+	case IsRetryable(err):
+		return ErrorClassRetryable
+	}
+	return ErrorClassPermanent
+}
+
+//redactURLError redacts the credentials GET calls carry in their query from the url of the *url.Error a call failed with, in place
+func redactURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		if parsed, parseErr := url.Parse(urlErr.URL); parseErr == nil {
+			urlErr.URL = redactURL(parsed)
+		} else {
+			urlErr.URL = ""
+		}
+	}
+	return err
+}
+
+//reportError sends an error to the client's ErrorSink and returns it. Cancelled calls are not failures and are not reported
+func (c *Client) reportError(ctx context.Context, err error) error {
+	sink := c.opts().ErrorSink
+	if sink == nil || err == nil || ctx.Err() == context.Canceled {
+		return err
+	}
+
+	report := ErrorReport{
+		At:            time.Now(),
+		Err:           redactURLError(err),
+		Class:         classify(err),
+		CaptchaID:     routeCaptchaID(ctx),
+		CorrelationID: CorrelationIDFrom(ctx),
+		Tenant:        TenantFrom(ctx),
+	}
+	if route := routeFrom(ctx); route != nil {
+		report.Endpoint = route.Name
+	}
+	report.CaptchaType, _ = ctx.Value(captchaTypeKey{}).(string)
+	report.Attempt, _ = ctx.Value(attemptKey{}).(int)
+	sink.Report(ctx, report)
+	return err
+}
@@ -0,0 +1,71 @@
+package godbc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+//errorRecorder is an ErrorSink keeping the reports
+type errorRecorder struct {
+	mu      sync.Mutex
+	reports []ErrorReport
+}
+
+func (r *errorRecorder) Report(ctx context.Context, report ErrorReport) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reports = append(r.reports, report)
+}
+
+func TestErrorSink(t *testing.T) {
+	sink := &errorRecorder{}
+	client, _ := newMockClient(&ClientOptions{ErrorSink: sink}, func(req *http.Request, body []byte) *http.Response {
+		if req.Method == `POST` {
+			return mockResponse(503, "")
+		}
+		return mockResponse(200, `{"status": 255, "error": "banned"}`)
+	})
+	ctx := context.Background()
+	client.CaptchaWithOptions(ctx, benchmarkImage(t), nil)
+	client.UserWithContext(ctx)
+
+	if len(sink.reports) != 2 {
+		t.Fatalf("got %d reports, want 2", len(sink.reports))
+	}
+	upload, user := sink.reports[0], sink.reports[1]
+	if upload.Endpoint != "upload" || upload.Class != ErrorClassRetryable || upload.CaptchaType != TypeImage.String() || upload.CorrelationID == "" || !errors.Is(upload.Err, ErrOverloadedServer) {
+		t.Errorf("unexpected upload report %+v", upload)
+	}
+	if user.Endpoint != "user" || user.Class != ErrorClassCredentials || !errors.Is(user.Err, ErrBanned) {
+		t.Errorf("unexpected user report %+v", user)
+	}
+}
+
+//failingTransport fails every request, as a transport does when the api can not be reached
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestErrorSinkRedactsCredentials(t *testing.T) {
+	sink := &errorRecorder{}
+	client := NewClient("secret-user", "secret-password", &ClientOptions{ErrorSink: sink})
+	client.WithHTTPClient(&http.Client{Transport: failingTransport{}})
+	ctx := context.Background()
+	_, returned := client.UserWithContext(ctx)
+	client.RecentCaptchas(ctx)
+
+	if len(sink.reports) == 0 {
+		t.Fatal("no report was sent")
+	}
+	for _, report := range append(sink.reports, ErrorReport{Err: returned}) {
+		if message := report.Err.Error(); strings.Contains(message, "secret-") {
+			t.Errorf("credentials leaked in %q", message)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package godbc
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+//EventType is the kind of step recorded in the client's event stream
+type EventType int
+
+//Event types
+const (
+	//EventSubmitted - a captcha was accepted by the service
+	EventSubmitted EventType = iota + 1
+	//EventSolved - a captcha was solved
+	EventSolved
+	//EventFailed - a captcha could not be solved (invalid or timed out)
+	EventFailed
+	//EventReported - a captcha was reported as incorrectly solved
+	EventReported
+)
+
+//maxEvents is how many events are kept in memory for local statistics
+const maxEvents = 10000
+
+//Event is a step of a captcha lifecycle as observed by the client
+type Event struct {
+	Type      EventType
+	CaptchaID int64
+	//CorrelationID - the correlation ID of the captcha, see WithCorrelationID
+	CorrelationID string
+	At            time.Time
+	Err           error
+	//Latency - for EventSolved and EventFailed, the time since the captcha was submitted, 0 if unknown
+	Latency time.Duration
+}
+
+//eventLog keeps the last maxEvents events. It grows up to twice as many before it is trimmed, so trimming is paid once every maxEvents events
+type eventLog struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (l *eventLog) add(e Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.events) >= 2*maxEvents {
+		l.events = append(l.events[:0], l.events[len(l.events)-maxEvents:]...)
+	}
+	l.events = append(l.events, e)
+}
+
+//last returns the last maxEvents events, l.mu must be held
+func (l *eventLog) last() []Event {
+	if len(l.events) > maxEvents {
+		return l.events[len(l.events)-maxEvents:]
+	}
+	return l.events
+}
+
+func (l *eventLog) since(t time.Time) []Event {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	events := []Event{}
+	for _, e := range l.last() {
+		if !e.At.Before(t) {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+func (c *Client) emit(ctx context.Context, eventType EventType, captchaID int64, err error) {
+	e := Event{Type: eventType, CaptchaID: captchaID, CorrelationID: CorrelationIDFrom(ctx), At: time.Now(), Err: err}
+	if (eventType == EventSolved || eventType == EventFailed) && c.submitted != nil {
+		if submittedAt, ok := c.submitted.get(captchaID); ok {
+			e.Latency = c.now().Sub(submittedAt)
+		}
+		c.submitted.settle(captchaID)
+	}
+	c.counters.count(eventType)
+	if eventType == EventSolved && c.reports != nil {
+		c.reports.solve()
+	}
+	if eventType != EventSubmitted && c.uploads != nil {
+		c.uploads.forget(captchaID)
+	}
+	if c.events != nil {
+		c.events.add(e)
+	}
+	if c.opts().OnEvent != nil {
+		c.opts().OnEvent(e)
+	}
+}
+
+//AccountStats is the activity of the account, as seen by this client
+type AccountStats struct {
+	Balance   float64
+	Rate      float64
+	Submitted int
+	Solved    int
+	Failed    int
+	Reported  int
+	//Accuracy - ratio of solved captchas that were not reported
+	Accuracy float64
+	//Spend - estimated cost of the solved captchas, in the same unit as Rate
+	Spend float64
+}
+
+//DailyStats is the activity of the account for one day (UTC), as seen by this client
+type DailyStats struct {
+	Day time.Time
+	AccountStats
+}
+
+func (s *AccountStats) count(e Event) {
+	switch e.Type {
+	case EventSubmitted:
+		s.Submitted++
+	case EventSolved:
+		s.Solved++
+	case EventFailed:
+		s.Failed++
+	case EventReported:
+		s.Reported++
+	}
+}
+
+func (s *AccountStats) finish(rate float64) {
+	s.Rate = rate
+	s.Spend = float64(s.Solved) * rate
+	if s.Solved > 0 {
+		s.Accuracy = float64(s.Solved-s.Reported) / float64(s.Solved)
+	}
+}
+
+/*Stats will retrieve the account statistics
+  The service does not expose solve statistics: balance and rate come from the `user` call, counts are aggregated from the client's own event stream
+*/
+func (c *Client) Stats(ctx context.Context) (*AccountStats, error) {
+	user, err := c.UserWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &AccountStats{Balance: user.Balance}
+	for _, e := range c.events.since(time.Time{}) {
+		stats.count(e)
+	}
+	stats.finish(user.Rate)
+
+	return stats, nil
+}
+
+/*History will retrieve the account statistics per day since the given time, oldest first
+  The service does not expose solve history: days are aggregated from the client's own event stream, spend is estimated with the current rate
+*/
+func (c *Client) History(ctx context.Context, since time.Time) ([]DailyStats, error) {
+	user, err := c.UserWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	history := []DailyStats{}
+	for _, e := range c.events.since(since) {
+		day := e.At.UTC().Truncate(24 * time.Hour)
+		if len(history) == 0 || !history[len(history)-1].Day.Equal(day) {
+			history = append(history, DailyStats{Day: day})
+		}
+		history[len(history)-1].count(e)
+	}
+	for i := range history {
+		history[i].finish(user.Rate)
+	}
+
+	return history, nil
+}
@@ -0,0 +1,78 @@
+package godbc
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStatsAndHistory(t *testing.T) {
+	var mu sync.Mutex
+	var solved []Event
+	client := NewClient("user", "password", &ClientOptions{Sandbox: &SandboxConfig{Answer: "abcdef", Rate: 0.5, Balance: 3}, OnEvent: func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		if e.Type == EventSolved {
+			solved = append(solved, e)
+		}
+	}})
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		ressource, err := client.CaptchaWithOptions(ctx, benchmarkImage(t), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resolved, err := client.WaitCaptchaWithContext(ctx, ressource)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			if _, err := client.ReportCaptchaWithContext(ctx, resolved); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	stats, err := client.Stats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := AccountStats{Balance: 3, Rate: 0.5, Submitted: 2, Solved: 2, Reported: 1, Accuracy: 0.5, Spend: 1}
+	if *stats != want {
+		t.Fatalf("got %+v, want %+v", *stats, want)
+	}
+	history, err := client.History(ctx, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].Solved != 2 || !history[0].Day.Equal(start.UTC().Truncate(24*time.Hour)) {
+		t.Fatalf("unexpected history %+v", history)
+	}
+	if history, _ := client.History(ctx, time.Now().Add(time.Hour)); len(history) != 0 {
+		t.Fatalf("got %+v, want no day after the events", history)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(solved) != 2 || solved[0].CaptchaID == 0 || solved[0].Latency <= 0 {
+		t.Fatalf("unexpected solve events %+v", solved)
+	}
+}
+
+func TestEventLogBound(t *testing.T) {
+	log := &eventLog{}
+	for i := 0; i <= 3*maxEvents; i++ {
+		log.add(Event{Type: EventSubmitted, CaptchaID: int64(i)})
+		if i == maxEvents || i == 3*maxEvents {
+			events := log.since(time.Time{})
+			if len(events) != maxEvents || events[0].CaptchaID != int64(i-maxEvents+1) || events[maxEvents-1].CaptchaID != int64(i) {
+				t.Fatalf("kept %d events from captcha %d, want the last %d", len(events), events[0].CaptchaID, maxEvents)
+			}
+		}
+	}
+	if len(log.events) > 2*maxEvents {
+		t.Fatalf("the log grew to %d events", len(log.events))
+	}
+}
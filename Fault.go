@@ -0,0 +1,115 @@
+package godbc
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+/*FaultInjector makes the client fail at random, to verify callers' resilience in integration tests
+  Each field is the probability, between 0 and 1, for a request to fail that way. Their sum should not exceed 1
+*/
+type FaultInjector struct {
+	//Forbidden - the service answers 403
+	Forbidden float64
+	//ServerError - the service answers 500
+	ServerError float64
+	//Overloaded - the service answers 503
+	Overloaded float64
+	//Timeout - the request times out without reaching the service
+	Timeout float64
+	//GarbledJSON - the service answers with a truncated body
+	GarbledJSON float64
+	//Seed - seed of the random source, 0 for a time based seed
+	Seed int64
+}
+
+//faultTimeoutError is the error of an injected timeout
+type faultTimeoutError struct{}
+
+func (faultTimeoutError) Error() string   { return "injected fault: i/o timeout" }
+func (faultTimeoutError) Timeout() bool   { return true }
+func (faultTimeoutError) Temporary() bool { return true }
+
+type faultTransport struct {
+	next     http.RoundTripper
+	injector FaultInjector
+
+	mu     sync.Mutex
+	random *rand.Rand
+}
+
+//Wrap returns a transport injecting faults in front of next, http.DefaultTransport if nil
+func (f *FaultInjector) Wrap(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	seed := f.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &faultTransport{next: next, injector: *f, random: rand.New(rand.NewSource(seed))}
+}
+
+func (t *faultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	draw := t.random.Float64()
+	t.mu.Unlock()
+
+	for _, fault := range []struct {
+		probability float64
+		statusCode  int
+	}{
+		{t.injector.Forbidden, 403},
+		{t.injector.ServerError, 500},
+		{t.injector.Overloaded, 503},
+	} {
+		if draw < fault.probability {
+			if req.Body != nil {
+				req.Body.Close()
+			}
+			return faultResponse(req, fault.statusCode, nil), nil
+		}
+		draw -= fault.probability
+	}
+
+	if draw < t.injector.Timeout {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, faultTimeoutError{}
+	}
+	draw -= t.injector.Timeout
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || draw >= t.injector.GarbledJSON {
+		return resp, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	garbled := faultResponse(req, resp.StatusCode, body[:len(body)/2])
+	garbled.Header = resp.Header
+	return garbled, nil
+}
+
+func faultResponse(req *http.Request, statusCode int, body []byte) *http.Response {
+	return &http.Response{
+		StatusCode:    statusCode,
+		Status:        strconv.Itoa(statusCode) + " " + http.StatusText(statusCode),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{},
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,58 @@
+package godbc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestFaultInjector(t *testing.T) {
+	cases := []struct {
+		injector FaultInjector
+		want     error
+	}{
+		{FaultInjector{Forbidden: 1}, ErrCredentialsRejected},
+		{FaultInjector{ServerError: 1}, ErrUnexpectedServerError},
+		{FaultInjector{GarbledJSON: 1}, ErrUnexpectedServerResponse},
+		{FaultInjector{}, nil},
+	}
+	for _, tc := range cases {
+		injector := tc.injector
+		client := NewClient("user", "password", &ClientOptions{Sandbox: &SandboxConfig{}, FaultInjector: &injector})
+		if _, err := client.UserWithContext(context.Background()); !errors.Is(err, tc.want) {
+			t.Errorf("%+v: got %v, want %v", tc.injector, err, tc.want)
+		}
+	}
+
+	client := NewClient("user", "password", &ClientOptions{Sandbox: &SandboxConfig{}, FaultInjector: &FaultInjector{Timeout: 1}})
+	var netErr net.Error
+	if _, err := client.UserWithContext(context.Background()); !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("got %v, want a timeout", err)
+	}
+}
+
+func TestFaultInjectorSeed(t *testing.T) {
+	outcomes := func() []bool {
+		client := NewClient("user", "password", &ClientOptions{Sandbox: &SandboxConfig{}, FaultInjector: &FaultInjector{ServerError: 0.5, Seed: 42}})
+		var failed []bool
+		for i := 0; i < 20; i++ {
+			_, err := client.UserWithContext(context.Background())
+			failed = append(failed, err != nil)
+		}
+		return failed
+	}
+	first, second := outcomes(), outcomes()
+	failures := 0
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatal("the same seed injected different faults")
+		}
+		if first[i] {
+			failures++
+		}
+	}
+	if failures == 0 || failures == len(first) {
+		t.Fatalf("%d faults in %d calls at a probability of 0.5", failures, len(first))
+	}
+}
@@ -0,0 +1,142 @@
+package godbc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	_ "image/gif"  //registers the GIF decoder for InspectImage
+	_ "image/jpeg" //registers the JPEG decoder for InspectImage
+	_ "image/png"  //registers the PNG decoder for InspectImage
+)
+
+//Format is an image format accepted by the service
+type Format int
+
+const (
+	//FormatUnknown - the content is not in an accepted format
+	FormatUnknown Format = iota
+	//FormatJPEG - JPEG image
+	FormatJPEG
+	//FormatPNG - PNG image
+	FormatPNG
+	//FormatGIF - GIF image
+	FormatGIF
+	//FormatBMP - BMP image
+	FormatBMP
+)
+
+//DetectFormat sniffs the format of an image from its magic bytes
+func DetectFormat(content []byte) Format {
+	switch {
+	case bytes.HasPrefix(content, []byte{255, 216, 255}):
+		return FormatJPEG
+	case bytes.HasPrefix(content, []byte{137, 80, 78, 71, 13, 10, 26, 10}):
+		return FormatPNG
+	case bytes.HasPrefix(content, []byte{71, 73, 70}):
+		return FormatGIF
+	case bytes.HasPrefix(content, []byte{66, 77}):
+		return FormatBMP
+	}
+	return FormatUnknown
+}
+
+//String returns the name of the format
+func (f Format) String() string {
+	switch f {
+	case FormatJPEG:
+		return "jpeg"
+	case FormatPNG:
+		return "png"
+	case FormatGIF:
+		return "gif"
+	case FormatBMP:
+		return "bmp"
+	}
+	return "unknown"
+}
+
+//ContentType returns the MIME type of the format
+func (f Format) ContentType() string {
+	if f == FormatUnknown {
+		return "application/octet-stream"
+	}
+	return "image/" + f.String()
+}
+
+//Extension returns the file extension of the format, with its leading dot
+func (f Format) Extension() string {
+	switch f {
+	case FormatJPEG:
+		return ".jpg"
+	case FormatUnknown:
+		return ""
+	}
+	return "." + f.String()
+}
+
+//MaxImageDimension is the largest width or height accepted for an image
+const MaxImageDimension = 4096
+
+//minImageSize is the size of the longest magic number, shorter content can not be an image
+const minImageSize = 8
+
+//ErrContentTooShort is returned for content too short to be an image
+var ErrContentTooShort = errors.New("Content is too short to be an image")
+
+//ErrImageDimensions is returned for images with an empty or absurd width or height
+var ErrImageDimensions = errors.New("Image dimensions are out of bounds")
+
+//ImageInfo describes an image checked by InspectImage
+type ImageInfo struct {
+	Format Format
+	Width  int
+	Height int
+}
+
+/*InspectImage decodes the header of an image to check it is in an accepted format and has sane dimensions
+  Returns ErrInvalidFormat when the content can not be decoded, ErrImageDimensions when its dimensions are out of bounds
+*/
+func InspectImage(content []byte) (ImageInfo, error) {
+	info := ImageInfo{Format: DetectFormat(content)}
+	switch info.Format {
+	case FormatUnknown:
+		return info, ErrInvalidFormat
+	case FormatBMP:
+		//image has no BMP decoder, width and height are read from the BITMAPINFOHEADER
+		if len(content) < 26 {
+			return info, ErrInvalidFormat
+		}
+		info.Width = int(int32(binary.LittleEndian.Uint32(content[18:22])))
+		info.Height = int(int32(binary.LittleEndian.Uint32(content[22:26])))
+		if info.Height < 0 {
+			//top-down bitmap
+			info.Height = -info.Height
+		}
+	default:
+		config, _, err := image.DecodeConfig(bytes.NewReader(content))
+		if err != nil {
+			return info, ErrInvalidFormat
+		}
+		info.Width, info.Height = config.Width, config.Height
+	}
+
+	if info.Width <= 0 || info.Height <= 0 || info.Width > MaxImageDimension || info.Height > MaxImageDimension {
+		return info, ErrImageDimensions
+	}
+	return info, nil
+}
+
+/*ValidateImage checks content can be submitted as an image captcha, returning its format
+  Returns ErrContentTooShort, ErrContentTooBig, ErrInvalidFormat or ErrImageDimensions
+*/
+func ValidateImage(content []byte) (Format, error) {
+	if len(content) < minImageSize {
+		return FormatUnknown, ErrContentTooShort
+	}
+	if len(content) > MaxContentSize {
+		return DetectFormat(content), ErrContentTooBig
+	}
+	info, err := InspectImage(content)
+	return info.Format, err
+}
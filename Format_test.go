@@ -0,0 +1,37 @@
+package godbc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestValidateImage(t *testing.T) {
+	format, err := ValidateImage(benchmarkImage(t))
+	if err != nil || format != FormatPNG {
+		t.Fatalf("got %s, %v for a PNG image", format, err)
+	}
+
+	//a BMP header of a 9000 pixels wide bitmap
+	bmp := make([]byte, 54)
+	copy(bmp, "BM")
+	binary.LittleEndian.PutUint32(bmp[18:22], 9000)
+	binary.LittleEndian.PutUint32(bmp[22:26], 10)
+
+	for name, tc := range map[string]struct {
+		content []byte
+		format  Format
+		want    error
+	}{
+		"short":      {[]byte{255, 216, 255}, FormatUnknown, ErrContentTooShort},
+		"big":        {append([]byte{255, 216, 255}, make([]byte, MaxContentSize)...), FormatJPEG, ErrContentTooBig},
+		"text":       {[]byte("not an image at all"), FormatUnknown, ErrInvalidFormat},
+		"truncated":  {bytes.Repeat([]byte{137, 80, 78, 71, 13, 10, 26, 10}, 2), FormatPNG, ErrInvalidFormat},
+		"dimensions": {bmp, FormatBMP, ErrImageDimensions},
+	} {
+		format, err := ValidateImage(tc.content)
+		if err != tc.want || format != tc.format {
+			t.Errorf("%s: got %s, %v, want %s, %v", name, format, err, tc.format, tc.want)
+		}
+	}
+}
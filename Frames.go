@@ -0,0 +1,266 @@
+package godbc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"sync"
+)
+
+//FrameSelection is the frame of an animated GIF submitted by GIFFrame
+type FrameSelection int
+
+const (
+	//FrameFirst - the first frame
+	FrameFirst FrameSelection = iota
+	//FrameMiddle - the frame in the middle of the animation
+	FrameMiddle
+	//FrameLast - the last frame
+	FrameLast
+	//FrameComposite - every frame drawn over the previous ones, for captchas drawing their text a piece per frame
+	FrameComposite
+)
+
+/*ExtractFrames decodes an animated GIF and renders each of its frames as displayed, with the frames before it and their disposal
+  Returns ErrInvalidFormat when the content is not a GIF, ErrImageDimensions or ErrTooManyFrames when it is too large to render
+*/
+func ExtractFrames(content []byte) ([]image.Image, error) {
+	animation, err := decodeGIF(content)
+	if err != nil {
+		return nil, err
+	}
+
+	canvas := image.NewRGBA(image.Rect(0, 0, animation.Config.Width, animation.Config.Height))
+	frames := make([]image.Image, 0, len(animation.Image))
+	for i, frame := range animation.Image {
+		disposal := byte(0)
+		if i < len(animation.Disposal) {
+			disposal = animation.Disposal[i]
+		}
+		var previous *image.RGBA
+		if disposal == gif.DisposalPrevious {
+			previous = cloneRGBA(canvas)
+		}
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		frames = append(frames, cloneRGBA(canvas))
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+	return frames, nil
+}
+
+//compositeFrames draws every frame of an animated GIF over the previous ones, ignoring their disposal
+func compositeFrames(content []byte) (image.Image, error) {
+	animation, err := decodeGIF(content)
+	if err != nil {
+		return nil, err
+	}
+	canvas := image.NewRGBA(image.Rect(0, 0, animation.Config.Width, animation.Config.Height))
+	for _, frame := range animation.Image {
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+	}
+	return canvas, nil
+}
+
+/*GIFFrame submits a single frame of animated GIFs, as a PNG image, for captchas only readable on some frames
+  Other images, and GIFs of a single frame, are returned as-is
+*/
+func GIFFrame(selection FrameSelection) Preprocessor {
+	return func(content []byte) ([]byte, error) {
+		if DetectFormat(content) != FormatGIF {
+			return content, nil
+		}
+		var img image.Image
+		if selection == FrameComposite {
+			var err error
+			if img, err = compositeFrames(content); err != nil {
+				return nil, err
+			}
+		} else {
+			frames, err := ExtractFrames(content)
+			if err != nil {
+				return nil, err
+			}
+			if len(frames) < 2 {
+				return content, nil
+			}
+			switch selection {
+			case FrameMiddle:
+				img = frames[len(frames)/2]
+			case FrameLast:
+				img = frames[len(frames)-1]
+			default:
+				img = frames[0]
+			}
+		}
+		return encodePNG(img)
+	}
+}
+
+//FrameVote is the answer most frames of an animated GIF were solved with by SolveFrames
+type FrameVote struct {
+	//Answer - the text most frames were solved with
+	Answer string
+	//Votes - how many frames were solved with Answer
+	Votes int
+	//Captcha - the first captcha solved with Answer
+	Captcha *CaptchaResponse
+	//Frames - the captcha of each frame submitted, nil for the frames that failed
+	Frames []*CaptchaResponse
+}
+
+/*SolveFrames submits several frames of an animated GIF as separate captchas, and returns the answer most of them were solved with
+  count: how many frames are submitted, evenly spread over the animation, every frame if 0 or more than it has
+  Ties go to the earliest frame. The error of the first frame is returned when none could be solved
+*/
+func (c *Client) SolveFrames(ctx context.Context, content []byte, count int, options *CaptchaOptions) (*FrameVote, error) {
+	frames, err := ExtractFrames(content)
+	if err != nil {
+		return nil, err
+	}
+	if count <= 0 || count > len(frames) {
+		count = len(frames)
+	}
+
+	vote := &FrameVote{Frames: make([]*CaptchaResponse, count)}
+	errs := make([]error, count)
+	//identical frames are separate votes IdempotentSubmit must not merge into one captcha
+	duplicates := withDuplicates(ctx)
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		frame, err := encodePNG(frames[i*len(frames)/count])
+		if err != nil {
+			return nil, err
+		}
+		wg.Add(1)
+		go func(i int, frame []byte) {
+			defer wg.Done()
+			ressource, err := c.CaptchaWithOptions(duplicates, frame, options)
+			if err == nil {
+				ressource, err = c.WaitCaptchaWithContext(duplicates, ressource)
+			}
+			vote.Frames[i], errs[i] = ressource, err
+		}(i, frame)
+	}
+	wg.Wait()
+
+	vote.Captcha, vote.Votes = tally(vote.Frames)
+	if vote.Captcha == nil {
+		return nil, errs[0]
+	}
+	vote.Answer = vote.Captcha.Text
+	return vote, nil
+}
+
+//Bounds of the animations decoded by ExtractFrames, every frame is rendered on its own canvas
+const (
+	maxGIFFrames       = 64
+	maxAnimationPixels = 32 << 20
+)
+
+//ErrTooManyFrames is returned for animated GIFs with more frames than can be rendered
+var ErrTooManyFrames = errors.New("Animation has too many frames")
+
+//decodeGIF decodes an animated GIF, once its dimensions and frame count are checked so a small file can not expand to huge frames
+func decodeGIF(content []byte) (*gif.GIF, error) {
+	if DetectFormat(content) != FormatGIF {
+		return nil, ErrInvalidFormat
+	}
+	info, err := InspectImage(content)
+	if err != nil {
+		return nil, err
+	}
+	count, err := countGIFFrames(content)
+	if err != nil {
+		return nil, err
+	}
+	if count > maxGIFFrames || count*info.Width*info.Height > maxAnimationPixels {
+		return nil, ErrTooManyFrames
+	}
+	animation, err := gif.DecodeAll(bytes.NewReader(content))
+	if err != nil || len(animation.Image) == 0 {
+		return nil, ErrInvalidFormat
+	}
+	return animation, nil
+}
+
+//countGIFFrames counts the image descriptors of a GIF by walking its blocks, without decoding them
+func countGIFFrames(content []byte) (int, error) {
+	//header and logical screen descriptor
+	if len(content) < 13 {
+		return 0, ErrInvalidFormat
+	}
+	pos := 13
+	if flags := content[10]; flags&0x80 != 0 {
+		pos += 3 << (flags&0x07 + 1)
+	}
+	//skipSubBlocks moves pos past a sequence of data sub-blocks, ended by an empty one
+	skipSubBlocks := func() bool {
+		for pos < len(content) {
+			size := int(content[pos])
+			pos += 1 + size
+			if size == 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	count := 0
+	for pos < len(content) {
+		switch content[pos] {
+		case 0x21:
+			//extension: introducer, label, sub-blocks
+			pos += 2
+			if !skipSubBlocks() {
+				return 0, ErrInvalidFormat
+			}
+		case 0x2C:
+			//image descriptor, local color table, LZW minimum code size, image data
+			if pos+10 > len(content) {
+				return 0, ErrInvalidFormat
+			}
+			flags := content[pos+9]
+			pos += 10
+			if flags&0x80 != 0 {
+				pos += 3 << (flags&0x07 + 1)
+			}
+			pos++
+			if !skipSubBlocks() {
+				return 0, ErrInvalidFormat
+			}
+			if count++; count > maxGIFFrames {
+				return count, nil
+			}
+		case 0x3B:
+			return count, nil
+		default:
+			return 0, ErrInvalidFormat
+		}
+	}
+	//a GIF missing its trailer is still decoded
+	return count, nil
+}
+
+func cloneRGBA(img *image.RGBA) *image.RGBA {
+	clone := image.NewRGBA(img.Bounds())
+	copy(clone.Pix, img.Pix)
+	return clone
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,135 @@
+package godbc
+
+import (
+	"errors"
+	"testing"
+)
+
+//responseCorpus seeds the response fuzz targets with valid bodies and the malformed ones seen from proxies and outages
+var responseCorpus = []string{
+	`{"captcha": 123456789, "is_correct": true, "text": "abcdef", "status": 0}`,
+	`{"captcha": 123456789, "is_correct": true, "text": "", "status": 0}`,
+	`{"captcha": 123456789, "is_correct": false, "text": "?", "status": 0}`,
+	`{"status": 255, "error": "not-logged-in"}`,
+	`{"status": 255, "error": "banned"}`,
+	`{"status": 7}`,
+	`{"user": 1, "rate": 0.139, "balance": 12.5, "is_banned": false, "status": 0}`,
+	`{"todays_accuracy": 0.95, "solved_in": 10, "is_service_overloaded": false, "status": 0}`,
+	`{"captcha": "123", "status": "0"}`,
+	`{"captcha": 1e400}`,
+	`  {"status": 0}  `,
+	`null`,
+	`[]`,
+	`""`,
+	`0`,
+	`{`,
+	``,
+	`<html><body>502 Bad Gateway</body></html>`,
+	`status=0&captcha=123&is_correct=1&text=abc`,
+}
+
+//checkParsed fails unless a parser returned either a response or an error, and a sentinel or *ServiceError as error
+func checkParsed(t *testing.T, ok bool, err error) {
+	if err == nil && !ok {
+		t.Fatal("nil response without error")
+	}
+	if err == nil {
+		return
+	}
+	var serviceErr *ServiceError
+	if err != ErrUnexpectedServerResponse && err != ErrCaptchaInvalid && !errors.As(err, &serviceErr) {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func FuzzParseCaptchaResponse(f *testing.F) {
+	for _, body := range responseCorpus {
+		f.Add([]byte(body))
+	}
+	f.Fuzz(func(t *testing.T, body []byte) {
+		response, err := ParseCaptchaResponse(body)
+		checkParsed(t, response != nil, err)
+		if err == nil && response.StatusCode() != StatusOK {
+			t.Fatalf("status %d returned without error", response.Status)
+		}
+	})
+}
+
+func FuzzParsePollResponse(f *testing.F) {
+	for _, body := range responseCorpus {
+		f.Add([]byte(body))
+	}
+	f.Fuzz(func(t *testing.T, body []byte) {
+		response, err := ParsePollResponse(body)
+		checkParsed(t, response != nil, err)
+		if err == nil && (!response.IsCorrect || response.Text == "?") {
+			t.Fatalf("unsolvable captcha %+v returned without error", response)
+		}
+	})
+}
+
+func FuzzParseUserResponse(f *testing.F) {
+	for _, body := range responseCorpus {
+		f.Add([]byte(body))
+	}
+	f.Fuzz(func(t *testing.T, body []byte) {
+		response, err := ParseUserResponse(body)
+		checkParsed(t, response != nil, err)
+	})
+}
+
+func FuzzParseStatusResponse(f *testing.F) {
+	for _, body := range responseCorpus {
+		f.Add([]byte(body))
+	}
+	f.Fuzz(func(t *testing.T, body []byte) {
+		response, err := ParseStatusResponse(body)
+		checkParsed(t, response != nil, err)
+	})
+}
+
+func FuzzParseCoordinates(f *testing.F) {
+	for _, text := range []string{`[[10,20],[30.5,40]]`, `[[1,2,3]]`, `[]`, `null`, `[[1e400,0]]`, `[["a","b"]]`, `?`, ``} {
+		f.Add(text)
+	}
+	f.Fuzz(func(t *testing.T, text string) {
+		_, err := ParseCoordinates(text)
+		if err != nil && err != ErrCaptchaInvalid {
+			t.Fatalf("unexpected error %v", err)
+		}
+	})
+}
+
+func FuzzParseSelection(f *testing.F) {
+	for _, text := range []string{`[1,4,7]`, `[]`, `null`, `[1.5]`, `{"a":1}`, `?`, ``} {
+		f.Add(text)
+	}
+	f.Fuzz(func(t *testing.T, text string) {
+		_, err := ParseSelection(text)
+		if err != nil && err != ErrCaptchaInvalid {
+			t.Fatalf("unexpected error %v", err)
+		}
+	})
+}
+
+func TestUndocumentedStatus(t *testing.T) {
+	bodies := map[string]string{
+		`{"captcha": 1, "is_correct": true, "text": "abc", "status": 7}`: "",
+		`{"status": 1, "error": "banned"}`:                               "banned",
+		`{"status": -1}`:                                                 "",
+	}
+	for body, code := range bodies {
+		_, err := ParseCaptchaResponse([]byte(body))
+		var serviceErr *ServiceError
+		if !errors.As(err, &serviceErr) || serviceErr.StatusCode() == StatusOK || serviceErr.Code != code {
+			t.Errorf("%s: got %v, want a *ServiceError", body, err)
+			continue
+		}
+		if code == "" && !errors.Is(err, ErrServiceBug) {
+			t.Errorf("%s: got %v, want ErrServiceBug without an error code", body, err)
+		}
+	}
+	if _, err := ParseUserResponse([]byte(`{"user": 1, "status": 3}`)); !errors.Is(err, ErrServiceBug) {
+		t.Errorf("got %v, want ErrServiceBug", err)
+	}
+}
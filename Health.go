@@ -0,0 +1,74 @@
+package godbc
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+//HealthReport is the result of a Ping, consumable by readiness probes
+type HealthReport struct {
+	CheckedAt time.Time
+	//Reachable - the endpoint answered the status call
+	Reachable bool
+	//Latency - round trip time of the status call
+	Latency time.Duration
+	//ServiceOverloaded - the service reports being overloaded
+	ServiceOverloaded bool
+	//CredentialsValid - the credentials were accepted by the user call
+	CredentialsValid bool
+	IsBanned         bool
+	HasCreditLeft    bool
+	Balance          float64
+	//ClockSkew - server clock minus local clock, from the Date header, 0 if unknown
+	ClockSkew time.Duration
+	//Err - the first failure met, nil if healthy
+	Err error `json:"-"`
+}
+
+//Healthy returns true if the client can submit captchas
+func (h *HealthReport) Healthy() bool {
+	return h.Err == nil && h.Reachable && h.CredentialsValid && !h.IsBanned && h.HasCreditLeft
+}
+
+/*Ping verifies endpoint reachability, credentials and clock skew, without consuming credit
+  The report is always returned, the error is the first failure met
+*/
+func (c *Client) Ping(ctx context.Context) (*HealthReport, error) {
+	report := &HealthReport{CheckedAt: time.Now()}
+
+	req, err := c.statusRequest(ctx)
+	if err != nil {
+		report.Err = err
+		return report, err
+	}
+	sentAt := time.Now()
+	header, body, err := c.doRequest(req)
+	report.Latency = time.Since(sentAt)
+	if err != nil {
+		report.Err = err
+		return report, err
+	}
+	report.Reachable = true
+	if date, err := http.ParseTime(header.Get("Date")); err == nil {
+		report.ClockSkew = date.Sub(sentAt.Add(report.Latency / 2)).Truncate(time.Second)
+	}
+	status, err := parse[StatusResponse](body, header.Get("Content-Type"))
+	if err != nil {
+		report.Err = err
+		return report, err
+	}
+	report.ServiceOverloaded = status.IsServiceOverloaded
+
+	user, err := c.UserWithContext(ForceRefresh(ctx))
+	if err != nil {
+		report.Err = err
+		return report, err
+	}
+	report.CredentialsValid = true
+	report.IsBanned = user.IsBanned
+	report.HasCreditLeft = user.HasCreditLeft()
+	report.Balance = user.Balance
+
+	return report, nil
+}
@@ -0,0 +1,44 @@
+package godbc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPing(t *testing.T) {
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	client, _ := newMockClient(nil, func(req *http.Request, body []byte) *http.Response {
+		if strings.HasSuffix(req.URL.Path, "/user") {
+			return mockResponse(200, `{"user": 1, "rate": 0.139, "balance": 12.5, "is_banned": false, "status": 0}`)
+		}
+		return mockResponse(200, `{"status": 0, "is_service_overloaded": true}`, "Date", date)
+	})
+	report, err := client.Ping(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Healthy() || !report.ServiceOverloaded || report.Balance != 12.5 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if report.ClockSkew < 50*time.Second || report.ClockSkew > 70*time.Second {
+		t.Errorf("got a clock skew of %s, want about a minute", report.ClockSkew)
+	}
+
+	client, _ = newMockClient(nil, func(req *http.Request, body []byte) *http.Response {
+		if strings.HasSuffix(req.URL.Path, "/user") {
+			return mockResponse(403, "")
+		}
+		return mockResponse(200, `{"status": 0, "is_service_overloaded": false}`)
+	})
+	report, err = client.Ping(context.Background())
+	if !errors.Is(err, ErrCredentialsRejected) || report.Err != err {
+		t.Fatalf("got %v, want ErrCredentialsRejected", err)
+	}
+	if !report.Reachable || report.CredentialsValid || report.Healthy() {
+		t.Fatalf("unexpected report %+v", report)
+	}
+}
@@ -0,0 +1,119 @@
+package godbc
+
+import (
+	"errors"
+	"mime"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+//ErrUnsupportedContentType - The response body is in a format Hydrate cannot decode
+var ErrUnsupportedContentType = errors.New("Response content type is not supported")
+
+//jsonContentType is the content type of the api responses requested by the client
+const jsonContentType = "application/json"
+
+/*Hydrator is an api response filled from a raw body, so transports other than HTTP (a socket, the daemon) decode responses with the same rules as the client
+  CaptchaResponse, UserResponse and StatusResponse implement it
+*/
+type Hydrator interface {
+	/*Hydrate decodes the body into the response and checks its status, a status other than 0 is returned as a *ServiceError
+	  contentType: the media type of the body, JSON if empty. The api's url-encoded format is accepted too
+	*/
+	Hydrate(body []byte, contentType string) error
+}
+
+//Hydrate decodes a captcha submission or report response, see Hydrator. Polls are checked further by ParsePollResponse
+func (r *CaptchaResponse) Hydrate(body []byte, contentType string) error {
+	return hydrate(r, body, contentType)
+}
+
+//Hydrate decodes a `user` response, see Hydrator
+func (r *UserResponse) Hydrate(body []byte, contentType string) error {
+	return hydrate(r, body, contentType)
+}
+
+//Hydrate decodes a `status` response, see Hydrator
+func (r *StatusResponse) Hydrate(body []byte, contentType string) error {
+	return hydrate(r, body, contentType)
+}
+
+//hydrate decodes a body in the given content type, and checks its status
+func hydrate(response apiStatus, body []byte, contentType string) error {
+	mediaType := jsonContentType
+	if contentType != "" {
+		parsed, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return ErrUnsupportedContentType
+		}
+		mediaType = parsed
+	}
+
+	switch {
+	case mediaType == jsonContentType || mediaType == "text/json" || strings.HasSuffix(mediaType, "+json"):
+		if err := decodeResponse(body, response); err != nil {
+			return err
+		}
+	case mediaType == "application/x-www-form-urlencoded":
+		if err := decodeForm(body, response); err != nil {
+			return err
+		}
+	default:
+		return ErrUnsupportedContentType
+	}
+
+	if status, message := response.apiStatus(); status != StatusOK {
+		return newServiceError(status, message)
+	}
+	return nil
+}
+
+//decodeForm decodes an url-encoded body into the fields of a struct, matched by their json name
+func decodeForm(body []byte, response interface{}) error {
+	values, err := url.ParseQuery(strings.TrimSpace(string(body)))
+	if err != nil || len(values) == 0 {
+		return ErrUnexpectedServerResponse
+	}
+
+	target := reflect.ValueOf(response).Elem()
+	for i := 0; i < target.NumField(); i++ {
+		name := strings.Split(target.Type().Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		if _, ok := values[name]; !ok {
+			continue
+		}
+		if err := setFormField(target.Field(i), values.Get(name)); err != nil {
+			return ErrUnexpectedServerResponse
+		}
+	}
+	return nil
+}
+
+//setFormField sets a field from its url-encoded value
+func setFormField(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		field.SetBool(value == "1" || value == "true")
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return ErrUnexpectedServerResponse
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package godbc
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHydrate(t *testing.T) {
+	captcha := &CaptchaResponse{}
+	if err := captcha.Hydrate([]byte("status=0&captcha=123&is_correct=1&text=abc"), "application/x-www-form-urlencoded; charset=utf-8"); err != nil {
+		t.Fatal(err)
+	}
+	if captcha.ID != 123 || !captcha.IsCorrect || captcha.Text != "abc" {
+		t.Fatalf("unexpected response %+v", captcha)
+	}
+
+	status := &StatusResponse{}
+	if err := status.Hydrate([]byte(`{"status": 0, "is_service_overloaded": true}`), ""); err != nil {
+		t.Fatal(err)
+	}
+	if !status.IsServiceOverloaded {
+		t.Fatalf("unexpected response %+v", status)
+	}
+	status = &StatusResponse{}
+	if err := status.Hydrate([]byte("status=0&is_service_overloaded=1&solved_in=12.5"), "application/x-www-form-urlencoded"); err != nil || !status.IsServiceOverloaded || status.SolvedIn != 12.5 {
+		t.Fatalf("unexpected response %+v, %v", status, err)
+	}
+
+	user := &UserResponse{}
+	var serviceErr *ServiceError
+	if err := user.Hydrate([]byte("status=255&error=not-logged-in"), "application/x-www-form-urlencoded"); !errors.As(err, &serviceErr) {
+		t.Fatalf("got %v, want a *ServiceError", err)
+	}
+	if err := user.Hydrate([]byte("<html></html>"), "text/html"); err != ErrUnsupportedContentType {
+		t.Fatalf("got %v, want ErrUnsupportedContentType", err)
+	}
+	if err := user.Hydrate([]byte("<html></html>"), "application/json"); err != ErrUnexpectedServerResponse {
+		t.Fatalf("got %v, want ErrUnexpectedServerResponse", err)
+	}
+}
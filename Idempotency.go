@@ -0,0 +1,165 @@
+package godbc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sync"
+	"time"
+)
+
+//Bounds of the pending uploads kept by IdempotentSubmit
+const (
+	pendingUploadTTL  = 10 * time.Minute
+	maxPendingUploads = 1024
+)
+
+//reconcileSkew is how long before its submission a captcha listed by RecentCaptchas may have been uploaded, for the clocks of the client and the service to differ
+const reconcileSkew = 5 * time.Second
+
+//errAmbiguousUpload - several captchas were uploaded since a lost submission, which one it is can not be told
+var errAmbiguousUpload = errors.New("Several captchas were uploaded since the submission")
+
+//uploadKey returns the idempotency token of an image submission
+func uploadKey(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+type duplicateKey struct{}
+
+//withDuplicates returns a context whose submissions are meant as duplicates of one another, IdempotentSubmit does not merge them
+func withDuplicates(ctx context.Context) context.Context {
+	return context.WithValue(ctx, duplicateKey{}, true)
+}
+
+func isDuplicate(ctx context.Context) bool {
+	duplicate, _ := ctx.Value(duplicateKey{}).(bool)
+	return duplicate
+}
+
+type pendingUpload struct {
+	ressource *CaptchaResponse
+	at        time.Time
+}
+
+//pendingUploads keeps the submitted captchas that are not yet solved, by idempotency token. Captchas never waited for expire after 10 minutes, and the oldest are dropped past 1024
+type pendingUploads struct {
+	mu    sync.Mutex
+	byKey map[string]pendingUpload
+	byID  map[int64]string
+}
+
+func (p *pendingUploads) get(key string, now time.Time) *CaptchaResponse {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pending, ok := p.byKey[key]
+	if !ok || now.Sub(pending.at) >= pendingUploadTTL {
+		return nil
+	}
+	return pending.ressource
+}
+
+func (p *pendingUploads) put(key string, ressource *CaptchaResponse, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.byKey == nil {
+		p.byKey = map[string]pendingUpload{}
+		p.byID = map[int64]string{}
+	}
+	var oldest string
+	for other, pending := range p.byKey {
+		if now.Sub(pending.at) >= pendingUploadTTL {
+			p.remove(other)
+		} else if oldest == "" || pending.at.Before(p.byKey[oldest].at) {
+			oldest = other
+		}
+	}
+	if len(p.byKey) >= maxPendingUploads {
+		p.remove(oldest)
+	}
+	p.byKey[key] = pendingUpload{ressource: ressource, at: now}
+	p.byID[ressource.ID] = key
+}
+
+func (p *pendingUploads) forget(id int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok := p.byID[id]; ok {
+		p.remove(key)
+	}
+}
+
+//remove drops a pending upload, the lock is held by the caller
+func (p *pendingUploads) remove(key string) {
+	if pending, ok := p.byKey[key]; ok {
+		delete(p.byID, pending.ressource.ID)
+		delete(p.byKey, key)
+	}
+}
+
+/*sendReconciled sends a submission. When its outcome is ambiguous, the body was written but the response was lost, the account's recent
+  uploads tell whether the service received it: the captcha uploaded since is returned, and the submission is sent once more when there is none.
+  The error is returned when the listing is unavailable or several captchas were uploaded since, rather than risk paying the captcha twice
+*/
+func (c *Client) sendReconciled(ctx context.Context, submittedAt time.Time, newRequest func(ctx context.Context) (*http.Request, error)) (*CaptchaResponse, error) {
+	for attempt := 0; ; attempt++ {
+		wrote := false
+		traced := httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+			WroteRequest: func(info httptrace.WroteRequestInfo) {
+				wrote = info.Err == nil
+			},
+		})
+		req, err := newRequest(traced)
+		if err != nil {
+			return nil, err
+		}
+
+		header, body, err := c.doRequest(req)
+		if err == nil {
+			return c.parseSubmission(withRoute(ctx, RouteUpload, 0), header, body, submittedAt)
+		}
+		if attempt > 0 || !wrote || ctx.Err() != nil || !isLostResponse(err) {
+			return nil, err
+		}
+
+		uploaded, listErr := c.uploadedSince(ctx, submittedAt)
+		if listErr != nil {
+			return nil, err
+		}
+		if uploaded != nil {
+			return c.registerSubmission(withRoute(ctx, RouteUpload, 0), uploaded, "", submittedAt), nil
+		}
+	}
+}
+
+//uploadedSince returns the captcha the account uploaded since a submission that the client does not know of, nil when there is none
+func (c *Client) uploadedSince(ctx context.Context, submittedAt time.Time) (*CaptchaResponse, error) {
+	captchas, err := c.RecentCaptchas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	since := submittedAt.Truncate(time.Second).Add(-reconcileSkew)
+	var uploaded *CaptchaResponse
+	for _, captcha := range captchas {
+		if captcha.SubmittedAt.Before(since) || c.submitted.submittedByClient(captcha.ID) {
+			continue
+		}
+		if uploaded != nil {
+			return nil, errAmbiguousUpload
+		}
+		uploaded = captcha
+	}
+	return uploaded, nil
+}
+
+//isLostResponse returns whether an api call failed without an answer of the service: the connection failed or timed out, or the body was cut
+func isLostResponse(err error) bool {
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,135 @@
+package godbc
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+//lossyServer is an api whose uploads are received but whose responses are dropped, listing the uploads in captcha/recent
+type lossyServer struct {
+	*httptest.Server
+
+	mu sync.Mutex
+	//lose - the uploads whose connection is closed without a response
+	lose    int
+	uploads int
+	//recorded - whether an upload whose response is lost is listed
+	recorded bool
+	//recent - the status of captcha/recent
+	recent int
+	listed []string
+}
+
+func newLossyServer(t *testing.T) *lossyServer {
+	s := &lossyServer{recorded: true, recent: 200}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if strings.HasSuffix(r.URL.Path, "/captcha/recent") {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(s.recent)
+			w.Write([]byte(`{"status": 0, "captchas": [` + strings.Join(s.listed, ",") + `]}`))
+			return
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		if strings.Contains(string(body), "idempotency_key") {
+			t.Error("the upload carries a field the api does not know")
+		}
+		s.uploads++
+		id := strconv.Itoa(100 + s.uploads)
+		if s.lose > 0 {
+			s.lose--
+			if s.recorded {
+				s.listed = append([]string{`{"captcha": ` + id + `, "is_correct": true, "text": "", "uploaded": ` + strconv.FormatInt(time.Now().Unix(), 10) + `}`}, s.listed...)
+			}
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		s.listed = append([]string{`{"captcha": ` + id + `, "is_correct": true, "text": "", "uploaded": ` + strconv.FormatInt(time.Now().Unix(), 10) + `}`}, s.listed...)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"captcha": ` + id + `, "is_correct": true, "text": "", "status": 0}`))
+	}))
+	return s
+}
+
+func (s *lossyServer) client(t *testing.T) *Client {
+	endpoint, _ := url.Parse(s.URL + "/api/")
+	return NewClient("user", "password", &ClientOptions{Endpoint: endpoint, IdempotentSubmit: true, CaptchaRetries: 1})
+}
+
+func TestIdempotentSubmitReconciles(t *testing.T) {
+	server := newLossyServer(t)
+	defer server.Close()
+	client := server.client(t)
+	ctx := context.Background()
+
+	//an earlier upload of the client is not mistaken for the lost one
+	earlier, err := client.CaptchaWithOptions(ctx, noiseImage(t, 16, 16), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.lose = 1
+	response, err := client.CaptchaWithOptions(ctx, benchmarkImage(t), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.ID != earlier.ID+1 || server.uploads != 2 {
+		t.Fatalf("got captcha %d after %d uploads, want the lost upload found in the listing", response.ID, server.uploads)
+	}
+	if again, err := client.CaptchaWithOptions(ctx, benchmarkImage(t), nil); err != nil || again.ID != response.ID || server.uploads != 2 {
+		t.Fatalf("the pending captcha was uploaded again: %v", err)
+	}
+}
+
+func TestIdempotentSubmitResends(t *testing.T) {
+	server := newLossyServer(t)
+	defer server.Close()
+	server.lose, server.recorded = 1, false
+	response, err := server.client(t).CaptchaWithOptions(context.Background(), benchmarkImage(t), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.ID != 102 || server.uploads != 2 {
+		t.Fatalf("got captcha %d after %d uploads, want the upload sent once more", response.ID, server.uploads)
+	}
+}
+
+func TestIdempotentSubmitUnreconciled(t *testing.T) {
+	server := newLossyServer(t)
+	defer server.Close()
+	server.lose, server.recent = 1, 404
+	if _, err := server.client(t).CaptchaWithOptions(context.Background(), benchmarkImage(t), nil); err == nil || !isLostResponse(err) {
+		t.Fatalf("got %v, want the lost response", err)
+	}
+	if server.uploads != 1 {
+		t.Fatalf("sent %d uploads without a listing to reconcile with", server.uploads)
+	}
+}
+
+func TestPendingUploadsEviction(t *testing.T) {
+	uploads := &pendingUploads{}
+	now := time.Now()
+	uploads.put("old", &CaptchaResponse{ID: 1}, now)
+	if uploads.get("old", now.Add(pendingUploadTTL)) != nil {
+		t.Fatal("an expired upload was returned")
+	}
+	for i := 0; i < maxPendingUploads+1; i++ {
+		uploads.put(strconv.Itoa(i), &CaptchaResponse{ID: int64(i + 2)}, now.Add(time.Duration(i)))
+	}
+	if len(uploads.byKey) != maxPendingUploads || len(uploads.byID) != maxPendingUploads {
+		t.Fatalf("kept %d uploads, want %d", len(uploads.byKey), maxPendingUploads)
+	}
+	if uploads.get("0", now) != nil || uploads.get("old", now) != nil {
+		t.Fatal("the oldest uploads were kept")
+	}
+}
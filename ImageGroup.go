@@ -0,0 +1,184 @@
+package godbc
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+//ImageGroupResult is the typed result of an image group captcha
+type ImageGroupResult struct {
+	//Selected - indexes of the matching tiles, as numbered by the service (starting at 1 from the top left tile, row by row)
+	Selected []int
+	//Tiles - indexes of the matching tiles in the request's Tiles, from 0, for stitched requests
+	Tiles []int
+	//Captcha - the solved captcha, to be used for reporting
+	Captcha *CaptchaResponse
+}
+
+//Error codes returned for image group requests
+var (
+	//ErrMissingBanner - An image group captcha needs its instruction, as a banner image or text
+	ErrMissingBanner = errors.New("Image group captcha needs a banner image or text")
+	//ErrInvalidGrid - The grid is malformed, or the candidates image can not be split into its tiles
+	ErrInvalidGrid = errors.New("Grid does not match the candidates image")
+)
+
+//Bounds of an image group grid
+const (
+	maxGridSide = 8
+	minTileSide = 16
+)
+
+/*ImageGroupRequest is an image group captcha, its instruction given as a banner image and/or text
+  Candidates: a single image holding all the candidate tiles laid out in a grid
+  Banner: the sample or instruction image shown above the tiles, may be nil with BannerText
+  BannerText: the instruction, e.g. "Select all images with a bus", may be empty with Banner
+  Grid: the layout of the candidates as columns x rows, e.g. "2x4" for 4 rows of 2 tiles, may be empty to let the service guess
+  Tiles: the candidate tiles as separate images, stitched into Candidates with Layout, and the Grid it results in, on submission
+*/
+type ImageGroupRequest struct {
+	Candidates []byte
+	Banner     []byte
+	BannerText string
+	Grid       string
+	Tiles      [][]byte
+	Layout     StitchLayout
+}
+
+/*ParseGrid parses a grid layout formatted as "<columns>x<rows>", width by height as the service reads it
+  Returns ErrInvalidGrid when it is malformed or has more than 8 rows or columns
+*/
+func ParseGrid(grid string) (columns, rows int, err error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(grid)), "x")
+	if len(parts) != 2 {
+		return 0, 0, ErrInvalidGrid
+	}
+	columns, err = strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, ErrInvalidGrid
+	}
+	rows, err = strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, ErrInvalidGrid
+	}
+	if rows < 1 || columns < 1 || rows > maxGridSide || columns > maxGridSide {
+		return 0, 0, ErrInvalidGrid
+	}
+	return columns, rows, nil
+}
+
+/*Validate checks the request can be submitted
+  The images must be valid, see ValidateImage, and the candidates image must split into tiles of at least 16 pixels,
+  no more than twice as wide as high or the other way round, as the tiles of a mismatched grid would be
+*/
+func (r *ImageGroupRequest) Validate() error {
+	if len(r.Banner) == 0 && strings.TrimSpace(r.BannerText) == "" {
+		return ErrMissingBanner
+	}
+	if len(r.Banner) > 0 {
+		if _, err := ValidateImage(r.Banner); err != nil {
+			return err
+		}
+	}
+	if _, err := ValidateImage(r.Candidates); err != nil {
+		return err
+	}
+	if r.Grid == "" {
+		return nil
+	}
+
+	columns, rows, err := ParseGrid(r.Grid)
+	if err != nil {
+		return err
+	}
+	info, err := InspectImage(r.Candidates)
+	if err != nil {
+		return err
+	}
+	tileWidth, tileHeight := info.Width/columns, info.Height/rows
+	if tileWidth < minTileSide || tileHeight < minTileSide || tileWidth > 2*tileHeight || tileHeight > 2*tileWidth {
+		return ErrInvalidGrid
+	}
+	return nil
+}
+
+/*ImageGroupCaptcha will solve a "pick the images matching the sample" captcha through the image group api
+  reference: the sample image the candidates are compared to
+  candidates: a single image holding all the candidate tiles laid out in a grid
+  grid: the layout of the candidates as columns x rows, e.g. "3x3", may be empty to let the service guess
+*/
+func (c *Client) ImageGroupCaptcha(ctx context.Context, reference, candidates []byte, grid string) (*ImageGroupResult, error) {
+	return c.ImageGroup(ctx, ImageGroupRequest{Candidates: candidates, Banner: reference, Grid: grid})
+}
+
+//ImageGroup will solve an image group captcha, with its banner image and/or text. Tiles are stitched, then the images are preprocessed and the request validated before submission
+func (c *Client) ImageGroup(ctx context.Context, request ImageGroupRequest) (*ImageGroupResult, error) {
+	var stitched *Stitched
+	if len(request.Tiles) > 0 {
+		var err error
+		if stitched, err = Stitch(request.Tiles, request.Layout); err != nil {
+			return nil, err
+		}
+		request.Candidates, request.Grid = stitched.Image, stitched.Grid()
+	}
+	var err error
+	if request.Candidates, err = c.preprocess(request.Candidates); err != nil {
+		return nil, err
+	}
+	if len(request.Banner) > 0 {
+		if request.Banner, err = c.preprocess(request.Banner); err != nil {
+			return nil, err
+		}
+	}
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+
+	v := url.Values{}
+	v.Set("type", TypeImageGroup.String())
+	if len(request.Banner) > 0 {
+		v.Set("banner", base64.StdEncoding.EncodeToString(request.Banner))
+	}
+	if request.BannerText != "" {
+		v.Set("banner_text", request.BannerText)
+	}
+	if request.Grid != "" {
+		v.Set("grid", request.Grid)
+	}
+
+	ressource, err := c.uploadImage(ctx, request.Candidates, v, nil)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := c.WaitCaptchaWithContext(ctx, ressource)
+	if err != nil {
+		return nil, err
+	}
+
+	selected, err := ParseSelection(resolved.Text)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImageGroupResult{Selected: selected, Captcha: resolved}
+	if stitched != nil {
+		result.Tiles = stitched.Tiles(selected)
+	}
+	return result, nil
+}
+
+//ParseSelection parses the text of a solved image group captcha, formatted as [i1,i2,...]
+func ParseSelection(text string) ([]int, error) {
+	selected := []int{}
+	err := json.Unmarshal([]byte(text), &selected)
+	if err != nil {
+		return nil, ErrCaptchaInvalid
+	}
+
+	return selected, nil
+}
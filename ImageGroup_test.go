@@ -0,0 +1,58 @@
+package godbc
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestImageGroupRequest(t *testing.T) {
+	client, transport := newMockClient(nil, func(req *http.Request, body []byte) *http.Response {
+		if req.Method == http.MethodPost {
+			return mockResponse(200, `{"captcha": 42, "is_correct": true, "text": "", "status": 0}`)
+		}
+		return mockResponse(200, `{"captcha": 42, "is_correct": true, "text": "[1,3]", "status": 0}`)
+	})
+	ctx := context.Background()
+	content := benchmarkImage(t)
+
+	for _, c := range []struct {
+		request ImageGroupRequest
+		want    error
+	}{
+		{ImageGroupRequest{Candidates: content, Grid: "3x1"}, ErrMissingBanner},
+		{ImageGroupRequest{Candidates: content, BannerText: "buses", Grid: "3"}, ErrInvalidGrid},
+		{ImageGroupRequest{Candidates: content, BannerText: "buses", Grid: "3x3"}, ErrInvalidGrid},
+		//the 200x70 candidates do not split into 3 rows of 1 tile
+		{ImageGroupRequest{Candidates: content, BannerText: "buses", Grid: "1x3"}, ErrInvalidGrid},
+		{ImageGroupRequest{Candidates: content, Banner: []byte("banner"), Grid: "3x1"}, ErrContentTooShort},
+	} {
+		if _, err := client.ImageGroup(ctx, c.request); err != c.want {
+			t.Errorf("%+v: got %v, want %v", c.request.Grid, err, c.want)
+		}
+	}
+
+	result, err := client.ImageGroup(ctx, ImageGroupRequest{Candidates: content, BannerText: "Select all images with a bus", Grid: "3x1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Selected) != 2 || result.Selected[0] != 1 || result.Selected[1] != 3 {
+		t.Fatalf("unexpected selection %v", result.Selected)
+	}
+	upload := transport.bodies[len(transport.bodies)-2]
+	if !bytes.Contains(upload, []byte(`name="banner_text"`)) || !bytes.Contains(upload, []byte("Select all images with a bus")) || bytes.Contains(upload, []byte(`name="banner"`)) {
+		t.Errorf("unexpected upload fields")
+	}
+}
+
+func TestParseGrid(t *testing.T) {
+	if columns, rows, err := ParseGrid("2x4"); err != nil || columns != 2 || rows != 4 {
+		t.Fatalf("got %d columns and %d rows, want 4 rows of 2: %v", columns, rows, err)
+	}
+	for _, grid := range []string{"", "2", "0x3", "9x1", "ax2"} {
+		if _, _, err := ParseGrid(grid); err != ErrInvalidGrid {
+			t.Errorf("%q: got %v, want ErrInvalidGrid", grid, err)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package godbc
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+//SolveState is a step of the lifecycle of a captcha waited for by WaitCaptcha
+type SolveState int
+
+//Solve states, see Transition for the transitions between them
+const (
+	//StateSubmitted - the captcha was submitted and has not been polled yet
+	StateSubmitted SolveState = iota + 1
+	//StatePolling - the captcha is polled until the service solves it
+	StatePolling
+	//StateSolved - the service solved the captcha, its answer has not been validated yet
+	StateSolved
+	//StateValidated - the answer passed the PostProcessors and the validators of its profile
+	StateValidated
+	//StateAccepted - the answer is returned, the final state of a successful solve
+	StateAccepted
+	//StateReported - the captcha was reported, as the service could not solve it or its answer was rejected
+	StateReported
+	//StateResubmitted - the reported captcha was submitted again, and has not been polled yet
+	StateResubmitted
+	//StateFailed - the captcha could not be solved, the final state of a failed solve
+	StateFailed
+)
+
+//String returns the name of the state
+func (s SolveState) String() string {
+	switch s {
+	case StateSubmitted:
+		return "submitted"
+	case StatePolling:
+		return "polling"
+	case StateSolved:
+		return "solved"
+	case StateValidated:
+		return "validated"
+	case StateAccepted:
+		return "accepted"
+	case StateReported:
+		return "reported"
+	case StateResubmitted:
+		return "resubmitted"
+	case StateFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
+//Terminal returns whether the state ends the lifecycle
+func (s SolveState) Terminal() bool {
+	return s == StateAccepted || s == StateFailed
+}
+
+/*Transition is a change of state of a captcha. The lifecycle goes
+  submitted → polling → solved → validated → accepted, and on a captcha the service could not solve or a rejected answer
+  polling or solved → reported → resubmitted → polling, or → failed once the retries are spent
+*/
+type Transition struct {
+	From, To SolveState
+	Job      Job
+	Err      error
+}
+
+/*Job is the persisted state of a captcha waited for, see JobStore
+  Key: identifies the solve across resubmissions, the correlation ID of the captcha or the ID of its first submission
+  CaptchaID: the submission being waited for
+  Attempt: the submission attempt, from 1
+  Text: the answer of image captchas once validated, empty with the Privacy option
+  Err: the error of a failed solve
+*/
+type Job struct {
+	Key         string
+	CaptchaID   int64
+	CaptchaType string
+	State       SolveState
+	Attempt     int
+	Text        string
+	Err         string
+	StartedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+//JobStore persists the state of the captchas waited for at each transition, so a restarted process knows which solves were in flight. Errors of the store do not interrupt the solve
+type JobStore interface {
+	SaveJob(ctx context.Context, job Job) error
+}
+
+//MemoryJobStore is a JobStore keeping the last state of each job in memory
+type MemoryJobStore struct {
+	mu   sync.Mutex
+	jobs map[string]Job
+}
+
+//NewMemoryJobStore returns an empty in-memory job store
+func NewMemoryJobStore() *MemoryJobStore {
+	return &MemoryJobStore{jobs: map[string]Job{}}
+}
+
+//SaveJob records the state of the job
+func (s *MemoryJobStore) SaveJob(ctx context.Context, job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.Key] = job
+	return nil
+}
+
+//Job returns the last state of a job
+func (s *MemoryJobStore) Job(key string) (Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[key]
+	return job, ok
+}
+
+//Pending returns the jobs not in a terminal state, oldest first
+func (s *MemoryJobStore) Pending() []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pending := []Job{}
+	for _, job := range s.jobs {
+		if !job.State.Terminal() {
+			pending = append(pending, job)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].StartedAt.Before(pending[j].StartedAt) })
+	return pending
+}
+
+//solveMachine runs the lifecycle of a captcha waited for
+type solveMachine struct {
+	c          *Client
+	ctx        context.Context
+	job        Job
+	validation *answerValidation
+	ressource  *CaptchaResponse
+	solved     *CaptchaResponse
+	err        error
+	//rejected - the captcha is reported for its answer, not because the service could not solve it
+	rejected         bool
+	invalid, answers int
+	//raw - the answer is not image text, it is accepted as the service returns it
+	raw bool
+}
+
+func (c *Client) newSolveMachine(ctx context.Context, ressource *CaptchaResponse) *solveMachine {
+	now := time.Now()
+	key := ressource.CorrelationID
+	if key == "" {
+		key = strconv.FormatInt(ressource.ID, 10)
+	}
+	return &solveMachine{
+		c:          c,
+		ctx:        ctx,
+		validation: ressource.validation,
+		ressource:  ressource,
+		raw:        ressource.rawAnswer || (ressource.captchaType != "" && ressource.captchaType != TypeImage.String()),
+		job:        Job{Key: key, CaptchaID: ressource.ID, CaptchaType: ressource.captchaType, Attempt: 1, StartedAt: now, UpdatedAt: now},
+	}
+}
+
+//transition moves the machine to a state, persisting the job and calling the hook
+func (m *solveMachine) transition(to SolveState) {
+	from := m.job.State
+	m.job.State, m.job.UpdatedAt = to, time.Now()
+	if to == StateFailed && m.err != nil {
+		m.job.Err = m.err.Error()
+	}
+	options := m.c.opts()
+	if options.JobStore != nil {
+		options.JobStore.SaveJob(m.ctx, m.job)
+	}
+	if options.OnTransition != nil && from != 0 {
+		options.OnTransition(Transition{From: from, To: to, Job: m.job, Err: m.err})
+	}
+}
+
+//run drives the captcha from its submission to StateAccepted or StateFailed
+func (m *solveMachine) run(progress func(attempt int, elapsed time.Duration)) (*CaptchaResponse, error) {
+	c, ctx := m.c, m.ctx
+	m.transition(StateSubmitted)
+	for {
+		switch m.job.State {
+		case StateSubmitted, StateResubmitted:
+			m.transition(StatePolling)
+		case StatePolling:
+			m.solved, m.err = c.waitSolved(ctx, m.ressource, progress)
+			switch {
+			case m.err == nil:
+				m.solved.SolvedAt = time.Now()
+				m.solved.Attempts = m.job.Attempt
+				m.solved.TotalWait = m.solved.SolvedAt.Sub(m.job.StartedAt)
+				m.transition(StateSolved)
+			case m.err == ErrCaptchaInvalid && m.invalid < c.opts().InvalidRetries && m.ressource.resubmit != nil:
+				m.invalid++
+				m.rejected = false
+				c.reportCaptcha(ctx, m.ressource, false)
+				m.transition(StateReported)
+			default:
+				m.transition(StateFailed)
+			}
+		case StateSolved:
+			if m.solved.token != nil || m.raw {
+				m.transition(StateAccepted)
+				continue
+			}
+			var text string
+			if text, m.err = c.postProcess(m.solved.Text, m.validation); m.err == nil {
+				m.solved.Text = text
+				if !c.opts().Privacy {
+					m.job.Text = text
+				}
+				m.transition(StateValidated)
+				continue
+			}
+			m.rejected = true
+			c.reportCaptcha(ctx, m.solved, true)
+			m.transition(StateReported)
+		case StateValidated:
+			m.transition(StateAccepted)
+		case StateReported:
+			if m.rejected {
+				if m.answers >= m.validation.retries(c.opts().ValidationRetries) || m.ressource.resubmit == nil {
+					m.transition(StateFailed)
+					continue
+				}
+				m.answers++
+			}
+			m.job.Attempt = 1 + m.invalid + m.answers
+			var resubmitted *CaptchaResponse
+			if resubmitted, m.err = m.ressource.resubmit(withAttempt(ctx, m.job.Attempt)); m.err != nil {
+				m.transition(StateFailed)
+				continue
+			}
+			resubmitted.validation = m.validation
+			m.ressource, m.job.CaptchaID, m.err = resubmitted, resubmitted.ID, nil
+			m.transition(StateResubmitted)
+		case StateAccepted:
+			return m.solved, nil
+		default:
+			return nil, m.err
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package godbc
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSolveLifecycle(t *testing.T) {
+	answers := []string{"bad", "good"}
+	store := NewMemoryJobStore()
+	var states []string
+	client := NewClient("user", "password", &ClientOptions{
+		CaptchaRetries:    5,
+		PostProcessors:    []PostProcessor{MatchRegexp(`^good$`)},
+		ValidationRetries: 1,
+		JobStore:          store,
+		OnTransition: func(transition Transition) {
+			if len(states) == 0 {
+				states = append(states, transition.From.String())
+			}
+			states = append(states, transition.To.String())
+		},
+		Sandbox: &SandboxConfig{Solve: func(content []byte) (string, error) {
+			answer := answers[0]
+			answers = answers[1:]
+			return answer, nil
+		}},
+	})
+
+	solved, err := client.Solve(WithCorrelationID(context.Background(), "job-1"), benchmarkImage(t), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "submitted polling solved reported resubmitted polling solved validated accepted"
+	if got := strings.Join(states, " "); got != want {
+		t.Fatalf("got transitions %s, want %s", got, want)
+	}
+	job, ok := store.Job("job-1")
+	if !ok {
+		t.Fatal("the job was not persisted")
+	}
+	if job.State != StateAccepted || job.Attempt != 2 || job.CaptchaID != solved.ID || job.Text != "good" {
+		t.Fatalf("unexpected job %+v", job)
+	}
+	if pending := store.Pending(); len(pending) != 0 {
+		t.Fatalf("unexpected pending jobs %+v", pending)
+	}
+}
@@ -0,0 +1,18 @@
+package godbc
+
+import (
+	"context"
+	"time"
+)
+
+//Limiter paces the api calls of the client, implementations can share one budget across processes, see the limiter package
+type Limiter interface {
+	//Wait blocks until a call is allowed, or the context is done
+	Wait(ctx context.Context) error
+}
+
+//LimiterBackoff is implemented by limiters that hold calls back when the service rate limits the client
+type LimiterBackoff interface {
+	//Backoff delays calls until the given time
+	Backoff(until time.Time)
+}
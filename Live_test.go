@@ -0,0 +1,66 @@
+//go:build live
+
+package godbc
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+//The live tests call the service with the DBCUSERNAME and DBCPASSWORD credentials, and spend credit:
+//	DBCUSERNAME=... DBCPASSWORD=... go test -tags live -run Live
+//DBCCAPTCHA can name an image to solve instead of the generated one
+
+func liveClient(t *testing.T) *Client {
+	username, password := os.Getenv("DBCUSERNAME"), os.Getenv("DBCPASSWORD")
+	if username == "" || password == "" {
+		t.Skip("DBCUSERNAME and DBCPASSWORD are not set")
+	}
+	return DefaultClient(username, password)
+}
+
+func TestLiveAccount(t *testing.T) {
+	client := liveClient(t)
+	status, err := client.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("status: %+v", status)
+
+	user, err := client.User()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.IsBanned || !user.HasCreditLeft() {
+		t.Fatalf("user is banned or has no credit left: %+v", user)
+	}
+}
+
+func TestLiveCaptcha(t *testing.T) {
+	client := liveClient(t)
+	content := benchmarkImage(t)
+	if path := os.Getenv("DBCCAPTCHA"); path != "" {
+		var err error
+		content, err = ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	ressource, err := client.CaptchaWithOptions(ctx, content, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolved, err := client.WaitCaptchaWithContext(ctx, ressource)
+	if err != nil && err != ErrCaptchaInvalid {
+		t.Fatal(err)
+	}
+	if resolved != nil {
+		t.Logf("captcha %d solved as %q", resolved.ID, resolved.Text)
+	}
+}
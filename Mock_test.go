@@ -0,0 +1,248 @@
+package godbc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+//mockTransport answers every request with its handler and keeps the requests, with their body, for assertions
+type mockTransport struct {
+	handler func(req *http.Request, body []byte) *http.Response
+
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (m *mockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	m.mu.Lock()
+	m.requests = append(m.requests, req)
+	m.bodies = append(m.bodies, body)
+	m.mu.Unlock()
+
+	resp := m.handler(req, body)
+	resp.Request = req
+	return resp, nil
+}
+
+//last returns the last request sent and its body
+func (m *mockTransport) last(t *testing.T) (*http.Request, []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.requests) == 0 {
+		t.Fatal("no request was sent")
+	}
+	return m.requests[len(m.requests)-1], m.bodies[len(m.bodies)-1]
+}
+
+//mockResponse returns a response with the given status and body, and headers as key, value pairs
+func mockResponse(statusCode int, body string, headers ...string) *http.Response {
+	header := http.Header{"Content-Type": {"application/json"}}
+	for i := 0; i+1 < len(headers); i += 2 {
+		header.Set(headers[i], headers[i+1])
+	}
+	return &http.Response{
+		StatusCode:    statusCode,
+		Status:        strconv.Itoa(statusCode) + " " + http.StatusText(statusCode),
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewBufferString(body)),
+		ContentLength: int64(len(body)),
+	}
+}
+
+//newMockClient returns a client sending its requests to the handler
+func newMockClient(options *ClientOptions, handler func(req *http.Request, body []byte) *http.Response) (*Client, *mockTransport) {
+	transport := &mockTransport{handler: handler}
+	client := NewClient("user", "password", options)
+	client.WithHTTPClient(&http.Client{Transport: transport})
+	return client, transport
+}
+
+//newSandboxClient returns a client running against the sandbox
+func newSandboxClient(config SandboxConfig) *Client {
+	return NewClient("user", "password", &ClientOptions{Sandbox: &config, CaptchaRetries: 5})
+}
+
+func TestHTTPStatusErrors(t *testing.T) {
+	cases := []struct {
+		statusCode int
+		want       error
+	}{
+		{403, ErrCredentialsRejected},
+		{400, ErrCaptchaRejected},
+		{413, ErrPayloadTooLarge},
+		{429, ErrRateLimited},
+		{500, ErrUnexpectedServerError},
+		{503, ErrOverloadedServer},
+	}
+	for _, tc := range cases {
+		client, _ := newMockClient(nil, func(req *http.Request, body []byte) *http.Response {
+			return mockResponse(tc.statusCode, "", "Retry-After", "2")
+		})
+		_, err := client.CaptchaWithOptions(context.Background(), benchmarkImage(t), nil)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%d: got %v, want %v", tc.statusCode, err, tc.want)
+		}
+		if tc.statusCode == 429 && RetryAfter(err) != 2*time.Second {
+			t.Errorf("429: got a retry after of %s, want 2s", RetryAfter(err))
+		}
+	}
+}
+
+func TestServiceErrors(t *testing.T) {
+	cases := map[string]error{
+		"banned":             ErrBanned,
+		"insufficient-funds": ErrInsufficientFunds,
+		"not-logged-in":      ErrCredentialsRejected,
+		"something-new":      ErrServiceBug,
+	}
+	for code, want := range cases {
+		client, _ := newMockClient(nil, func(req *http.Request, body []byte) *http.Response {
+			return mockResponse(200, `{"status": 255, "error": "`+code+`"}`)
+		})
+		_, err := client.UserWithContext(context.Background())
+		var serviceErr *ServiceError
+		if !errors.As(err, &serviceErr) || serviceErr.Code != code || !errors.Is(err, want) {
+			t.Errorf("%s: got %v, want %v", code, err, want)
+		}
+	}
+}
+
+func TestImageUploadRequest(t *testing.T) {
+	client, transport := newMockClient(nil, func(req *http.Request, body []byte) *http.Response {
+		return mockResponse(200, `{"captcha": 42, "is_correct": true, "text": "", "status": 0}`)
+	})
+	content := benchmarkImage(t)
+	response, err := client.CaptchaWithOptions(context.Background(), content, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.ID != 42 || response.Format != FormatPNG {
+		t.Fatalf("unexpected response %+v", response)
+	}
+
+	req, body := transport.last(t)
+	if req.Method != `POST` || req.URL.Path != "/api/captcha" {
+		t.Fatalf("unexpected request %s %s", req.Method, req.URL)
+	}
+	if req.Header.Get("Accept") != "application/json" {
+		t.Errorf("unexpected Accept header %q", req.Header.Get("Accept"))
+	}
+	if req.ContentLength != int64(len(body)) {
+		t.Errorf("Content-Length is %d for a body of %d bytes", req.ContentLength, len(body))
+	}
+	_, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	form, err := multipart.NewReader(bytes.NewReader(body), params["boundary"]).ReadForm(int64(len(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if form.Value["username"][0] != "user" || form.Value["password"][0] != "password" {
+		t.Errorf("unexpected credentials %v", form.Value)
+	}
+	if files := form.File["captchafile"]; len(files) != 1 || files[0].Size != int64(len(content)) {
+		t.Errorf("unexpected captcha file %v", form.File)
+	}
+}
+
+func TestTokenRequest(t *testing.T) {
+	client, transport := newMockClient(nil, func(req *http.Request, body []byte) *http.Response {
+		return mockResponse(200, `{"captcha": 7, "is_correct": true, "text": "", "status": 0}`)
+	})
+	_, err := client.Hcaptcha(context.Background(), HcaptchaRequestPayload{PageURL: "https://example.com", SiteKey: "key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, body := transport.last(t)
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if form.Get("type") != TypeHcaptcha.String() || form.Get("hcaptcha_params") == "" {
+		t.Errorf("unexpected form %v", form)
+	}
+}
+
+func TestMalformedResponse(t *testing.T) {
+	client, _ := newMockClient(nil, func(req *http.Request, body []byte) *http.Response {
+		return mockResponse(200, `<html>502 Bad Gateway</html>`, "Content-Type", "text/html")
+	})
+	_, err := client.StatusWithContext(context.Background())
+	if err != ErrUnexpectedServerResponse {
+		t.Fatalf("got %v, want ErrUnexpectedServerResponse", err)
+	}
+}
+
+func TestSandboxSolve(t *testing.T) {
+	client := newSandboxClient(SandboxConfig{Answer: "abcdef"})
+	ctx := context.Background()
+	ressource, err := client.CaptchaWithOptions(ctx, benchmarkImage(t), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolved, err := client.WaitCaptchaWithContext(ctx, ressource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.Text != "abcdef" || resolved.ID != ressource.ID || resolved.Attempts != 1 {
+		t.Fatalf("unexpected response %+v", resolved)
+	}
+
+	_, err = client.ReportCaptchaWithContext(ctx, resolved)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSandboxUnsolvable(t *testing.T) {
+	client := newSandboxClient(SandboxConfig{FailureRate: 1})
+	ctx := context.Background()
+	ressource, err := client.CaptchaWithOptions(ctx, benchmarkImage(t), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.WaitCaptchaWithContext(ctx, ressource)
+	if err != ErrCaptchaInvalid {
+		t.Fatalf("got %v, want ErrCaptchaInvalid", err)
+	}
+}
+
+func TestSandboxAccount(t *testing.T) {
+	client := newSandboxClient(SandboxConfig{Balance: 12.5, Rate: 0.139})
+	user, err := client.User()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Balance != 12.5 || !user.HasCreditLeft() {
+		t.Fatalf("unexpected user %+v", user)
+	}
+	status, err := client.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.IsServiceOverloaded {
+		t.Fatalf("unexpected status %+v", status)
+	}
+}
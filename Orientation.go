@@ -0,0 +1,144 @@
+package godbc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/draw"
+	"image/jpeg"
+)
+
+//orientationQuality is the JPEG quality of images rotated by NormalizeOrientation
+const orientationQuality = 95
+
+//exifOrientationTag is the EXIF tag of the orientation of the image
+const exifOrientationTag = 0x0112
+
+/*NormalizeOrientation rotates and flips JPEG images as their EXIF orientation says they are displayed, as phones save photos and screenshots
+  sideways with the orientation to apply. Images without EXIF orientation, or already upright, are returned as-is
+  Returns ErrImageDimensions for images over MaxImageDimension
+*/
+func NormalizeOrientation() Preprocessor {
+	return func(content []byte) ([]byte, error) {
+		orientation := ExifOrientation(content)
+		if orientation <= 1 || orientation > 8 {
+			return content, nil
+		}
+		//the dimensions are checked before decoding, so a small file can not expand to a huge image
+		if _, err := InspectImage(content); err != nil {
+			return nil, err
+		}
+		img, err := jpeg.Decode(bytes.NewReader(content))
+		if err != nil {
+			return nil, ErrInvalidFormat
+		}
+
+		buf := &bytes.Buffer{}
+		if err := jpeg.Encode(buf, orient(img, orientation), &jpeg.Options{Quality: orientationQuality}); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+}
+
+//ExifOrientation returns the EXIF orientation of a JPEG image, from 1 (upright) to 8, or 0 when it has none
+func ExifOrientation(content []byte) int {
+	if DetectFormat(content) != FormatJPEG {
+		return 0
+	}
+	for i := 2; i+4 <= len(content); {
+		if content[i] != 0xFF {
+			return 0
+		}
+		marker := content[i+1]
+		if marker == 0xD8 || marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			i += 2
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 {
+			//the image data starts, metadata comes before it
+			return 0
+		}
+		length := int(binary.BigEndian.Uint16(content[i+2 : i+4]))
+		if length < 2 || i+2+length > len(content) {
+			return 0
+		}
+		segment := content[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 0
+}
+
+//tiffOrientation reads the orientation tag of the first IFD of a TIFF header
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	offset := int(order.Uint32(tiff[4:8]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 0
+	}
+	entries := int(order.Uint16(tiff[offset : offset+2]))
+	for i := 0; i < entries; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:entry+2]) == exifOrientationTag {
+			return int(order.Uint16(tiff[entry+8 : entry+10]))
+		}
+	}
+	return 0
+}
+
+//orient returns the image as displayed with the EXIF orientation, from 2 to 8
+func orient(img image.Image, orientation int) image.Image {
+	bounds := img.Bounds()
+	src := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+	width, height := bounds.Dx(), bounds.Dy()
+
+	dstWidth, dstHeight := width, height
+	if orientation >= 5 {
+		//orientations 5 to 8 turn the image a quarter
+		dstWidth, dstHeight = height, width
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = width-1-x, y
+			case 3:
+				dx, dy = width-1-x, height-1-y
+			case 4:
+				dx, dy = x, height-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = height-1-y, x
+			case 7:
+				dx, dy = height-1-y, width-1-x
+			case 8:
+				dx, dy = y, width-1-x
+			default:
+				dx, dy = x, y
+			}
+			copy(dst.Pix[dst.PixOffset(dx, dy):dst.PixOffset(dx, dy)+4], src.Pix[src.PixOffset(x, y):src.PixOffset(x, y)+4])
+		}
+	}
+	return dst
+}
@@ -0,0 +1,380 @@
+package godbc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+//ErrPoolClosed is returned by a Pool once Close was called
+var ErrPoolClosed = errors.New("Pool is closed")
+
+//poolStatusInterval is how often a Pool refreshes the service's average solve time
+const poolStatusInterval = 30 * time.Second
+
+//Priority is the lane of a captcha in a Pool
+type Priority int
+
+const (
+	//PriorityBatch - background captchas, solved when no interactive captcha is waiting
+	PriorityBatch Priority = iota
+	//PriorityInteractive - captchas someone is waiting on, e.g. blocking a login, they jump ahead of batch captchas
+	PriorityInteractive
+)
+
+//PoolOptions are settings of a Pool
+type PoolOptions struct {
+	//Workers - how many captchas are solved at the same time, 1 if unset
+	Workers int
+	//TargetLatency - when the average solve time goes over it, workers wait for the difference before taking new captchas. 0 disables pacing
+	TargetLatency time.Duration
+	//Retries - how many times a captcha failing with a retryable error is solved again, see IsRetryable
+	Retries int
+	//Quotas - enforced on the tenant of each captcha, see WithTenant. May be nil
+	Quotas *Quotas
+	//Profiles - the profiles of SolveFor, the client's Profiles if nil. Their MaxConcurrent caps the captchas solved at the same time per site key or domain
+	Profiles *ProfileRegistry
+	//OnBackpressure - called whenever a worker slows down its intake, may be nil
+	OnBackpressure func(BackpressureEvent)
+}
+
+//BackpressureEvent is sent when a Pool slows down its intake because captchas take too long to solve
+type BackpressureEvent struct {
+	At time.Time
+	//AverageSolveTime - the solve time that triggered the slow down, the worst of the service's average and the pool's recent solves
+	AverageSolveTime time.Duration
+	//Delay - how long the worker waits before taking a new captcha
+	Delay time.Duration
+}
+
+//Pool solves image captchas with a fixed number of workers, pacing its intake when the service degrades
+type Pool struct {
+	client  *Client
+	options PoolOptions
+	lanes   [PriorityInteractive + 1]chan *poolJob
+	cancel  context.CancelFunc
+	closed  chan struct{}
+	closing sync.Once
+	wg      sync.WaitGroup
+
+	mu             sync.Mutex
+	recentLatency  time.Duration
+	serviceLatency time.Duration
+	statusAt       time.Time
+	sites          map[siteKey]*siteSlots
+}
+
+//siteKey is a site key or domain with the MaxConcurrent of its profile, profiles sharing a key with different limits do not share slots
+type siteKey struct {
+	key string
+	max int
+}
+
+//siteSlots are the slots of a site, dropped once no captcha holds or waits for one
+type siteSlots struct {
+	slots chan struct{}
+	users int
+}
+
+type poolJob struct {
+	ctx  context.Context
+	run  func(ctx context.Context) (*CaptchaResponse, error)
+	done chan poolResult
+}
+
+type poolResult struct {
+	response *CaptchaResponse
+	err      error
+}
+
+//NewPool starts the workers of a pool, it runs until Close is called
+func NewPool(client *Client, options PoolOptions) *Pool {
+	if options.Workers < 1 {
+		options.Workers = 1
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool{
+		client:  client,
+		options: options,
+		cancel:  cancel,
+		closed:  make(chan struct{}),
+	}
+	for i := range p.lanes {
+		p.lanes[i] = make(chan *poolJob)
+	}
+	for i := 0; i < options.Workers; i++ {
+		p.wg.Add(1)
+		go p.work(ctx)
+	}
+
+	return p
+}
+
+/*Solve queues a batch image captcha and waits for its answer
+  options: solving hints, may be nil
+*/
+func (p *Pool) Solve(ctx context.Context, content []byte, options *CaptchaOptions) (*CaptchaResponse, error) {
+	return p.SolveWithPriority(ctx, content, options, PriorityBatch)
+}
+
+/*SolveWithPriority queues an image captcha in the lane of the given priority and waits for its answer
+  Returns ErrQuotaExceeded when the tenant of the context used up its quota
+  options: solving hints, may be nil
+*/
+func (p *Pool) SolveWithPriority(ctx context.Context, content []byte, options *CaptchaOptions, priority Priority) (*CaptchaResponse, error) {
+	return p.reserve(ctx, priority, func(ctx context.Context) (*CaptchaResponse, error) {
+		return p.client.Solve(ctx, content, options, nil)
+	})
+}
+
+/*SolveFor queues the captcha of a page, solved with the profile registered for its url, and waits for the solution
+  When the profile has MaxConcurrent set, the captcha waits for its site key or domain to have fewer captchas being solved before it is queued
+  extra: the captcha image for image profiles
+*/
+func (p *Pool) SolveFor(ctx context.Context, pageurl string, extra ...[]byte) (*CaptchaResponse, error) {
+	profiles := p.options.Profiles
+	if profiles == nil {
+		profiles = p.client.opts().Profiles
+	}
+	if profiles == nil {
+		return nil, ErrNoProfile
+	}
+	profile, ok := profiles.Match(pageurl)
+	if !ok {
+		return nil, ErrNoProfile
+	}
+
+	if profile.MaxConcurrent > 0 {
+		release, err := p.acquireSite(ctx, profile.concurrencyKey(pageurl), profile.MaxConcurrent)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+	return p.reserve(ctx, PriorityBatch, func(ctx context.Context) (*CaptchaResponse, error) {
+		return p.client.solveProfile(ctx, profile, pageurl, extra...)
+	})
+}
+
+//acquireSite waits for a free slot of a site key or domain, the returned function frees it
+func (p *Pool) acquireSite(ctx context.Context, key string, max int) (func(), error) {
+	site := siteKey{key: key, max: max}
+	p.mu.Lock()
+	if p.sites == nil {
+		p.sites = map[siteKey]*siteSlots{}
+	}
+	slots, ok := p.sites[site]
+	if !ok {
+		slots = &siteSlots{slots: make(chan struct{}, max)}
+		p.sites[site] = slots
+	}
+	slots.users++
+	p.mu.Unlock()
+
+	leave := func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if slots.users--; slots.users == 0 {
+			delete(p.sites, site)
+		}
+	}
+	select {
+	case <-ctx.Done():
+		leave()
+		return nil, ctx.Err()
+	case <-p.closed:
+		leave()
+		return nil, ErrPoolClosed
+	case slots.slots <- struct{}{}:
+		return func() {
+			<-slots.slots
+			leave()
+		}, nil
+	}
+}
+
+//reserve charges the tenant's quota for a captcha, then queues it and waits for its answer
+func (p *Pool) reserve(ctx context.Context, priority Priority, run func(ctx context.Context) (*CaptchaResponse, error)) (*CaptchaResponse, error) {
+	if priority < PriorityBatch || priority > PriorityInteractive {
+		priority = PriorityBatch
+	}
+	if quotas := p.options.Quotas; quotas != nil {
+		tenant, price := TenantFrom(ctx), p.client.price()
+		if price == 0 && quotas.limitsSpend(tenant) {
+			//the spend can not be checked without the rate, the captcha is refused when it can not be fetched
+			var err error
+			if price, err = p.client.fetchPrice(ctx); err != nil {
+				return nil, err
+			}
+		}
+		if err := quotas.reserve(tenant, price); err != nil {
+			return nil, err
+		}
+		response, err := p.enqueue(ctx, priority, run)
+		if err != nil {
+			quotas.release(tenant, price)
+		}
+		return response, err
+	}
+	return p.enqueue(ctx, priority, run)
+}
+
+//enqueue queues a captcha and waits for its answer
+func (p *Pool) enqueue(ctx context.Context, priority Priority, run func(ctx context.Context) (*CaptchaResponse, error)) (*CaptchaResponse, error) {
+	job := &poolJob{ctx: ctx, run: run, done: make(chan poolResult, 1)}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-p.closed:
+		return nil, ErrPoolClosed
+	case p.lanes[priority] <- job:
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-job.done:
+		return result.response, result.err
+	}
+}
+
+//Close stops the workers, captchas being solved are abandoned. It may be called more than once
+func (p *Pool) Close() {
+	p.closing.Do(func() {
+		p.cancel()
+		close(p.closed)
+	})
+	p.wg.Wait()
+}
+
+//work paces and solves queued captchas
+func (p *Pool) work(ctx context.Context) {
+	defer p.wg.Done()
+	for {
+		if !p.pace(ctx) {
+			return
+		}
+		job := p.next(ctx)
+		if job == nil {
+			return
+		}
+		response, err := p.solve(ctx, job)
+		job.done <- poolResult{response: response, err: err}
+	}
+}
+
+//next waits for a queued captcha, interactive captchas first, it returns nil when the pool is closed
+func (p *Pool) next(ctx context.Context) *poolJob {
+	select {
+	case job := <-p.lanes[PriorityInteractive]:
+		return job
+	default:
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case job := <-p.lanes[PriorityInteractive]:
+		return job
+	case job := <-p.lanes[PriorityBatch]:
+		return job
+	}
+}
+
+//solve solves a job, bound to both the pool's and the caller's context
+func (p *Pool) solve(ctx context.Context, job *poolJob) (*CaptchaResponse, error) {
+	jobCtx, cancel := context.WithCancel(job.ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-ctx.Done():
+			cancel()
+		case <-jobCtx.Done():
+		}
+	}()
+
+	response, err := job.run(jobCtx)
+	for retry := 0; retry < p.options.Retries && IsRetryable(err) && jobCtx.Err() == nil; retry++ {
+		wait := RetryAfter(err)
+		if wait < time.Second {
+			wait = time.Second
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-jobCtx.Done():
+		case <-timer.C:
+		}
+		timer.Stop()
+		if jobCtx.Err() != nil {
+			return nil, jobCtx.Err()
+		}
+		response, err = job.run(jobCtx)
+	}
+	if err == nil && !response.Local {
+		p.observe(response.SolvedAt.Sub(response.SubmittedAt))
+	}
+	return response, err
+}
+
+//pace waits before taking a new captcha when solves take longer than the target latency, it returns false when the pool is closed
+func (p *Pool) pace(ctx context.Context) bool {
+	if p.options.TargetLatency <= 0 {
+		return ctx.Err() == nil
+	}
+
+	average := p.averageSolveTime(ctx)
+	if average <= p.options.TargetLatency {
+		return ctx.Err() == nil
+	}
+	delay := average - p.options.TargetLatency
+	if p.options.OnBackpressure != nil {
+		p.options.OnBackpressure(BackpressureEvent{At: time.Now(), AverageSolveTime: average, Delay: delay})
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+//observe blends the latency of a solve into the pool's recent latency
+func (p *Pool) observe(latency time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.recentLatency == 0 {
+		p.recentLatency = latency
+		return
+	}
+	p.recentLatency = (p.recentLatency*4 + latency) / 5
+}
+
+//averageSolveTime returns the worst of the service's average solve time and the pool's recent latency
+func (p *Pool) averageSolveTime(ctx context.Context) time.Duration {
+	p.mu.Lock()
+	refresh := time.Since(p.statusAt) > poolStatusInterval
+	if refresh {
+		p.statusAt = time.Now()
+	}
+	p.mu.Unlock()
+
+	if refresh {
+		if status, err := p.client.StatusWithContext(ctx); err == nil {
+			p.mu.Lock()
+			p.serviceLatency = time.Duration(status.SolvedIn * float64(time.Second))
+			p.mu.Unlock()
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.recentLatency > p.serviceLatency {
+		return p.recentLatency
+	}
+	return p.serviceLatency
+}
@@ -0,0 +1,92 @@
+package godbc
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestPoolSiteConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	profiles := NewProfileRegistry()
+	profiles.Register(`^https://example\.com/`, Profile{Type: int(TypeRecaptchaV2), SiteKey: "key", MaxConcurrent: 1})
+	client := NewClient("user", "password", &ClientOptions{Sandbox: &SandboxConfig{}, CaptchaRetries: 5, OnEvent: func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch e.Type {
+		case EventSubmitted:
+			inFlight++
+		case EventSolved, EventFailed:
+			inFlight--
+		}
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+	}})
+	pool := NewPool(client, PoolOptions{Workers: 3, Profiles: profiles})
+	defer pool.Close()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := pool.SolveFor(context.Background(), "https://example.com/login")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if maxInFlight != 1 {
+		t.Fatalf("%d captchas of the site key were solved at the same time, want 1", maxInFlight)
+	}
+	if _, err := pool.SolveFor(context.Background(), "https://other.com/"); err != ErrNoProfile {
+		t.Fatalf("got %v, want ErrNoProfile", err)
+	}
+}
+
+func TestPoolSiteSlots(t *testing.T) {
+	pool := NewPool(newSandboxClient(SandboxConfig{}), PoolOptions{})
+	defer pool.Close()
+	ctx := context.Background()
+
+	release, err := pool.acquireSite(ctx, "key", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//a profile sharing the key with another limit keeps its own slots
+	other, err := pool.acquireSite(ctx, "key", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pool.sites) != 2 {
+		t.Fatalf("got %d sites, want the limits kept apart", len(pool.sites))
+	}
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := pool.acquireSite(cancelled, "key", 1); err != context.Canceled {
+		t.Fatalf("got %v, want the full site waited for", err)
+	}
+
+	release()
+	other()
+	if len(pool.sites) != 0 {
+		t.Fatalf("kept %d idle sites", len(pool.sites))
+	}
+}
+
+func TestPoolCloseTwice(t *testing.T) {
+	pool := NewPool(newSandboxClient(SandboxConfig{}), PoolOptions{})
+	pool.Close()
+	pool.Close()
+	if _, err := pool.Solve(context.Background(), benchmarkImage(t), nil); err != ErrPoolClosed {
+		t.Fatalf("got %v, want ErrPoolClosed", err)
+	}
+}
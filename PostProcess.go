@@ -0,0 +1,133 @@
+package godbc
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+//ErrAnswerRejected is returned when a solved answer does not pass validation
+var ErrAnswerRejected = errors.New("Answer was rejected by validation")
+
+//PostProcessor transforms the text of a solved captcha, an error rejects the answer
+type PostProcessor func(text string) (string, error)
+
+//DigitConfusables maps letters commonly misread for digits to those digits, for numeric captchas
+var DigitConfusables = map[rune]rune{'O': '0', 'o': '0', 'D': '0', 'l': '1', 'I': '1', 'i': '1', 'Z': '2', 'S': '5', 's': '5', 'B': '8'}
+
+//LetterConfusables maps digits commonly misread for letters to those letters, for alphabetic captchas
+var LetterConfusables = map[rune]rune{'0': 'O', '1': 'l', '2': 'Z', '5': 'S', '8': 'B'}
+
+//TrimSpace removes leading and trailing white space
+func TrimSpace() PostProcessor {
+	return func(text string) (string, error) {
+		return strings.TrimSpace(text), nil
+	}
+}
+
+//Lowercase maps the answer to lower case
+func Lowercase() PostProcessor {
+	return func(text string) (string, error) {
+		return strings.ToLower(text), nil
+	}
+}
+
+//Uppercase maps the answer to upper case
+func Uppercase() PostProcessor {
+	return func(text string) (string, error) {
+		return strings.ToUpper(text), nil
+	}
+}
+
+//MapConfusables replaces every character found in mapping, see DigitConfusables and LetterConfusables
+func MapConfusables(mapping map[rune]rune) PostProcessor {
+	return func(text string) (string, error) {
+		return strings.Map(func(r rune) rune {
+			if replacement, ok := mapping[r]; ok {
+				return replacement
+			}
+			return r
+		}, text), nil
+	}
+}
+
+//MatchRegexp rejects answers that do not match pattern, it panics if pattern does not compile
+func MatchRegexp(pattern string) PostProcessor {
+	re := regexp.MustCompile(pattern)
+	return func(text string) (string, error) {
+		if !re.MatchString(text) {
+			return "", ErrAnswerRejected
+		}
+		return text, nil
+	}
+}
+
+//Length rejects answers shorter than min or longer than max characters, max is not checked when 0
+func Length(min, max int) PostProcessor {
+	return func(text string) (string, error) {
+		length := utf8.RuneCountInString(text)
+		if length < min || (max > 0 && length > max) {
+			return "", ErrAnswerRejected
+		}
+		return text, nil
+	}
+}
+
+//Charset rejects answers with characters not in allowed, e.g. "0123456789"
+func Charset(allowed string) PostProcessor {
+	return func(text string) (string, error) {
+		for _, r := range text {
+			if !strings.ContainsRune(allowed, r) {
+				return "", ErrAnswerRejected
+			}
+		}
+		return text, nil
+	}
+}
+
+//answerValidation is the validation of the answers of a profile's captchas
+type answerValidation struct {
+	validators []PostProcessor
+	maxRetries int
+}
+
+//retries returns how many times a rejected answer is submitted again, fallback when the profile does not set it
+func (v *answerValidation) retries(fallback int) int {
+	if v == nil || v.maxRetries <= 0 {
+		return fallback
+	}
+	return v.maxRetries
+}
+
+//with returns the validation followed by validate, v may be nil
+func (v *answerValidation) with(validate func(text string) error) *answerValidation {
+	extended := &answerValidation{validators: []PostProcessor{func(text string) (string, error) {
+		return text, validate(text)
+	}}}
+	if v != nil {
+		extended.validators = append(append([]PostProcessor{}, v.validators...), extended.validators...)
+		extended.maxRetries = v.maxRetries
+	}
+	return extended
+}
+
+//postProcess runs the configured post processors on a solved answer, then the validators of its profile
+func (c *Client) postProcess(text string, validation *answerValidation) (string, error) {
+	for _, process := range c.opts().PostProcessors {
+		var err error
+		if text, err = process(text); err != nil {
+			return "", err
+		}
+	}
+	if validation == nil {
+		return text, nil
+	}
+	for _, validate := range validation.validators {
+		var err error
+		if text, err = validate(text); err != nil {
+			return "", err
+		}
+	}
+	return text, nil
+}
@@ -0,0 +1,76 @@
+package godbc
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPostProcessors(t *testing.T) {
+	for _, tc := range []struct {
+		process PostProcessor
+		text    string
+		want    string
+		err     error
+	}{
+		{TrimSpace(), " abc\n", "abc", nil},
+		{Lowercase(), "AbC", "abc", nil},
+		{Uppercase(), "AbC", "ABC", nil},
+		{MapConfusables(DigitConfusables), "4O1S", "4015", nil},
+		{MapConfusables(LetterConfusables), "H3110", "H3llO", nil},
+		{MatchRegexp(`^[a-z]+$`), "abc1", "", ErrAnswerRejected},
+		{Length(3, 0), "abcdefgh", "abcdefgh", nil},
+		{Length(3, 5), "çàé", "çàé", nil},
+		{Length(3, 5), "abcdef", "", ErrAnswerRejected},
+		{Charset("0123456789"), "12a", "", ErrAnswerRejected},
+	} {
+		text, err := tc.process(tc.text)
+		if text != tc.want || err != tc.err {
+			t.Errorf("%q: got %q, %v, want %q, %v", tc.text, text, err, tc.want, tc.err)
+		}
+	}
+}
+
+func TestPostProcessedAnswer(t *testing.T) {
+	client := NewClient("user", "password", &ClientOptions{
+		CaptchaRetries: 5,
+		PostProcessors: []PostProcessor{TrimSpace(), MapConfusables(DigitConfusables)},
+		Sandbox:        &SandboxConfig{Answer: " 4O1S "},
+	})
+	ressource, err := client.CaptchaWithOptions(context.Background(), benchmarkImage(t), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolved, err := client.WaitCaptchaWithContext(context.Background(), ressource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.Text != "4015" {
+		t.Fatalf("got the answer %q, want 4015", resolved.Text)
+	}
+}
+
+func TestRawAnswers(t *testing.T) {
+	newClient := func(answer string) *Client {
+		return NewClient("user", "password", &ClientOptions{
+			CaptchaRetries: 5,
+			PostProcessors: []PostProcessor{Charset("0123456789"), Length(4, 6)},
+			Sandbox:        &SandboxConfig{Answer: answer},
+		})
+	}
+
+	slider, err := newClient("[[120,40]]").SliderCaptcha(context.Background(), benchmarkImage(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slider.Offset != (Point{X: 120, Y: 40}) || slider.Captcha.Text != "[[120,40]]" || slider.Captcha.Attempts != 1 {
+		t.Fatalf("the slider answer was post-processed: %+v", slider.Captcha)
+	}
+
+	rotated, err := newClient("90").RotateCaptcha(context.Background(), benchmarkImage(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.Angle != 90 || rotated.Captcha.Attempts != 1 {
+		t.Fatalf("the rotation answer was post-processed: %+v", rotated.Captcha)
+	}
+}
@@ -0,0 +1,35 @@
+package godbc
+
+import (
+	"context"
+)
+
+//PreSolver attempts to solve image captchas locally (e.g. with an OCR) before they are sent to the service
+type PreSolver interface {
+	//PreSolve returns the captcha text and a confidence between 0 and 1
+	PreSolve(ctx context.Context, content []byte) (text string, confidence float64, err error)
+}
+
+//defaultPreSolverConfidence is the confidence a local solution needs to be used, when not configured
+const defaultPreSolverConfidence = 0.9
+
+/*preSolve returns a locally solved captcha, or nil when the pre-solver is not confident enough and the service has to be used
+  Locally solved captchas have no ID, are returned as-is by WaitCaptcha and are never reported to the service
+*/
+func (c *Client) preSolve(ctx context.Context, content []byte) *CaptchaResponse {
+	options := c.opts()
+	if options.PreSolver == nil {
+		return nil
+	}
+	minConfidence := options.PreSolverMinConfidence
+	if minConfidence <= 0 {
+		minConfidence = defaultPreSolverConfidence
+	}
+
+	text, confidence, err := options.PreSolver.PreSolve(ctx, content)
+	if err != nil || text == "" || confidence < minConfidence {
+		return nil
+	}
+
+	return c.describe(&CaptchaResponse{IsCorrect: true, Text: text, Confidence: confidence, Local: true}, "")
+}
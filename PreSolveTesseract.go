@@ -0,0 +1,58 @@
+//go:build gosseract
+
+package godbc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/otiai10/gosseract/v2"
+)
+
+//TesseractPreSolver is a PreSolver backed by a local Tesseract OCR, available with the `gosseract` build tag
+type TesseractPreSolver struct {
+	//Whitelist - characters the answer can contain, e.g. "0123456789", may be empty
+	Whitelist string
+	//Languages - tesseract languages, defaults to tesseract's default
+	Languages []string
+}
+
+//PreSolve reads the captcha text with Tesseract, the confidence is the average of its words confidence
+func (t *TesseractPreSolver) PreSolve(ctx context.Context, content []byte) (string, float64, error) {
+	client := gosseract.NewClient()
+	defer client.Close()
+
+	err := client.SetImageFromBytes(content)
+	if err != nil {
+		return "", 0, err
+	}
+	if t.Whitelist != "" {
+		err = client.SetWhitelist(t.Whitelist)
+		if err != nil {
+			return "", 0, err
+		}
+	}
+	if len(t.Languages) > 0 {
+		err = client.SetLanguage(t.Languages...)
+		if err != nil {
+			return "", 0, err
+		}
+	}
+
+	boxes, err := client.GetBoundingBoxes(gosseract.RIL_WORD)
+	if err != nil {
+		return "", 0, err
+	}
+	if len(boxes) == 0 {
+		return "", 0, nil
+	}
+
+	words := make([]string, 0, len(boxes))
+	confidence := 0.0
+	for _, box := range boxes {
+		words = append(words, box.Word)
+		confidence += box.Confidence
+	}
+
+	return strings.Join(words, " "), confidence / float64(len(boxes)) / 100, nil
+}
@@ -0,0 +1,66 @@
+package godbc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+//fixedPreSolver answers every captcha with the same text and confidence
+type fixedPreSolver struct {
+	text       string
+	confidence float64
+	err        error
+}
+
+func (s fixedPreSolver) PreSolve(ctx context.Context, content []byte) (string, float64, error) {
+	return s.text, s.confidence, s.err
+}
+
+func TestPreSolver(t *testing.T) {
+	cases := []struct {
+		solver        fixedPreSolver
+		minConfidence float64
+		local         bool
+	}{
+		{fixedPreSolver{text: "local", confidence: 0.95}, 0, true},
+		{fixedPreSolver{text: "local", confidence: 0.8}, 0, false},
+		{fixedPreSolver{text: "local", confidence: 0.8}, 0.5, true},
+		{fixedPreSolver{text: "", confidence: 1}, 0, false},
+		{fixedPreSolver{text: "local", confidence: 1, err: errors.New("ocr failed")}, 0, false},
+	}
+	for _, tc := range cases {
+		client, transport := newMockClient(&ClientOptions{PreSolver: tc.solver, PreSolverMinConfidence: tc.minConfidence}, func(req *http.Request, body []byte) *http.Response {
+			if req.Method == `POST` {
+				return mockResponse(200, `{"captcha": 42, "is_correct": true, "text": "", "status": 0}`)
+			}
+			return mockResponse(200, `{"captcha": 42, "is_correct": true, "text": "remote", "status": 0}`)
+		})
+		ctx := context.Background()
+		ressource, err := client.CaptchaWithOptions(ctx, benchmarkImage(t), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resolved, err := client.WaitCaptchaWithContext(ctx, ressource)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !tc.local {
+			if resolved.Local || resolved.Text != "remote" {
+				t.Errorf("%+v: got %+v, want the captcha solved by the service", tc.solver, resolved)
+			}
+			continue
+		}
+		if !resolved.Local || resolved.Text != "local" || resolved.ID != 0 {
+			t.Errorf("%+v: got %+v, want the local answer", tc.solver, resolved)
+		}
+		if _, err := client.ReportCaptchaWithContext(ctx, resolved); err != nil {
+			t.Error(err)
+		}
+		if len(transport.requests) != 0 {
+			t.Errorf("%+v: a local answer reached the service", tc.solver)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package godbc
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+)
+
+//Preprocessor transforms an image captcha before it is uploaded, returning content as-is when it has nothing to do. An error aborts the submission
+type Preprocessor func(content []byte) ([]byte, error)
+
+//Bounds of the FitSize search
+const (
+	fitMaxQuality    = 90
+	fitQualityStep   = 10
+	fitScaleStep     = 0.75
+	fitMinDimension  = 32
+	defaultFitFloor  = 30
+	defaultFitTarget = MaxContentSize
+)
+
+/*FitSize re-encodes images over maxSize bytes to JPEG, at decreasing qualities down to minQuality, then downscaled, until they fit
+  maxSize: the size to fit in, MaxContentSize if 0
+  minQuality: the lowest JPEG quality tried before downscaling, between 1 and 100, 30 if 0
+  Returns ErrContentTooBig when the image would have to shrink under 32 pixels, or is a BMP as BMP images can not be decoded,
+  ErrImageDimensions when it is over MaxImageDimension, ErrInvalidFormat when it can not be decoded
+*/
+func FitSize(maxSize, minQuality int) Preprocessor {
+	if maxSize <= 0 {
+		maxSize = defaultFitTarget
+	}
+	if minQuality <= 0 {
+		minQuality = defaultFitFloor
+	}
+	if minQuality > 100 {
+		minQuality = 100
+	}
+	return func(content []byte) ([]byte, error) {
+		if len(content) <= maxSize {
+			return content, nil
+		}
+		//the dimensions are checked before decoding, so a small file can not expand to a huge image
+		info, err := InspectImage(content)
+		if err != nil {
+			return nil, err
+		}
+		if info.Format == FormatBMP {
+			//no BMP decoder is registered, the image can not be re-encoded and stays too big
+			return nil, ErrContentTooBig
+		}
+		img, _, err := image.Decode(bytes.NewReader(content))
+		if err != nil {
+			return nil, ErrInvalidFormat
+		}
+		//JPEG has no transparency, transparent pixels are flattened on white
+		flat := image.NewRGBA(img.Bounds())
+		draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+		draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+
+		buf := &bytes.Buffer{}
+		current := flat
+		for {
+			for quality := fitMaxQuality; ; quality -= fitQualityStep {
+				if quality < minQuality {
+					quality = minQuality
+				}
+				buf.Reset()
+				if err := jpeg.Encode(buf, current, &jpeg.Options{Quality: quality}); err != nil {
+					return nil, err
+				}
+				if buf.Len() <= maxSize {
+					return buf.Bytes(), nil
+				}
+				if quality == minQuality {
+					break
+				}
+			}
+
+			width := int(float64(current.Bounds().Dx()) * fitScaleStep)
+			height := int(float64(current.Bounds().Dy()) * fitScaleStep)
+			if width < fitMinDimension || height < fitMinDimension {
+				return nil, ErrContentTooBig
+			}
+			current = downscale(flat, width, height)
+		}
+	}
+}
+
+//downscale resizes an image to a smaller size, averaging the source pixels each destination pixel covers
+func downscale(src *image.RGBA, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := bounds.Min.Y + (y+1)*bounds.Dy()/height
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := bounds.Min.X + (x+1)*bounds.Dx()/width
+			var r, g, b, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					i := src.PixOffset(sx, sy)
+					r += uint32(src.Pix[i])
+					g += uint32(src.Pix[i+1])
+					b += uint32(src.Pix[i+2])
+					a += uint32(src.Pix[i+3])
+					n++
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3] = uint8(r/n), uint8(g/n), uint8(b/n), uint8(a/n)
+		}
+	}
+	return dst
+}
+
+//preprocess runs the configured preprocessors on an image captcha
+func (c *Client) preprocess(content []byte) ([]byte, error) {
+	for _, process := range c.opts().Preprocessors {
+		var err error
+		if content, err = process(content); err != nil {
+			return nil, err
+		}
+	}
+	return content, nil
+}
@@ -0,0 +1,361 @@
+package godbc
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+//noiseImage returns a PNG of random pixels, which compresses poorly
+func noiseImage(t *testing.T, width, height int) []byte {
+	random := rand.New(rand.NewSource(1))
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	random.Read(img.Pix)
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestFitSize(t *testing.T) {
+	small := benchmarkImage(t)
+	if fitted, err := FitSize(0, 0)(small); err != nil || !bytes.Equal(fitted, small) {
+		t.Fatalf("an image under the limit was changed: %v", err)
+	}
+
+	big := noiseImage(t, 500, 500)
+	if len(big) <= MaxContentSize {
+		t.Fatalf("the test image is only %d bytes", len(big))
+	}
+	fitted, err := FitSize(0, 0)(big)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fitted) > MaxContentSize || DetectFormat(fitted) != FormatJPEG {
+		t.Fatalf("got a %s of %d bytes", DetectFormat(fitted), len(fitted))
+	}
+
+	//a quality floor too high to fit forces downscaling
+	fitted, err = FitSize(40*1024, 95)(big)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := InspectImage(fitted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fitted) > 40*1024 || info.Width >= 500 {
+		t.Fatalf("got %d bytes at %dx%d", len(fitted), info.Width, info.Height)
+	}
+
+	//a 400x200 24 bits BMP, over the limit
+	bmp := make([]byte, 54+400*200*3)
+	copy(bmp, "BM")
+	binary.LittleEndian.PutUint32(bmp[18:22], 400)
+	binary.LittleEndian.PutUint32(bmp[22:26], 200)
+	if _, err := FitSize(0, 0)(bmp); err != ErrContentTooBig {
+		t.Fatalf("got %v for a BMP, want ErrContentTooBig", err)
+	}
+
+	if _, err := FitSize(100, 0)(big); err != ErrContentTooBig {
+		t.Fatalf("got %v, want ErrContentTooBig", err)
+	}
+
+	//a compressed image of huge dimensions is refused before it is decoded
+	wide := &bytes.Buffer{}
+	png.Encode(wide, image.NewGray(image.Rect(0, 0, MaxImageDimension+1, 1)))
+	if _, err := FitSize(10, 0)(wide.Bytes()); err != ErrImageDimensions {
+		t.Fatalf("got %v, want ErrImageDimensions", err)
+	}
+
+	client, transport := newMockClient(&ClientOptions{Preprocessors: []Preprocessor{FitSize(0, 0)}}, func(req *http.Request, body []byte) *http.Response {
+		return mockResponse(200, `{"captcha": 42, "is_correct": true, "text": "", "status": 0}`)
+	})
+	response, err := client.CaptchaWithOptions(context.Background(), big, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, body := transport.last(t); response.Format != FormatJPEG || len(body) > MaxContentSize+4096 {
+		t.Fatalf("uploaded a %s in %d bytes", response.Format, len(body))
+	}
+}
+
+func TestPreprocessedDownload(t *testing.T) {
+	big := noiseImage(t, 500, 500)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(big)
+	}))
+	defer server.Close()
+
+	client := NewClient("user", "password", nil)
+	if _, err := client.CaptchaFromURL(server.URL); err != ErrContentTooBig {
+		t.Fatalf("got %v, want ErrContentTooBig without preprocessors", err)
+	}
+
+	uploads := 0
+	client, transport := newMockClient(&ClientOptions{Privacy: true, Preprocessors: []Preprocessor{FitSize(0, 0)}}, func(req *http.Request, body []byte) *http.Response {
+		if req.URL.Host != "api.dbcapi.me" {
+			return mockResponse(200, string(big), "Content-Type", "image/png")
+		}
+		if uploads++; uploads == 1 {
+			return mockResponse(503, "")
+		}
+		return mockResponse(200, `{"captcha": 42, "is_correct": true, "text": "", "status": 0}`)
+	})
+	if _, err := client.CaptchaFromURL(server.URL); err == nil {
+		t.Fatal("the first upload should fail")
+	}
+	content := append([]byte(nil), big...)
+	if _, err := client.CaptchaWithOptions(context.Background(), content, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(content, big) {
+		t.Error("the caller's image was changed")
+	}
+	if req, body := transport.last(t); len(uploadedFile(t, req, body)) > MaxContentSize {
+		t.Error("the image was not fitted")
+	}
+}
+
+//orientedJPEG returns a 32x16 JPEG, black on its left half and white on its right half, with an EXIF orientation
+func orientedJPEG(t *testing.T, orientation uint16, order binary.ByteOrder) []byte {
+	img := image.NewGray(image.Rect(0, 0, 32, 16))
+	for y := 0; y < 16; y++ {
+		for x := 16; x < 32; x++ {
+			img.SetGray(x, y, color.Gray{Y: 255})
+		}
+	}
+	buf := &bytes.Buffer{}
+	if err := jpeg.Encode(buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+
+	tiff := make([]byte, 26)
+	copy(tiff, "MM")
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1)
+	order.PutUint16(tiff[10:], 0x0112)
+	order.PutUint16(tiff[12:], 3)
+	order.PutUint32(tiff[14:], 1)
+	order.PutUint16(tiff[18:], orientation)
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(segment)+2))
+	content := buf.Bytes()
+	return append(append(append([]byte{}, content[:2]...), append(app1, segment...)...), content[2:]...)
+}
+
+func TestNormalizeOrientation(t *testing.T) {
+	plain := benchmarkImage(t)
+	if rotated, err := NormalizeOrientation()(plain); err != nil || !bytes.Equal(rotated, plain) {
+		t.Fatalf("an image without EXIF was changed: %v", err)
+	}
+	upright := orientedJPEG(t, 1, binary.BigEndian)
+	if rotated, err := NormalizeOrientation()(upright); err != nil || !bytes.Equal(rotated, upright) {
+		t.Fatalf("an upright image was changed: %v", err)
+	}
+
+	brightness := func(img image.Image, x, y int) uint8 {
+		return color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y
+	}
+	for _, c := range []struct {
+		orientation uint16
+		order       binary.ByteOrder
+		width       int
+		dark, light image.Point
+	}{
+		{3, binary.BigEndian, 32, image.Pt(28, 8), image.Pt(4, 8)},
+		{6, binary.LittleEndian, 16, image.Pt(8, 4), image.Pt(8, 28)},
+		{8, binary.BigEndian, 16, image.Pt(8, 28), image.Pt(8, 4)},
+	} {
+		content := orientedJPEG(t, c.orientation, c.order)
+		if got := ExifOrientation(content); got != int(c.orientation) {
+			t.Fatalf("read orientation %d, want %d", got, c.orientation)
+		}
+		rotated, err := NormalizeOrientation()(content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := jpeg.Decode(bytes.NewReader(rotated))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if img.Bounds().Dx() != c.width {
+			t.Errorf("orientation %d: unexpected size %v", c.orientation, img.Bounds())
+		}
+		if brightness(img, c.dark.X, c.dark.Y) > 64 || brightness(img, c.light.X, c.light.Y) < 192 {
+			t.Errorf("orientation %d: the image was not turned upright", c.orientation)
+		}
+		if ExifOrientation(rotated) != 0 {
+			t.Errorf("orientation %d: the orientation was kept", c.orientation)
+		}
+	}
+	//a start of frame declaring a 60000x60000 image
+	huge := orientedJPEG(t, 6, binary.BigEndian)
+	sof := bytes.Index(huge, []byte{0xFF, 0xC0})
+	binary.BigEndian.PutUint16(huge[sof+5:], 60000)
+	binary.BigEndian.PutUint16(huge[sof+7:], 60000)
+	if _, err := NormalizeOrientation()(huge); err != ErrImageDimensions {
+		t.Fatalf("got %v, want ErrImageDimensions", err)
+	}
+}
+
+//animatedGIF returns a 30x10 GIF of 3 frames, blackening a third of the image each, the second frame disposed to the background
+func animatedGIF(t *testing.T) []byte {
+	palette := color.Palette{color.Transparent, color.Black, color.White}
+	animation := &gif.GIF{Config: image.Config{Width: 30, Height: 10, ColorModel: palette}}
+	first := image.NewPaletted(image.Rect(0, 0, 30, 10), palette)
+	for i := range first.Pix {
+		if i%30 < 10 {
+			first.Pix[i] = 1
+		} else {
+			first.Pix[i] = 2
+		}
+	}
+	for _, frame := range []*image.Paletted{first, image.NewPaletted(image.Rect(10, 0, 20, 10), palette), image.NewPaletted(image.Rect(20, 0, 30, 10), palette)} {
+		if frame != first {
+			for i := range frame.Pix {
+				frame.Pix[i] = 1
+			}
+		}
+		animation.Image = append(animation.Image, frame)
+		animation.Delay = append(animation.Delay, 10)
+	}
+	animation.Disposal = []byte{gif.DisposalNone, gif.DisposalBackground, gif.DisposalNone}
+	buf := &bytes.Buffer{}
+	if err := gif.EncodeAll(buf, animation); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+//darkColumns returns which thirds of a frame are black at mid height, e.g. "101"
+func darkColumns(t *testing.T, content []byte) string {
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	columns := ""
+	for _, x := range []int{5, 15, 25} {
+		r, g, b, a := img.At(x, 5).RGBA()
+		if a > 0x8000 && r+g+b < 0x8000 {
+			columns += "1"
+		} else {
+			columns += "0"
+		}
+	}
+	return columns
+}
+
+func TestGIFFrames(t *testing.T) {
+	content := animatedGIF(t)
+	frames, err := ExtractFrames(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 3 {
+		t.Fatalf("got %d frames", len(frames))
+	}
+
+	for selection, want := range map[FrameSelection]string{FrameFirst: "100", FrameMiddle: "110", FrameLast: "101", FrameComposite: "111"} {
+		frame, err := GIFFrame(selection)(content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if DetectFormat(frame) != FormatPNG {
+			t.Fatalf("frame %d is a %s", selection, DetectFormat(frame))
+		}
+		if got := darkColumns(t, frame); got != want {
+			t.Errorf("frame %d: got %s, want %s", selection, got, want)
+		}
+	}
+	if plain := benchmarkImage(t); !bytes.Equal(mustPreprocess(t, GIFFrame(FrameFirst), plain), plain) {
+		t.Errorf("an image that is not a GIF was changed")
+	}
+
+	client := newSandboxClient(SandboxConfig{Solve: func(frame []byte) (string, error) {
+		if darkColumns(t, frame)[2] == '1' {
+			return "dark", nil
+		}
+		return "light", nil
+	}})
+	vote, err := client.SolveFrames(context.Background(), content, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vote.Answer != "light" || vote.Votes != 2 || len(vote.Frames) != 3 || vote.Captcha != vote.Frames[0] {
+		t.Fatalf("unexpected vote %+v", vote)
+	}
+}
+
+func mustPreprocess(t *testing.T, process Preprocessor, content []byte) []byte {
+	processed, err := process(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return processed
+}
+
+//repeatedGIF returns a GIF of count identical frames
+func repeatedGIF(t *testing.T, count int) []byte {
+	palette := color.Palette{color.Black, color.White}
+	animation := &gif.GIF{Config: image.Config{Width: 30, Height: 10, ColorModel: palette}}
+	for i := 0; i < count; i++ {
+		animation.Image = append(animation.Image, image.NewPaletted(image.Rect(0, 0, 30, 10), palette))
+		animation.Delay = append(animation.Delay, 10)
+	}
+	buf := &bytes.Buffer{}
+	if err := gif.EncodeAll(buf, animation); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGIFFrameBounds(t *testing.T) {
+	if count, err := countGIFFrames(animatedGIF(t)); err != nil || count != 3 {
+		t.Fatalf("got %d, %v frames", count, err)
+	}
+	if _, err := ExtractFrames(repeatedGIF(t, maxGIFFrames+1)); err != ErrTooManyFrames {
+		t.Fatalf("got %v, want ErrTooManyFrames", err)
+	}
+
+	huge := animatedGIF(t)
+	binary.LittleEndian.PutUint16(huge[6:8], 60000)
+	binary.LittleEndian.PutUint16(huge[8:10], 60000)
+	if _, err := ExtractFrames(huge); err != ErrImageDimensions {
+		t.Fatalf("got %v, want ErrImageDimensions", err)
+	}
+	if _, err := GIFFrame(FrameComposite)(huge); err != ErrImageDimensions {
+		t.Fatalf("got %v, want ErrImageDimensions", err)
+	}
+}
+
+func TestSolveIdenticalFrames(t *testing.T) {
+	client := NewClient("user", "password", &ClientOptions{IdempotentSubmit: true, CaptchaRetries: 5, Sandbox: &SandboxConfig{Answer: "same"}})
+	vote, err := client.SolveFrames(context.Background(), repeatedGIF(t, 3), 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := map[int64]bool{}
+	for _, frame := range vote.Frames {
+		ids[frame.ID] = true
+	}
+	if vote.Votes != 3 || len(ids) != 3 {
+		t.Fatalf("the identical frames were merged into %d captchas for %d votes", len(ids), vote.Votes)
+	}
+}
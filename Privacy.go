@@ -0,0 +1,26 @@
+package godbc
+
+import "bytes"
+
+//wipe zeroes a buffer
+func wipe(buf []byte) {
+	for i := range buf {
+		buf[i] = 0
+	}
+}
+
+//wipingBody is a request body zeroed once the transport is done with it
+type wipingBody struct {
+	*bytes.Reader
+	buf []byte
+}
+
+func newWipingBody(buf []byte) *wipingBody {
+	return &wipingBody{Reader: bytes.NewReader(buf), buf: buf}
+}
+
+//Close zeroes the body
+func (b *wipingBody) Close() error {
+	wipe(b.buf)
+	return nil
+}
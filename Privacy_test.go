@@ -0,0 +1,69 @@
+package godbc
+
+import (
+	"bytes"
+	"context"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+//uploadedFile returns the captchafile part of an upload request
+func uploadedFile(t *testing.T, req *http.Request, body []byte) []byte {
+	t.Helper()
+	_, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	form, err := multipart.NewReader(bytes.NewReader(body), params["boundary"]).ReadForm(int64(len(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := form.File["captchafile"]
+	if len(files) != 1 {
+		t.Fatalf("unexpected captcha files %v", form.File)
+	}
+	file, err := files[0].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	content := &bytes.Buffer{}
+	content.ReadFrom(file)
+	return content.Bytes()
+}
+
+func TestPrivacy(t *testing.T) {
+	uploads := 0
+	audit := &bytes.Buffer{}
+	client, transport := newMockClient(&ClientOptions{Privacy: true, AuditSink: NewJSONAuditSink(audit), AuditRawContent: true}, func(req *http.Request, body []byte) *http.Response {
+		if uploads++; uploads == 1 {
+			return mockResponse(503, "")
+		}
+		return mockResponse(200, `{"captcha": 42, "is_correct": true, "text": "", "status": 0}`)
+	})
+	original := benchmarkImage(t)
+	content := append([]byte(nil), original...)
+
+	if _, err := client.CaptchaWithOptions(context.Background(), content, nil); err == nil {
+		t.Fatal("the first upload should fail")
+	}
+	response, err := client.CaptchaWithOptions(context.Background(), content, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(content, original) {
+		t.Error("the caller's image was changed")
+	}
+	if req, body := transport.last(t); !bytes.Equal(uploadedFile(t, req, body), original) {
+		t.Error("the retry did not upload the image")
+	}
+	if response.resubmit != nil {
+		t.Error("the image is kept to be submitted again")
+	}
+	if strings.Contains(audit.String(), `"content"`) {
+		t.Errorf("the image was audited: %s", audit.String())
+	}
+}
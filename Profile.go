@@ -0,0 +1,150 @@
+package godbc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sync"
+)
+
+//ErrNoProfile - No profile matches the url given to SolveFor
+var ErrNoProfile = errors.New("No profile matches the url")
+
+/*Profile describes how captchas of a target site are solved
+  Type: api type of the captcha, one of TypeImage, TypeRecaptchaV2, TypeRecaptchaV3, TypeHcaptcha, TypeTurnstile or TypeFuncaptcha as an int, see CaptchaType
+  SiteKey: the data-sitekey token, or the FunCaptcha public key, for token captchas
+  Proxy, ProxyType: the proxy to solve token captchas through, may be empty
+  Options: solving hints for image captchas, may be nil
+  MaxConcurrent: how many captchas of the site key, or of the page's domain without one, a Pool solves at the same time through SolveFor. 0 is unlimited
+  Validators: check the answers of image profiles after the client's PostProcessors, e.g. Length(6, 6) and Charset("0123456789"). Rejected answers are reported and submitted again
+  ValidationRetries: how many times a rejected answer is submitted again, ClientOptions.ValidationRetries if 0
+*/
+type Profile struct {
+	Type              int
+	SiteKey           string
+	Proxy             string
+	ProxyType         string
+	Options           *CaptchaOptions
+	MaxConcurrent     int
+	Validators        []PostProcessor
+	ValidationRetries int
+
+	pattern *regexp.Regexp
+}
+
+//ProfileRegistry maps page urls to the profile of their captchas
+type ProfileRegistry struct {
+	mu       sync.RWMutex
+	profiles []*Profile
+}
+
+//NewProfileRegistry returns an empty profile registry
+func NewProfileRegistry() *ProfileRegistry {
+	return &ProfileRegistry{}
+}
+
+//Register adds a profile for the page urls matching the pattern, a regular expression. Profiles are matched in registration order
+func (r *ProfileRegistry) Register(pattern string, profile Profile) error {
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	profile.pattern = compiled
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.profiles = append(r.profiles, &profile)
+	return nil
+}
+
+//Match returns the first profile matching the page url
+func (r *ProfileRegistry) Match(pageurl string) (*Profile, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, profile := range r.profiles {
+		if profile.pattern.MatchString(pageurl) {
+			return profile, true
+		}
+	}
+	return nil, false
+}
+
+/*SolveFor solves the captcha of a page with the profile registered for its url, and waits for the solution
+  extra: the captcha image for image profiles
+*/
+func (c *Client) SolveFor(ctx context.Context, pageurl string, extra ...[]byte) (*CaptchaResponse, error) {
+	if c.opts().Profiles == nil {
+		return nil, ErrNoProfile
+	}
+	profile, ok := c.opts().Profiles.Match(pageurl)
+	if !ok {
+		return nil, ErrNoProfile
+	}
+	return c.solveProfile(ctx, profile, pageurl, extra...)
+}
+
+//concurrencyKey returns what the concurrency of the profile is capped on: its site key, or the domain of the page
+func (p *Profile) concurrencyKey(pageurl string) string {
+	if p.SiteKey != "" {
+		return "sitekey:" + p.SiteKey
+	}
+	if parsed, err := url.Parse(pageurl); err == nil && parsed.Hostname() != "" {
+		return "domain:" + parsed.Hostname()
+	}
+	return "url:" + pageurl
+}
+
+//tokenParams returns the JSON parameters of the profile's token captcha, its site key sent in keyField
+func (p *Profile) tokenParams(keyField, pageurl string) map[string]string {
+	params := map[string]string{keyField: p.SiteKey, "pageurl": pageurl}
+	if p.Proxy != "" {
+		params["proxy"] = p.Proxy
+		params["proxytype"] = p.ProxyType
+	}
+	return params
+}
+
+//solveProfile solves the captcha of a page with the given profile, and waits for the solution
+func (c *Client) solveProfile(ctx context.Context, profile *Profile, pageurl string, extra ...[]byte) (*CaptchaResponse, error) {
+	var ressource *CaptchaResponse
+	var err error
+	switch profile.CaptchaType() {
+	case TypeImage:
+		if len(extra) == 0 {
+			return nil, fmt.Errorf("Profile for %s needs the captcha image", pageurl)
+		}
+		ressource, err = c.CaptchaWithOptions(ctx, extra[0], profile.Options)
+		if err == nil && len(profile.Validators) > 0 {
+			ressource.validation = &answerValidation{validators: profile.Validators, maxRetries: profile.ValidationRetries}
+		}
+	case TypeRecaptchaV2:
+		ressource, err = c.RecaptchaWithPayload(ctx, RecaptchaRequestPayload{
+			PageURL:   pageurl,
+			GoogleKey: profile.SiteKey,
+			Proxy:     profile.Proxy,
+			ProxyType: profile.ProxyType,
+		})
+	case TypeHcaptcha:
+		ressource, err = c.Hcaptcha(ctx, HcaptchaRequestPayload{
+			PageURL:   pageurl,
+			SiteKey:   profile.SiteKey,
+			Proxy:     profile.Proxy,
+			ProxyType: profile.ProxyType,
+		})
+	case TypeRecaptchaV3:
+		return c.SolveToken(ctx, profile.CaptchaType(), profile.tokenParams("googlekey", pageurl))
+	case TypeTurnstile:
+		return c.SolveToken(ctx, profile.CaptchaType(), profile.tokenParams("sitekey", pageurl))
+	case TypeFuncaptcha:
+		return c.SolveToken(ctx, profile.CaptchaType(), profile.tokenParams("publickey", pageurl))
+	default:
+		return nil, fmt.Errorf("Profile for %s has an unsupported captcha type %d", pageurl, profile.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return c.WaitCaptchaWithContext(ctx, ressource)
+}
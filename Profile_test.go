@@ -0,0 +1,38 @@
+package godbc
+
+import (
+	"context"
+	"testing"
+)
+
+func TestProfileValidators(t *testing.T) {
+	answers := []string{"12a456", "12345", "123456"}
+	reported := 0
+	profiles := NewProfileRegistry()
+	profiles.Register(`^https://example\.com/`, Profile{Type: int(TypeImage), Validators: []PostProcessor{Length(6, 6), Charset("0123456789")}, ValidationRetries: 2})
+	client := NewClient("user", "password", &ClientOptions{Profiles: profiles, CaptchaRetries: 5, PostProcessors: []PostProcessor{TrimSpace()}, Sandbox: &SandboxConfig{Solve: func(content []byte) (string, error) {
+		answer := answers[0]
+		answers = answers[1:]
+		return " " + answer, nil
+	}}, OnEvent: func(e Event) {
+		if e.Type == EventReported {
+			reported++
+		}
+	}})
+
+	solved, err := client.SolveFor(context.Background(), "https://example.com/login", benchmarkImage(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if solved.Text != "123456" || solved.Attempts != 3 || reported != 2 {
+		t.Fatalf("got %q after %d attempts and %d reports", solved.Text, solved.Attempts, reported)
+	}
+
+	answers = []string{"abcdef", "abcdef", "abcdef"}
+	if _, err := client.SolveFor(context.Background(), "https://example.com/login", benchmarkImage(t)); err != ErrAnswerRejected {
+		t.Fatalf("got %v, want ErrAnswerRejected", err)
+	}
+	if len(answers) != 0 {
+		t.Fatalf("the profile's retries were not used, %d answers left", len(answers))
+	}
+}
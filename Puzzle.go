@@ -0,0 +1,99 @@
+package godbc
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+//Point is a coordinate on a captcha image, in pixels from the top left corner
+type Point struct {
+	X int
+	Y int
+}
+
+//RotateResult is the typed result of a rotation captcha
+type RotateResult struct {
+	//Angle - clockwise rotation to apply, in degrees
+	Angle float64
+	//Captcha - the solved captcha, to be used for reporting
+	Captcha *CaptchaResponse
+}
+
+//SliderResult is the typed result of a slider captcha
+type SliderResult struct {
+	//Offset - where the slider piece has to be dropped
+	Offset Point
+	//Captcha - the solved captcha, to be used for reporting
+	Captcha *CaptchaResponse
+}
+
+/*RotateCaptcha will solve a rotation captcha (e.g. Alibaba rotate puzzles) and return the angle to apply
+  content: the image with the rotated element
+*/
+func (c *Client) RotateCaptcha(ctx context.Context, content []byte) (*RotateResult, error) {
+	ressource, err := c.CaptchaWithOptions(ctx, content, &CaptchaOptions{MaxLength: 3})
+	if err != nil {
+		return nil, err
+	}
+	ressource.rawAnswer = true
+	resolved, err := c.WaitCaptchaWithContext(ctx, ressource)
+	if err != nil {
+		return nil, err
+	}
+
+	angle, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(resolved.Text, "°")), 64)
+	if err != nil {
+		return nil, ErrCaptchaInvalid
+	}
+
+	return &RotateResult{Angle: math.Mod(angle, 360), Captcha: resolved}, nil
+}
+
+/*SliderCaptcha will solve a slider captcha (e.g. GeeTest slide puzzles) through the coordinates api, and return the offset to drag the piece to
+  content: the background image with the puzzle gap
+*/
+func (c *Client) SliderCaptcha(ctx context.Context, content []byte) (*SliderResult, error) {
+	v := url.Values{}
+	v.Set("type", TypeCoordinates.String())
+	ressource, err := c.submitImage(ctx, content, v, nil)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := c.WaitCaptchaWithContext(ctx, ressource)
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := ParseCoordinates(resolved.Text)
+	if err != nil {
+		return nil, err
+	}
+	if len(points) == 0 {
+		return nil, ErrCaptchaInvalid
+	}
+
+	return &SliderResult{Offset: points[0], Captcha: resolved}, nil
+}
+
+//ParseCoordinates parses the text of a solved coordinates captcha, formatted as [[x1,y1],[x2,y2],...]
+func ParseCoordinates(text string) ([]Point, error) {
+	raw := [][]float64{}
+	err := json.Unmarshal([]byte(text), &raw)
+	if err != nil {
+		return nil, ErrCaptchaInvalid
+	}
+
+	points := make([]Point, 0, len(raw))
+	for _, coords := range raw {
+		if len(coords) != 2 {
+			return nil, ErrCaptchaInvalid
+		}
+		points = append(points, Point{X: int(math.Round(coords[0])), Y: int(math.Round(coords[1]))})
+	}
+
+	return points, nil
+}
@@ -0,0 +1,39 @@
+package godbc
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRotateCaptcha(t *testing.T) {
+	for answer, want := range map[string]float64{"90": 90, "45.5°": 45.5, "450°": 90} {
+		result, err := newSandboxClient(SandboxConfig{Answer: answer}).RotateCaptcha(context.Background(), benchmarkImage(t))
+		if err != nil || result.Angle != want {
+			t.Errorf("%q: got %+v, %v, want an angle of %g", answer, result, err, want)
+		}
+	}
+	if _, err := newSandboxClient(SandboxConfig{Answer: "left"}).RotateCaptcha(context.Background(), benchmarkImage(t)); err != ErrCaptchaInvalid {
+		t.Fatalf("got %v, want ErrCaptchaInvalid", err)
+	}
+}
+
+func TestSliderCaptcha(t *testing.T) {
+	result, err := newSandboxClient(SandboxConfig{Answer: "[[120.6,40],[3,4]]"}).SliderCaptcha(context.Background(), benchmarkImage(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Offset != (Point{X: 121, Y: 40}) || result.Captcha == nil {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if _, err := newSandboxClient(SandboxConfig{Answer: "[]"}).SliderCaptcha(context.Background(), benchmarkImage(t)); err != ErrCaptchaInvalid {
+		t.Fatalf("got %v without coordinates, want ErrCaptchaInvalid", err)
+	}
+}
+
+func TestParseCoordinates(t *testing.T) {
+	for _, text := range []string{"", "120,40", "[[1,2,3]]", `[["a","b"]]`} {
+		if _, err := ParseCoordinates(text); err != ErrCaptchaInvalid {
+			t.Errorf("%q: got %v, want ErrCaptchaInvalid", text, err)
+		}
+	}
+}
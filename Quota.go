@@ -0,0 +1,182 @@
+package godbc
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//ErrQuotaExceeded is returned when a tenant used up its quota
+var ErrQuotaExceeded = errors.New("Tenant quota exceeded")
+
+type tenantKey struct{}
+
+//WithTenant returns a context whose captchas are accounted to the given tenant by a Pool with Quotas
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+//TenantFrom returns the tenant set with WithTenant, empty if none was set
+func TenantFrom(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+//TenantQuota limits the solves of a tenant, a zero field is unlimited
+type TenantQuota struct {
+	SolvesPerHour int
+	//SpendPerDay - in the account's currency, solves are priced at the rate of the last `user` call, made by the Pool when none was. Captchas are refused while the rate can not be fetched
+	SpendPerDay float64
+}
+
+//TenantStats are the usage statistics of a tenant
+type TenantStats struct {
+	Solves         int64
+	Rejected       int64
+	SolvesLastHour int
+	SpendToday     float64
+}
+
+//Quotas enforces per tenant quotas on captchas sharing one account, the zero value gives every tenant an unlimited quota
+type Quotas struct {
+	//Default - the quota of tenants without their own
+	Default TenantQuota
+
+	mu     sync.Mutex
+	quotas map[string]TenantQuota
+	usage  map[string]*tenantUsage
+}
+
+type tenantUsage struct {
+	stats  TenantStats
+	recent []time.Time
+	day    time.Time
+}
+
+//NewQuotas returns quotas giving every tenant the default quota
+func NewQuotas(defaultQuota TenantQuota) *Quotas {
+	return &Quotas{
+		Default: defaultQuota,
+		quotas:  map[string]TenantQuota{},
+		usage:   map[string]*tenantUsage{},
+	}
+}
+
+//Set sets the quota of a tenant
+func (q *Quotas) Set(tenant string, quota TenantQuota) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.quotas == nil {
+		q.quotas = map[string]TenantQuota{}
+	}
+	q.quotas[tenant] = quota
+}
+
+//Stats returns the usage statistics of a tenant
+func (q *Quotas) Stats(tenant string) TenantStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	usage, ok := q.usage[tenant]
+	if !ok {
+		return TenantStats{}
+	}
+
+	now := time.Now()
+	stats := usage.stats
+	for _, at := range usage.recent {
+		if now.Sub(at) <= time.Hour {
+			stats.SolvesLastHour++
+		}
+	}
+	if !now.Truncate(24 * time.Hour).Equal(usage.day) {
+		stats.SpendToday = 0
+	}
+	return stats
+}
+
+//reserve accounts a solve to a tenant, or returns ErrQuotaExceeded
+func (q *Quotas) reserve(tenant string, price float64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := time.Now()
+	usage := q.current(tenant, now)
+	quota, ok := q.quotas[tenant]
+	if !ok {
+		quota = q.Default
+	}
+	if (quota.SolvesPerHour > 0 && len(usage.recent) >= quota.SolvesPerHour) || (quota.SpendPerDay > 0 && usage.stats.SpendToday+price > quota.SpendPerDay) {
+		usage.stats.Rejected++
+		return ErrQuotaExceeded
+	}
+
+	usage.recent = append(usage.recent, now)
+	usage.stats.Solves++
+	usage.stats.SpendToday += price
+	return nil
+}
+
+//limitsSpend returns whether the tenant's quota has a SpendPerDay
+func (q *Quotas) limitsSpend(tenant string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	quota, ok := q.quotas[tenant]
+	if !ok {
+		quota = q.Default
+	}
+	return quota.SpendPerDay > 0
+}
+
+//release gives back a solve that was not charged
+func (q *Quotas) release(tenant string, price float64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	usage := q.current(tenant, time.Now())
+	if len(usage.recent) > 0 {
+		usage.recent = usage.recent[:len(usage.recent)-1]
+	}
+	usage.stats.Solves--
+	usage.stats.SpendToday = math.Max(0, usage.stats.SpendToday-price)
+}
+
+//current returns the usage of a tenant, with solves older than an hour and spend of previous days dropped
+func (q *Quotas) current(tenant string, now time.Time) *tenantUsage {
+	if q.usage == nil {
+		q.usage = map[string]*tenantUsage{}
+	}
+	usage, ok := q.usage[tenant]
+	if !ok {
+		usage = &tenantUsage{}
+		q.usage[tenant] = usage
+	}
+
+	i := 0
+	for i < len(usage.recent) && now.Sub(usage.recent[i]) > time.Hour {
+		i++
+	}
+	usage.recent = usage.recent[i:]
+	if day := now.Truncate(24 * time.Hour); !day.Equal(usage.day) {
+		usage.day = day
+		usage.stats.SpendToday = 0
+	}
+	return usage
+}
+
+//price returns the cost of a solve at the last rate seen by a `user` call
+func (c *Client) price() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&c.counters.rateBits))
+}
+
+//fetchPrice returns the cost of a solve, making a `user` call when no rate was seen yet
+func (c *Client) fetchPrice(ctx context.Context) (float64, error) {
+	if price := c.price(); price > 0 {
+		return price, nil
+	}
+	user, err := c.UserWithContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return user.Rate, nil
+}
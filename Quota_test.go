@@ -0,0 +1,59 @@
+package godbc
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestPoolSpendQuota(t *testing.T) {
+	client := newSandboxClient(SandboxConfig{Rate: 0.2})
+	pool := NewPool(client, PoolOptions{Quotas: NewQuotas(TenantQuota{SpendPerDay: 0.5})})
+	defer pool.Close()
+	ctx := WithTenant(context.Background(), "tenant")
+
+	for i := 0; i < 2; i++ {
+		if _, err := pool.Solve(ctx, benchmarkImage(t), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := pool.Solve(ctx, benchmarkImage(t), nil); err != ErrQuotaExceeded {
+		t.Fatalf("got %v, want ErrQuotaExceeded once the day's spend is used", err)
+	}
+	if stats := pool.options.Quotas.Stats("tenant"); stats.SpendToday != 0.4 || stats.Rejected != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestPoolSpendQuotaWithoutRate(t *testing.T) {
+	client, transport := newMockClient(nil, func(req *http.Request, body []byte) *http.Response {
+		return mockResponse(503, "")
+	})
+	pool := NewPool(client, PoolOptions{Quotas: NewQuotas(TenantQuota{SpendPerDay: 0.5})})
+	defer pool.Close()
+
+	if _, err := pool.Solve(context.Background(), benchmarkImage(t), nil); err == nil {
+		t.Fatal("a captcha was accepted without the rate to check its spend")
+	}
+	if req, _ := transport.last(t); req.Method != `GET` {
+		t.Fatalf("the captcha was uploaded with %s %s", req.Method, req.URL)
+	}
+}
+
+func TestQuotasZeroValue(t *testing.T) {
+	quotas := &Quotas{Default: TenantQuota{SolvesPerHour: 1}}
+	if stats := quotas.Stats("unknown"); stats != (TenantStats{}) || len(quotas.usage) != 0 {
+		t.Fatalf("Stats recorded a usage for an unknown tenant: %+v", quotas.usage)
+	}
+	if err := quotas.reserve("a", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := quotas.reserve("a", 0); err != ErrQuotaExceeded {
+		t.Fatalf("got %v, want ErrQuotaExceeded", err)
+	}
+	quotas = &Quotas{}
+	quotas.Set("b", TenantQuota{SolvesPerHour: 2})
+	if stats := quotas.Stats("b"); stats.Solves != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
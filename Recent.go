@@ -0,0 +1,45 @@
+package godbc
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+//ErrEndpointUnavailable - The service does not expose this endpoint
+var ErrEndpointUnavailable = errors.New("Endpoint is not exposed by the service")
+
+type recentResponse struct {
+	Status   StatusCode      `json:"status"`
+	Error    string          `json:"error"`
+	Captchas []recentCaptcha `json:"captchas"`
+}
+
+type recentCaptcha struct {
+	CaptchaResponse
+	//Uploaded - the upload time, in unix seconds
+	Uploaded int64 `json:"uploaded"`
+}
+
+/*RecentCaptchas lists the account's recent uploads, most recent first, to recover the captchas of a crashed process or reconcile them with a job store
+  Their SubmittedAt is the upload time reported by the service. Captchas still being solved have an empty Text.
+  ErrEndpointUnavailable is returned when the service does not expose the listing
+*/
+func (c *Client) RecentCaptchas(ctx context.Context) ([]*CaptchaResponse, error) {
+	//the credentials go in the body, a query would leave them in the access logs of proxies and of the service
+	response, err := Call[recentResponse](ctx, c, `POST`, RouteRecent, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	captchas := make([]*CaptchaResponse, 0, len(response.Captchas))
+	for i := range response.Captchas {
+		captcha := response.Captchas[i].CaptchaResponse
+		if uploaded := response.Captchas[i].Uploaded; uploaded > 0 {
+			captcha.SubmittedAt = time.Unix(uploaded, 0)
+			c.submitted.record(captcha.ID, captcha.SubmittedAt)
+		}
+		captchas = append(captchas, c.describe(&captcha, ""))
+	}
+	return captchas, nil
+}
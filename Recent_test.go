@@ -0,0 +1,37 @@
+package godbc
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestRecentCaptchas(t *testing.T) {
+	client := newSandboxClient(SandboxConfig{Answer: "abcdef"})
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := client.CaptchaWithOptions(ctx, benchmarkImage(t), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	recent, err := client.RecentCaptchas(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recent) != 2 || recent[0].ID != 2 || recent[1].ID != 1 || recent[0].SubmittedAt.IsZero() {
+		t.Fatalf("unexpected listing %+v", recent)
+	}
+
+	client, transport := newMockClient(nil, func(req *http.Request, body []byte) *http.Response {
+		return mockResponse(404, `{"status": 255, "error": "not-found"}`)
+	})
+	if _, err := client.RecentCaptchas(ctx); err != ErrEndpointUnavailable {
+		t.Fatalf("got %v, want ErrEndpointUnavailable", err)
+	}
+	req, body := transport.last(t)
+	form, _ := url.ParseQuery(string(body))
+	if req.Method != `POST` || req.URL.RawQuery != "" || form.Get("password") != "password" {
+		t.Fatalf("the credentials were sent in %s %s", req.Method, req.URL)
+	}
+}
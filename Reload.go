@@ -0,0 +1,128 @@
+package godbc
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"os"
+	"time"
+)
+
+/*UpdateOptions replaces the client options at runtime, e.g. in long-running solver daemons
+  The new options apply atomically to the calls started afterwards, in-flight calls finish with the options they started with.
+  Options not specified take default values, as in NewClient. The transport is not rebuilt: HTTPTimeout can only be lowered under its initial value, TLSHandshakeTimeout is kept
+*/
+func (c *Client) UpdateOptions(options *ClientOptions) {
+	c.options.Store(setDefaultOptions(options))
+}
+
+//Options returns a copy of the current client options, e.g. to be modified and given to UpdateOptions
+func (c *Client) Options() ClientOptions {
+	return *c.opts()
+}
+
+//OptionsDecoder decodes a serialized configuration into new options, based on the current ones
+type OptionsDecoder func(data []byte, current ClientOptions) (*ClientOptions, error)
+
+//jsonOptions is the JSON form of the serializable client options
+type jsonOptions struct {
+	Endpoint            *string       `json:"endpoint"`
+	HTTPTimeout         *string       `json:"http_timeout"`
+	TLSHandshakeTimeout *string       `json:"tls_handshake_timeout"`
+	CaptchaRetries      *int          `json:"captcha_retries"`
+	AdaptivePolling     *bool         `json:"adaptive_polling"`
+	IdempotentSubmit    *bool         `json:"idempotent_submit"`
+	AutoResolve         *bool         `json:"auto_resolve"`
+	ReportPolicy        *ReportPolicy `json:"report_policy"`
+}
+
+/*JSONOptionsDecoder decodes options from JSON, fields left out keep their current value:
+  {"endpoint": "http://api.dbcapi.me/api/", "http_timeout": "30s", "captcha_retries": 30, "adaptive_polling": true, "report_policy": {"Mode": 3, "MaxPerHour": 10}}
+*/
+func JSONOptionsDecoder(data []byte, current ClientOptions) (*ClientOptions, error) {
+	decoded := jsonOptions{}
+	err := json.Unmarshal(data, &decoded)
+	if err != nil {
+		return nil, err
+	}
+
+	options := current
+	if decoded.Endpoint != nil {
+		options.Endpoint, err = url.Parse(*decoded.Endpoint)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if decoded.HTTPTimeout != nil {
+		d, err := time.ParseDuration(*decoded.HTTPTimeout)
+		if err != nil {
+			return nil, err
+		}
+		options.HTTPTimeout = &d
+	}
+	if decoded.TLSHandshakeTimeout != nil {
+		d, err := time.ParseDuration(*decoded.TLSHandshakeTimeout)
+		if err != nil {
+			return nil, err
+		}
+		options.TLSHandshakeTimeout = &d
+	}
+	if decoded.CaptchaRetries != nil {
+		options.CaptchaRetries = *decoded.CaptchaRetries
+	}
+	if decoded.AdaptivePolling != nil {
+		options.AdaptivePolling = *decoded.AdaptivePolling
+	}
+	if decoded.IdempotentSubmit != nil {
+		options.IdempotentSubmit = *decoded.IdempotentSubmit
+	}
+	if decoded.AutoResolve != nil {
+		options.AutoResolve = *decoded.AutoResolve
+	}
+	if decoded.ReportPolicy != nil {
+		options.ReportPolicy = *decoded.ReportPolicy
+	}
+
+	return &options, nil
+}
+
+/*WatchOptionsFile reloads the client options from a file whenever it changes, until the context is done
+  decode: the file format, defaults to JSONOptionsDecoder
+  onError: called when the file cannot be read or decoded, the current options are then kept. May be nil
+*/
+func (c *Client) WatchOptionsFile(ctx context.Context, path string, interval time.Duration, decode OptionsDecoder, onError func(error)) {
+	if decode == nil {
+		decode = JSONOptionsDecoder
+	}
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	var lastModified time.Time
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		info, err := os.Stat(path)
+		if err == nil && !info.ModTime().Equal(lastModified) {
+			lastModified = info.ModTime()
+			var data []byte
+			data, err = os.ReadFile(path)
+			if err == nil {
+				var options *ClientOptions
+				options, err = decode(data, c.Options())
+				if err == nil {
+					c.UpdateOptions(options)
+				}
+			}
+		}
+		if err != nil && onError != nil {
+			onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
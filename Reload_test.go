@@ -0,0 +1,64 @@
+package godbc
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJSONOptionsDecoder(t *testing.T) {
+	current := ClientOptions{CaptchaRetries: 7, AdaptivePolling: true}
+	options, err := JSONOptionsDecoder([]byte(`{"endpoint": "http://localhost:8080/api/", "http_timeout": "5s", "idempotent_submit": true}`), current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if options.Endpoint.Host != "localhost:8080" || *options.HTTPTimeout != 5*time.Second || !options.IdempotentSubmit {
+		t.Fatalf("unexpected options %+v", options)
+	}
+	if options.CaptchaRetries != 7 || !options.AdaptivePolling {
+		t.Fatalf("the options left out were not kept: %+v", options)
+	}
+	if _, err := JSONOptionsDecoder([]byte(`{"http_timeout": "soon"}`), current); err == nil {
+		t.Fatal("an invalid duration was decoded")
+	}
+}
+
+func TestWatchOptionsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "options.json")
+	if err := os.WriteFile(path, []byte(`{"captcha_retries": 12}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	client := NewClient("user", "password", &ClientOptions{CaptchaRetries: 3})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errs := make(chan error, 100)
+	go client.WatchOptionsFile(ctx, path, 5*time.Millisecond, nil, func(err error) { errs <- err })
+
+	waitFor := func(condition func() bool) {
+		deadline := time.Now().Add(2 * time.Second)
+		for !condition() {
+			if time.Now().After(deadline) {
+				t.Fatalf("the options file was not reloaded, the options are %+v", client.Options())
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	waitFor(func() bool { return client.Options().CaptchaRetries == 12 })
+
+	//the modification time is moved forward, the file system may not tell apart writes this close
+	if err := os.WriteFile(path, []byte(`{"captcha_retries": `), 0600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(path, later, later)
+	select {
+	case <-errs:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the invalid options file was not reported")
+	}
+	if client.Options().CaptchaRetries != 12 {
+		t.Fatalf("the options were not kept when the file is invalid: %+v", client.Options())
+	}
+}
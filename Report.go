@@ -0,0 +1,192 @@
+package godbc
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+//Errors of reports refused locally, the service was not called
+var (
+	//ErrReportRefused - The report was refused by the client's report policy
+	ErrReportRefused = errors.New("Report was refused by the report policy")
+	//ErrReportWindowExpired - The captcha was submitted more than ReportWindow ago, the service would reject the report
+	ErrReportWindowExpired = errors.New("Report window has expired - captchas can only be reported within 1h of their submission")
+)
+
+//ReportMode tells when ReportCaptcha is allowed to report captchas
+type ReportMode int
+
+//Report modes
+const (
+	//ReportAlways - every report is sent
+	ReportAlways ReportMode = iota
+	//ReportNever - no report is sent
+	ReportNever
+	//ReportOnValidatorFailure - only reports issued by the client's answer validation are sent
+	ReportOnValidatorFailure
+	//ReportMaxPerHour - at most MaxPerHour reports are sent in any rolling hour
+	ReportMaxPerHour
+)
+
+/*ReportPolicy limits captcha reporting, as over-reporting gets accounts banned
+  Mode: when reports are allowed
+  MaxPerHour: the limit for ReportMaxPerHour
+  MaxRatio: if above 0, reports are refused once reported/solved for the session would go above this ratio. It applies once the session solved a captcha
+*/
+type ReportPolicy struct {
+	Mode       ReportMode
+	MaxPerHour int
+	MaxRatio   float64
+}
+
+//reportBudget tracks the reports and solves of the session
+type reportBudget struct {
+	mu     sync.Mutex
+	solved int
+	//reports - how many captchas the session reported
+	reports int
+	//reported - when the reports of the last hour were sent, kept for ReportMaxPerHour
+	reported []time.Time
+}
+
+func (b *reportBudget) solve() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.solved++
+}
+
+//allow reserves a report if the policy allows it, now is the time of the client's Clock
+func (b *reportBudget) allow(policy ReportPolicy, fromValidator bool, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch policy.Mode {
+	case ReportNever:
+		return false
+	case ReportOnValidatorFailure:
+		if !fromValidator {
+			return false
+		}
+	case ReportMaxPerHour:
+		hourAgo := now.Add(-time.Hour)
+		expired := 0
+		for expired < len(b.reported) && !b.reported[expired].After(hourAgo) {
+			expired++
+		}
+		b.reported = b.reported[expired:]
+		if len(b.reported) >= policy.MaxPerHour {
+			return false
+		}
+	}
+
+	if policy.MaxRatio > 0 && b.solved > 0 && float64(b.reports+1)/float64(b.solved) > policy.MaxRatio {
+		return false
+	}
+
+	b.reports++
+	if policy.Mode == ReportMaxPerHour {
+		b.reported = append(b.reported, now)
+	}
+	return true
+}
+
+//release gives back a report reserved by allow, when it could not be sent
+func (b *reportBudget) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.reports > 0 {
+		b.reports--
+	}
+	if len(b.reported) > 0 {
+		b.reported = b.reported[:len(b.reported)-1]
+	}
+}
+
+func (b *reportBudget) ratio() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.solved == 0 {
+		return 0
+	}
+	return float64(b.reports) / float64(b.solved)
+}
+
+//submissionLog keeps the submission time of the captchas still in the report window, and which of the captchas submitted by the client are not solved yet
+type submissionLog struct {
+	mu      sync.Mutex
+	at      map[int64]time.Time
+	pending map[int64]bool
+	//own - the captchas submitted by the client, the others were listed by RecentCaptchas
+	own map[int64]bool
+}
+
+//record keeps the submission time of a captcha, forgetting the captchas out of the report window
+func (l *submissionLog) record(id int64, at time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.at == nil {
+		l.at = map[int64]time.Time{}
+	}
+	for other, submittedAt := range l.at {
+		if at.Sub(submittedAt) >= ReportWindow {
+			delete(l.at, other)
+			delete(l.pending, other)
+			delete(l.own, other)
+		}
+	}
+	l.at[id] = at
+}
+
+//submit records a captcha submitted by the client, pending until settle is called
+func (l *submissionLog) submit(id int64, at time.Time) {
+	l.record(id, at)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.pending == nil {
+		l.pending = map[int64]bool{}
+		l.own = map[int64]bool{}
+	}
+	l.pending[id] = true
+	l.own[id] = true
+}
+
+//submittedByClient returns whether the captcha was submitted by the client in the report window
+func (l *submissionLog) submittedByClient(id int64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.own[id]
+}
+
+//settle marks a captcha as solved or failed
+func (l *submissionLog) settle(id int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.pending, id)
+}
+
+func (l *submissionLog) get(id int64) (time.Time, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	at, ok := l.at[id]
+	return at, ok
+}
+
+/*inReportWindow returns false once a captcha was submitted more than ReportWindow ago, by the client's Clock
+  The submission time tracked by the client prevails over the response's, captchas with an unknown submission time are considered reportable
+*/
+func (c *Client) inReportWindow(ressource *CaptchaResponse) bool {
+	submittedAt, ok := c.submitted.get(ressource.ID)
+	if !ok {
+		submittedAt = ressource.SubmittedAt
+	}
+	if submittedAt.IsZero() {
+		return true
+	}
+	return c.now().Sub(submittedAt) < ReportWindow
+}
+
+//ReportRatio returns the ratio of reported captchas over solved captchas for the client's session
+func (c *Client) ReportRatio() float64 {
+	return c.reports.ratio()
+}
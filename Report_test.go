@@ -0,0 +1,123 @@
+package godbc
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+//fakeClock is a Clock moved forward by tests
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestReportWindow(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	client, transport := newMockClient(&ClientOptions{Clock: clock}, func(req *http.Request, body []byte) *http.Response {
+		return mockResponse(200, `{"captcha": 42, "is_correct": true, "text": "abc", "status": 0}`)
+	})
+	ctx := context.Background()
+	ressource, err := client.CaptchaWithOptions(ctx, benchmarkImage(t), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Add(59 * time.Minute)
+	if _, err := client.ReportCaptchaWithContext(ctx, &CaptchaResponse{ID: ressource.ID}); err != nil {
+		t.Fatal(err)
+	}
+	clock.Add(2 * time.Minute)
+	sent := len(transport.requests)
+	if _, err := client.ReportCaptchaWithContext(ctx, &CaptchaResponse{ID: ressource.ID}); err != ErrReportWindowExpired {
+		t.Fatalf("got %v, want ErrReportWindowExpired", err)
+	}
+	if len(transport.requests) != sent {
+		t.Fatal("an expired report reached the service")
+	}
+}
+
+func TestCaptchaAgeClock(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	client, _ := newMockClient(&ClientOptions{Clock: clock}, func(req *http.Request, body []byte) *http.Response {
+		return mockResponse(200, `{"captcha": 42, "is_correct": true, "text": "abc", "status": 0}`)
+	})
+	ressource, err := client.CaptchaWithOptions(context.Background(), benchmarkImage(t), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Add(30 * time.Minute)
+	if age := ressource.Age(); age != 30*time.Minute {
+		t.Fatalf("got age %v, want 30m by the client's Clock", age)
+	}
+	if !ressource.CanStillReport() {
+		t.Fatal("captcha should still be reportable")
+	}
+	clock.Add(31 * time.Minute)
+	if ressource.CanStillReport() {
+		t.Fatal("captcha should be out of the report window by the client's Clock")
+	}
+}
+
+func TestReportMaxPerHourClock(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	client, _ := newMockClient(&ClientOptions{Clock: clock, ReportPolicy: ReportPolicy{Mode: ReportMaxPerHour, MaxPerHour: 1}}, func(req *http.Request, body []byte) *http.Response {
+		return mockResponse(200, `{"captcha": 42, "is_correct": false, "text": "", "status": 0}`)
+	})
+	ctx := context.Background()
+	if _, err := client.ReportCaptchaWithContext(ctx, &CaptchaResponse{ID: 42}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.ReportCaptchaWithContext(ctx, &CaptchaResponse{ID: 42}); err != ErrReportRefused {
+		t.Fatalf("got %v, want ErrReportRefused within the hour", err)
+	}
+	clock.Add(61 * time.Minute)
+	if _, err := client.ReportCaptchaWithContext(ctx, &CaptchaResponse{ID: 42}); err != nil {
+		t.Fatalf("got %v, want the report allowed an hour later by the client's Clock", err)
+	}
+}
+
+func TestReportBudget(t *testing.T) {
+	budget := &reportBudget{}
+	now := time.Now()
+	hourly := ReportPolicy{Mode: ReportMaxPerHour, MaxPerHour: 2}
+	for i := 0; i < 100; i++ {
+		if !budget.allow(hourly, false, now.Add(time.Duration(i)*time.Hour)) {
+			t.Fatalf("report %d was refused", i)
+		}
+	}
+	if len(budget.reported) != 1 {
+		t.Fatalf("kept %d report times, want those of the last hour", len(budget.reported))
+	}
+
+	ratio := ReportPolicy{MaxRatio: 0.5}
+	budget = &reportBudget{}
+	if !budget.allow(ratio, false, now) {
+		t.Fatal("a report was refused before any captcha was solved")
+	}
+	budget.solve()
+	budget.solve()
+	budget.solve()
+	budget.solve()
+	if !budget.allow(ratio, false, now) {
+		t.Fatal("a report under the ratio was refused")
+	}
+	if budget.allow(ratio, false, now) || budget.ratio() != 0.5 {
+		t.Fatalf("a report above the ratio was allowed, ratio %v", budget.ratio())
+	}
+}
@@ -0,0 +1,232 @@
+package godbc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+/*BuildCaptchaRequest returns the request the client would send to submit an image captcha, so it can go through custom pipelines (queues, proxies, batching)
+  The response body can then be decoded with ParseCaptchaResponse
+  options: solving hints, may be nil
+*/
+func (c *Client) BuildCaptchaRequest(ctx context.Context, content []byte, options *CaptchaOptions) (*http.Request, error) {
+	return c.buildImageRequest(ctx, content, nil, options)
+}
+
+func (c *Client) buildImageRequest(ctx context.Context, content []byte, fields url.Values, options *CaptchaOptions) (*http.Request, error) {
+	if _, err := ValidateImage(content); err != nil {
+		return nil, err
+	}
+
+	format := DetectFormat(content)
+	return c.buildUploadRequest(ctx, content, "captcha"+format.Extension(), format.ContentType(), fields, options)
+}
+
+//buildUploadRequest returns the multipart request uploading content as the captchafile
+func (c *Client) buildUploadRequest(ctx context.Context, content []byte, filename, contentType string, fields url.Values, options *CaptchaOptions) (*http.Request, error) {
+	ctx, urlReq, err := c.route(ctx, RouteUpload, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	//the body is streamed from content as the transport reads it, sizing it first so it is not sent chunked
+	boundary := multipart.NewWriter(ioutil.Discard).Boundary()
+	solveTime, withSolveTime := c.maxSolveTime(ctx)
+	write := func(w io.Writer) error {
+		writer := multipart.NewWriter(w)
+		err := writer.SetBoundary(boundary)
+		if err != nil {
+			return err
+		}
+		err = c.writeImageFields(writer, fields, options)
+		if err != nil {
+			return err
+		}
+		if withSolveTime {
+			err = writer.WriteField("max_solve_time", solveTime)
+			if err != nil {
+				return err
+			}
+		}
+		part := textproto.MIMEHeader{}
+		part.Set("Content-Disposition", `form-data; name="captchafile"; filename="`+filename+`"`)
+		part.Set("Content-Type", contentType)
+		pw, err := writer.CreatePart(part)
+		if err != nil {
+			return err
+		}
+		_, err = pw.Write(content)
+		if err != nil {
+			return err
+		}
+		return writer.Close()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, `POST`, urlReq.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "multipart/form-data; boundary="+boundary)
+	if c.compresses(len(content) + multipartOverhead) {
+		req.Header.Set("Content-Encoding", "gzip")
+		req.ContentLength = -1
+		write = gzipped(write)
+	} else {
+		counter := &countingWriter{}
+		err = write(counter)
+		if err != nil {
+			return nil, err
+		}
+		req.ContentLength = counter.n
+	}
+	req.Body = newStreamBody(write)
+	req.GetBody = func() (io.ReadCloser, error) {
+		return newStreamBody(write), nil
+	}
+
+	return req, nil
+}
+
+//writeImageFields writes the credentials, extra fields and solving hints of an image submission
+func (c *Client) writeImageFields(writer *multipart.Writer, fields url.Values, options *CaptchaOptions) error {
+	err := writer.WriteField("username", c.username)
+	if err != nil {
+		return err
+	}
+	err = writer.WriteField("password", c.password)
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		err = writer.WriteField(key, fields.Get(key))
+		if err != nil {
+			return err
+		}
+	}
+	if options != nil {
+		return options.writeFields(writer)
+	}
+	return nil
+}
+
+/*BuildTokenRequest returns the request the client would send to submit a token captcha, so it can go through custom pipelines (queues, proxies, batching)
+  The response body can then be decoded with ParseCaptchaResponse
+  captchaType: the api type of the captcha, e.g. int(TypeRecaptchaV2)
+  params: the token parameters, marshalled to JSON. They are checked against the Required fields of a registered type, see RegisterType
+*/
+func (c *Client) BuildTokenRequest(ctx context.Context, captchaType int, params interface{}) (*http.Request, error) {
+	spec := LookupType(CaptchaType(captchaType))
+	if err := spec.validate(params); err != nil {
+		return nil, err
+	}
+	payloadBytes, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	v := url.Values{}
+	v.Set("username", c.username)
+	v.Set("password", c.password)
+	v.Set("type", strconv.Itoa(captchaType))
+	v.Set(spec.paramsField(), string(payloadBytes))
+
+	return c.buildFormRequest(ctx, v)
+}
+
+func (c *Client) buildFormRequest(ctx context.Context, v url.Values) (*http.Request, error) {
+	ctx, urlReq, err := c.route(ctx, RouteUpload, 0)
+	if err != nil {
+		return nil, err
+	}
+	if solveTime, ok := c.maxSolveTime(ctx); ok {
+		v.Set("max_solve_time", solveTime)
+	}
+
+	return c.newPostRequest(ctx, urlReq.String(), []byte(v.Encode()), "application/x-www-form-urlencoded")
+}
+
+//maxSolveTime returns the seconds left before the context's deadline, when PropagateDeadline is set
+func (c *Client) maxSolveTime(ctx context.Context) (string, bool) {
+	if !c.opts().PropagateDeadline {
+		return "", false
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return "", false
+	}
+	left := time.Until(deadline)
+	if left <= 0 {
+		return "", false
+	}
+
+	return strconv.Itoa(int(math.Ceil(left.Seconds()))), true
+}
+
+//decodeResponse decodes the JSON object of an api response, anything else is ErrUnexpectedServerResponse
+func decodeResponse(body []byte, response interface{}) error {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 || body[0] != '{' {
+		return ErrUnexpectedServerResponse
+	}
+	if err := json.Unmarshal(body, response); err != nil {
+		return ErrUnexpectedServerResponse
+	}
+	return nil
+}
+
+/*ParseCaptchaResponse decodes the body of a captcha api response (submission or report)
+  A status other than 0 is returned as a *ServiceError
+*/
+func ParseCaptchaResponse(body []byte) (*CaptchaResponse, error) {
+	return parse[CaptchaResponse](body, jsonContentType)
+}
+
+/*ParsePollResponse decodes the body of a captcha poll response
+  On top of ParseCaptchaResponse checks, a captcha the solvers gave up on (not correct, or solved as "?") is returned as ErrCaptchaInvalid.
+  A captcha still being solved is returned with an empty Text
+*/
+func ParsePollResponse(body []byte) (*CaptchaResponse, error) {
+	response, err := ParseCaptchaResponse(body)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPoll(response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+//checkPoll returns ErrCaptchaInvalid for a captcha the solvers gave up on
+func checkPoll(response *CaptchaResponse) error {
+	if !response.IsCorrect || response.Text == "?" {
+		return ErrCaptchaInvalid
+	}
+	return nil
+}
+
+//ParseUserResponse decodes the body of a `user` api response. A status other than 0 is returned as a *ServiceError
+func ParseUserResponse(body []byte) (*UserResponse, error) {
+	return parse[UserResponse](body, jsonContentType)
+}
+
+//ParseStatusResponse decodes the body of a `status` api response. A status other than 0 is returned as a *ServiceError
+func ParseStatusResponse(body []byte) (*StatusResponse, error) {
+	return parse[StatusResponse](body, jsonContentType)
+}
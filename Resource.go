@@ -0,0 +1,60 @@
+package godbc
+
+import (
+	"context"
+	"errors"
+)
+
+//ErrDetached - The response was not returned by a Client, e.g. it was decoded from JSON, so it cannot be waited, reported or refreshed on its own
+var ErrDetached = errors.New("Captcha response is not attached to a client")
+
+/*describe attaches a response to the client, with the urls of its poll and report endpoints
+  location: the poll url given by the service, kept when it points at the captcha's poll endpoint, may be empty
+*/
+func (c *Client) describe(response *CaptchaResponse, location string) *CaptchaResponse {
+	response.client = c
+	if response.Local || response.ID == 0 {
+		return response
+	}
+	if id, ok := c.captchaIDFromLocation(location); ok && id == response.ID {
+		if pollURL, err := c.opts().Endpoint.Parse(location); err == nil {
+			response.PollURL = pollURL.String()
+		}
+	}
+	if response.PollURL == "" {
+		if pollURL, err := c.routeURL(RoutePoll, response.ID); err == nil {
+			response.PollURL = pollURL.String()
+		}
+	}
+	if reportURL, err := c.routeURL(RouteReport, response.ID); err == nil {
+		response.ReportURL = reportURL.String()
+	}
+	return response
+}
+
+//Wait waits for the captcha to be solved with the client that returned it, see Client.WaitCaptchaWithContext
+func (r *CaptchaResponse) Wait(ctx context.Context) (*CaptchaResponse, error) {
+	if r.client == nil {
+		return nil, ErrDetached
+	}
+	return r.client.WaitCaptchaWithContext(ctx, r)
+}
+
+//Report reports the captcha as incorrectly solved with the client that returned it, see Client.ReportCaptchaWithContext
+func (r *CaptchaResponse) Report(ctx context.Context) (*CaptchaResponse, error) {
+	if r.client == nil {
+		return nil, ErrDetached
+	}
+	return r.client.ReportCaptchaWithContext(ctx, r)
+}
+
+//Refresh polls the captcha once with the client that returned it and returns its current state, the receiver is left unchanged
+func (r *CaptchaResponse) Refresh(ctx context.Context) (*CaptchaResponse, error) {
+	if r.client == nil {
+		return nil, ErrDetached
+	}
+	if r.Local {
+		return r, nil
+	}
+	return r.client.PollCaptchaWithContext(ctx, r)
+}
@@ -0,0 +1,36 @@
+package godbc
+
+import (
+	"context"
+	"testing"
+)
+
+func TestResponseMethods(t *testing.T) {
+	client := newSandboxClient(SandboxConfig{Answer: "abcdef"})
+	ctx := context.Background()
+	ressource, err := client.CaptchaWithOptions(ctx, benchmarkImage(t), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refreshed, err := ressource.Refresh(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshed.ID != ressource.ID || refreshed.PollURL != ressource.PollURL {
+		t.Fatalf("unexpected refreshed response %+v", refreshed)
+	}
+	resolved, err := ressource.Wait(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.Text != "abcdef" {
+		t.Fatalf("unexpected response %+v", resolved)
+	}
+	if _, err := resolved.Report(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := (&CaptchaResponse{ID: 1}).Wait(ctx); err != ErrDetached {
+		t.Fatalf("got %v, want ErrDetached", err)
+	}
+}
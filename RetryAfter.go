@@ -0,0 +1,77 @@
+package godbc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+//RetryAfterError is returned when the service asked to slow down, with when to try again. It wraps the error of the response, e.g. ErrOverloadedServer
+type RetryAfterError struct {
+	Err error
+	//RetryAfter - from the Retry-After header, or until Reset when no call is Remaining
+	RetryAfter time.Duration
+	//Limit, Remaining, Reset - from the X-RateLimit-* headers, zero when missing
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("%s (retry after %s)", e.Err, e.RetryAfter)
+}
+
+//Unwrap returns the error of the response
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+//RetryAfter returns how long the service asked to wait before trying again, 0 if err does not tell
+func RetryAfter(err error) time.Duration {
+	var retryErr *RetryAfterError
+	if errors.As(err, &retryErr) {
+		return retryErr.RetryAfter
+	}
+	return 0
+}
+
+//withRetryAfter wraps err in a RetryAfterError when the headers tell when to try again
+func withRetryAfter(err error, header http.Header) error {
+	retryErr := &RetryAfterError{Err: err}
+	found := false
+	if value := header.Get("Retry-After"); value != "" {
+		if seconds, parseErr := strconv.Atoi(value); parseErr == nil {
+			retryErr.RetryAfter, found = time.Duration(seconds)*time.Second, true
+		} else if at, parseErr := http.ParseTime(value); parseErr == nil {
+			retryErr.RetryAfter, found = time.Until(at), true
+		}
+	}
+	if limit, parseErr := strconv.Atoi(header.Get("X-RateLimit-Limit")); parseErr == nil {
+		retryErr.Limit, found = limit, true
+	}
+	if remaining, parseErr := strconv.Atoi(header.Get("X-RateLimit-Remaining")); parseErr == nil {
+		retryErr.Remaining, found = remaining, true
+	}
+	if reset, parseErr := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); parseErr == nil {
+		//either a unix time or a number of seconds
+		if reset > 1000000000 {
+			retryErr.Reset = time.Unix(reset, 0)
+		} else {
+			retryErr.Reset = time.Now().Add(time.Duration(reset) * time.Second)
+		}
+		found = true
+		if retryErr.RetryAfter == 0 && retryErr.Remaining == 0 {
+			retryErr.RetryAfter = time.Until(retryErr.Reset)
+		}
+	}
+
+	if !found {
+		return err
+	}
+	if retryErr.RetryAfter < 0 {
+		retryErr.RetryAfter = 0
+	}
+	return retryErr
+}
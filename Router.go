@@ -0,0 +1,402 @@
+package godbc
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+//ErrNoProvider - The router has no provider to send the captcha to
+var ErrNoProvider = errors.New("No provider available")
+
+//Solver solves captchas and takes reports of wrong answers. *Client implements it, adapters of other providers can too, so a Router can split traffic between them
+type Solver interface {
+	SolveImage(ctx context.Context, content []byte, options *CaptchaOptions) (*CaptchaResponse, error)
+	SolveToken(ctx context.Context, captchaType CaptchaType, params interface{}) (*CaptchaResponse, error)
+	ReportCaptchaWithContext(ctx context.Context, ressource *CaptchaResponse) (*CaptchaResponse, error)
+}
+
+//SolveImage uploads an image captcha and waits for its answer, see Solve
+func (c *Client) SolveImage(ctx context.Context, content []byte, options *CaptchaOptions) (*CaptchaResponse, error) {
+	return c.Solve(ctx, content, options, nil)
+}
+
+//Provider is a solver a Router sends captchas to
+type Provider struct {
+	//Name - identifies the provider in the responses, see CaptchaResponse.Provider, and in the router statistics
+	Name   string
+	Solver Solver
+	//Weight - the share of the traffic of RouteWeighted, 1 if 0
+	Weight float64
+	//Prices - the price of a captcha of each type, in the currency of the provider's balance, see Router.SetPrices
+	Prices PriceTable
+}
+
+//PriceTable is the price of a captcha of each type, types missing from it are priced at the account rate when known
+type PriceTable map[CaptchaType]float64
+
+//accountSolver is a Solver exposing its account, e.g. *Client, so the router can follow its balance
+type accountSolver interface {
+	UserWithContext(ctx context.Context) (*UserResponse, error)
+}
+
+//RouteStrategy is how a Router picks the provider of a captcha
+type RouteStrategy int
+
+//Route strategies
+const (
+	//RouteWeighted - providers are picked at random, in proportion of their Weight
+	RouteWeighted RouteStrategy = iota
+	//RouteFastest - the provider with the lowest observed solve latency is picked
+	RouteFastest
+	//RouteMostAccurate - the provider with the fewest reported answers is picked
+	RouteMostAccurate
+	//RouteCheapest - the provider with the lowest price for the captcha type is picked, unpriced providers last
+	RouteCheapest
+)
+
+/*RouterOptions tunes a Router
+  Strategy: how the provider of a captcha is picked, the other providers are tried in turn when it fails
+  Strategies: the strategy of some captcha types, overriding Strategy, e.g. RouteCheapest for TypeImage and RouteMostAccurate for TypeRecaptchaV3. See Router.SetStrategy
+  DemoteAfter: consecutive failures after which a provider is demoted, 3 if 0
+  DemoteFor: how long a demoted provider only gets the captchas no other provider could take, 1 minute if 0
+  MinAccuracy: below this ratio of unreported answers, over at least 20 solves, a provider is demoted. 0 disables it
+*/
+type RouterOptions struct {
+	Strategy    RouteStrategy
+	Strategies  map[CaptchaType]RouteStrategy
+	DemoteAfter int
+	DemoteFor   time.Duration
+	MinAccuracy float64
+}
+
+//minAccuracySamples is how many solves a provider needs before its accuracy can demote it
+const minAccuracySamples = 20
+
+//ProviderStats is what a Router observed of a provider
+type ProviderStats struct {
+	Name     string
+	Solved   int
+	Failed   int
+	Reported int
+	//Latency - the moving average of solve latency
+	Latency time.Duration
+	//Accuracy - the ratio of answers that were not reported, 1 without solves
+	Accuracy float64
+	//DemotedUntil - zero when the provider is not demoted
+	DemotedUntil time.Time
+	//Balance - the last known balance, less the price of the captchas solved since. Only set when BalanceKnown
+	Balance      float64
+	BalanceKnown bool
+}
+
+type routedProvider struct {
+	Provider
+	stats    ProviderStats
+	failures int
+	//rate - the account rate, the price of the types missing from Prices
+	rate float64
+}
+
+//price returns the price of a captcha of the given type, false when unknown
+func (p *routedProvider) price(captchaType CaptchaType) (float64, bool) {
+	if price, ok := p.Prices[captchaType]; ok {
+		return price, true
+	}
+	return p.rate, p.rate > 0
+}
+
+//funded returns false when the provider is known to lack the balance for a captcha of the given type
+func (p *routedProvider) funded(captchaType CaptchaType) bool {
+	if !p.stats.BalanceKnown {
+		return true
+	}
+	price, _ := p.price(captchaType)
+	return p.stats.Balance > 0 && p.stats.Balance >= price
+}
+
+func (p *routedProvider) accuracy() float64 {
+	if p.stats.Solved == 0 {
+		return 1
+	}
+	return 1 - float64(p.stats.Reported)/float64(p.stats.Solved)
+}
+
+//Router is a Solver splitting captchas between providers, failing over to the next provider on retryable errors and demoting misbehaving ones
+type Router struct {
+	options RouterOptions
+
+	mu        sync.Mutex
+	random    *rand.Rand
+	providers []*routedProvider
+}
+
+//NewRouter returns a router between the given providers
+func NewRouter(options RouterOptions, providers ...Provider) *Router {
+	if options.DemoteAfter <= 0 {
+		options.DemoteAfter = 3
+	}
+	if options.DemoteFor <= 0 {
+		options.DemoteFor = time.Minute
+	}
+	strategies := map[CaptchaType]RouteStrategy{}
+	for captchaType, strategy := range options.Strategies {
+		strategies[captchaType] = strategy
+	}
+	options.Strategies = strategies
+	r := &Router{options: options, random: rand.New(rand.NewSource(time.Now().UnixNano()))}
+	for _, provider := range providers {
+		if provider.Weight <= 0 {
+			provider.Weight = 1
+		}
+		provider.Prices = provider.Prices.clone()
+		r.providers = append(r.providers, &routedProvider{Provider: provider, stats: ProviderStats{Name: provider.Name}})
+	}
+	return r
+}
+
+//SolveImage solves an image captcha with the picked provider, then the others in turn while they fail
+func (r *Router) SolveImage(ctx context.Context, content []byte, options *CaptchaOptions) (*CaptchaResponse, error) {
+	return r.solve(ctx, TypeImage, func(solver Solver) (*CaptchaResponse, error) {
+		return solver.SolveImage(ctx, content, options)
+	})
+}
+
+//SolveToken solves a token captcha with the picked provider, then the others in turn while they fail
+func (r *Router) SolveToken(ctx context.Context, captchaType CaptchaType, params interface{}) (*CaptchaResponse, error) {
+	return r.solve(ctx, captchaType, func(solver Solver) (*CaptchaResponse, error) {
+		return solver.SolveToken(ctx, captchaType, params)
+	})
+}
+
+//ReportCaptchaWithContext reports a captcha to the provider that solved it, counting against its accuracy
+func (r *Router) ReportCaptchaWithContext(ctx context.Context, ressource *CaptchaResponse) (*CaptchaResponse, error) {
+	r.mu.Lock()
+	provider := r.provider(ressource.Provider)
+	if provider == nil {
+		r.mu.Unlock()
+		return nil, ErrNoProvider
+	}
+	provider.stats.Reported++
+	r.checkAccuracy(provider)
+	r.mu.Unlock()
+
+	return provider.Solver.ReportCaptchaWithContext(ctx, ressource)
+}
+
+//SetStrategy changes the strategy picking the provider of a captcha type
+func (r *Router) SetStrategy(captchaType CaptchaType, strategy RouteStrategy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.options.Strategies[captchaType] = strategy
+}
+
+//SetPrices replaces the price table of a provider, ErrNoProvider is returned when no provider has this name
+func (r *Router) SetPrices(name string, prices PriceTable) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	provider := r.provider(name)
+	if provider == nil {
+		return ErrNoProvider
+	}
+	provider.Prices = prices.clone()
+	return nil
+}
+
+//SetBalance sets the balance of a provider, captchas it cannot afford go to the other providers first
+func (r *Router) SetBalance(name string, balance float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	provider := r.provider(name)
+	if provider == nil {
+		return ErrNoProvider
+	}
+	provider.stats.Balance, provider.stats.BalanceKnown = balance, true
+	return nil
+}
+
+//RefreshBalances fetches the balance and rate of the providers exposing their account, such as *Client. The first error is returned, after all providers were refreshed
+func (r *Router) RefreshBalances(ctx context.Context) error {
+	var first error
+	for _, provider := range r.list() {
+		account, ok := provider.Solver.(accountSolver)
+		if !ok {
+			continue
+		}
+		user, err := account.UserWithContext(ctx)
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			continue
+		}
+		r.mu.Lock()
+		provider.stats.Balance, provider.stats.BalanceKnown = user.Balance, true
+		provider.rate = user.Rate
+		r.mu.Unlock()
+	}
+	return first
+}
+
+//Stats returns what the router observed of each provider
+func (r *Router) Stats() []ProviderStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := make([]ProviderStats, 0, len(r.providers))
+	for _, p := range r.providers {
+		s := p.stats
+		s.Accuracy = p.accuracy()
+		stats = append(stats, s)
+	}
+	return stats
+}
+
+func (r *Router) solve(ctx context.Context, captchaType CaptchaType, solve func(solver Solver) (*CaptchaResponse, error)) (*CaptchaResponse, error) {
+	err := ErrNoProvider
+	for _, provider := range r.order(captchaType) {
+		start := time.Now()
+		var solved *CaptchaResponse
+		solved, err = solve(provider.Solver)
+		if err == nil {
+			r.observe(provider, captchaType, time.Since(start), nil)
+			solved.Provider = provider.Name
+			return solved, nil
+		}
+		if ctx.Err() != nil || !failsOver(err) {
+			return nil, err
+		}
+		r.observe(provider, captchaType, 0, err)
+	}
+	return nil, err
+}
+
+//provider returns the provider of the given name, nil if none. r.mu must be held
+func (r *Router) provider(name string) *routedProvider {
+	for _, p := range r.providers {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+//list returns a copy of the providers
+func (r *Router) list() []*routedProvider {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*routedProvider{}, r.providers...)
+}
+
+//clone copies a price table, so the caller's map can change without a lock
+func (t PriceTable) clone() PriceTable {
+	prices := PriceTable{}
+	for captchaType, price := range t {
+		prices[captchaType] = price
+	}
+	return prices
+}
+
+//failsOver returns true when another provider may solve a captcha this one failed, or could not solve in time
+func failsOver(err error) bool {
+	return IsRetryable(err) || IsCredentialError(err) || errors.Is(err, ErrInsufficientFunds) || errors.Is(err, ErrCaptchaTimeout) || errors.Is(err, ErrCaptchaInvalid)
+}
+
+//order returns the providers in the order they are tried for a captcha type, demoted providers and those short of balance last
+func (r *Router) order(captchaType CaptchaType) []*routedProvider {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	healthy, demoted := []*routedProvider{}, []*routedProvider{}
+	for _, p := range r.providers {
+		if now.Before(p.stats.DemotedUntil) || !p.funded(captchaType) {
+			demoted = append(demoted, p)
+		} else {
+			healthy = append(healthy, p)
+		}
+	}
+	r.rank(healthy, captchaType)
+	r.rank(demoted, captchaType)
+	return append(healthy, demoted...)
+}
+
+//rank sorts providers by preference of the strategy of the captcha type
+func (r *Router) rank(providers []*routedProvider, captchaType CaptchaType) {
+	strategy, ok := r.options.Strategies[captchaType]
+	if !ok {
+		strategy = r.options.Strategy
+	}
+	switch strategy {
+	case RouteCheapest:
+		price := func(p *routedProvider) float64 {
+			if price, ok := p.price(captchaType); ok {
+				return price
+			}
+			return math.Inf(1)
+		}
+		sort.SliceStable(providers, func(i, j int) bool { return price(providers[i]) < price(providers[j]) })
+	case RouteFastest:
+		sort.SliceStable(providers, func(i, j int) bool { return providers[i].stats.Latency < providers[j].stats.Latency })
+	case RouteMostAccurate:
+		sort.SliceStable(providers, func(i, j int) bool { return providers[i].accuracy() > providers[j].accuracy() })
+	default:
+		//weighted draw without replacement
+		for i := range providers {
+			total := 0.0
+			for _, p := range providers[i:] {
+				total += p.Weight
+			}
+			draw := r.random.Float64() * total
+			for j, p := range providers[i:] {
+				draw -= p.Weight
+				if draw < 0 || j == len(providers[i:])-1 {
+					providers[i], providers[i+j] = providers[i+j], providers[i]
+					break
+				}
+			}
+		}
+	}
+}
+
+//observe records the outcome of a solve, demoting the provider after DemoteAfter consecutive failures and debiting its known balance
+func (r *Router) observe(provider *routedProvider, captchaType CaptchaType, latency time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if errors.Is(err, ErrInsufficientFunds) {
+		provider.stats.Balance, provider.stats.BalanceKnown = 0, true
+	}
+	if err != nil {
+		provider.stats.Failed++
+		provider.failures++
+		if provider.failures >= r.options.DemoteAfter {
+			provider.failures = 0
+			provider.stats.DemotedUntil = time.Now().Add(r.options.DemoteFor)
+		}
+		return
+	}
+
+	provider.stats.Solved++
+	provider.failures = 0
+	if price, ok := provider.price(captchaType); ok && provider.stats.BalanceKnown {
+		provider.stats.Balance -= price
+	}
+	if provider.stats.Latency == 0 {
+		provider.stats.Latency = latency
+	} else {
+		provider.stats.Latency = (provider.stats.Latency*4 + latency) / 5
+	}
+	r.checkAccuracy(provider)
+}
+
+//checkAccuracy demotes a provider whose accuracy fell under MinAccuracy
+func (r *Router) checkAccuracy(provider *routedProvider) {
+	if r.options.MinAccuracy <= 0 || provider.stats.Solved < minAccuracySamples || provider.accuracy() >= r.options.MinAccuracy {
+		return
+	}
+	if time.Now().After(provider.stats.DemotedUntil) {
+		provider.stats.DemotedUntil = time.Now().Add(r.options.DemoteFor)
+	}
+}
@@ -0,0 +1,137 @@
+package godbc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+//fakeSolver answers with its text, or fails with its error
+type fakeSolver struct {
+	text     string
+	err      error
+	solved   int
+	reported int
+}
+
+func (s *fakeSolver) SolveImage(ctx context.Context, content []byte, options *CaptchaOptions) (*CaptchaResponse, error) {
+	return s.SolveToken(ctx, TypeImage, nil)
+}
+
+func (s *fakeSolver) SolveToken(ctx context.Context, captchaType CaptchaType, params interface{}) (*CaptchaResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.solved++
+	return &CaptchaResponse{IsCorrect: true, Text: s.text}, nil
+}
+
+func (s *fakeSolver) ReportCaptchaWithContext(ctx context.Context, ressource *CaptchaResponse) (*CaptchaResponse, error) {
+	s.reported++
+	return ressource, nil
+}
+
+func TestRouterFailover(t *testing.T) {
+	failing := &fakeSolver{err: ErrOverloadedServer}
+	working := &fakeSolver{text: "ok"}
+	router := NewRouter(RouterOptions{DemoteAfter: 2, DemoteFor: time.Hour},
+		Provider{Name: "failing", Solver: failing, Weight: 1000},
+		Provider{Name: "working", Solver: working, Weight: 0.001})
+
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		solved, err := router.SolveImage(ctx, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if solved.Provider != "working" || solved.Text != "ok" {
+			t.Fatalf("unexpected response %+v", solved)
+		}
+	}
+
+	stats := router.Stats()
+	if stats[0].Failed != 2 || stats[0].DemotedUntil.IsZero() {
+		t.Fatalf("the failing provider was not demoted after 2 failures: %+v", stats[0])
+	}
+	if stats[1].Solved != 10 {
+		t.Fatalf("unexpected stats %+v", stats[1])
+	}
+
+	if _, err := router.ReportCaptchaWithContext(ctx, &CaptchaResponse{Provider: "working"}); err != nil {
+		t.Fatal(err)
+	}
+	if working.reported != 1 || router.Stats()[1].Accuracy != 0.9 {
+		t.Fatalf("the report did not reach the provider: %+v", router.Stats()[1])
+	}
+}
+
+func TestRouterPermanentError(t *testing.T) {
+	invalid := &fakeSolver{err: ErrInvalidFormat}
+	other := &fakeSolver{text: "ok"}
+	router := NewRouter(RouterOptions{Strategy: RouteMostAccurate}, Provider{Name: "invalid", Solver: invalid}, Provider{Name: "other", Solver: other})
+	if _, err := router.SolveImage(context.Background(), nil, nil); err != ErrInvalidFormat {
+		t.Fatalf("got %v, want ErrInvalidFormat", err)
+	}
+	if other.solved != 0 {
+		t.Fatal("an invalid captcha was sent to another provider")
+	}
+}
+
+func TestRouterCheapest(t *testing.T) {
+	cheap := &fakeSolver{text: "cheap"}
+	accurate := &fakeSolver{text: "accurate"}
+	router := NewRouter(RouterOptions{
+		Strategy:   RouteCheapest,
+		Strategies: map[CaptchaType]RouteStrategy{TypeRecaptchaV3: RouteMostAccurate},
+	},
+		Provider{Name: "accurate", Solver: accurate, Prices: PriceTable{TypeImage: 0.2}},
+		Provider{Name: "cheap", Solver: cheap, Prices: PriceTable{TypeImage: 0.1}})
+
+	ctx := context.Background()
+	solved, err := router.SolveImage(ctx, nil, nil)
+	if err != nil || solved.Provider != "cheap" {
+		t.Fatalf("got %+v, %v, want the cheapest provider", solved, err)
+	}
+	if _, err := router.ReportCaptchaWithContext(ctx, solved); err != nil {
+		t.Fatal(err)
+	}
+	solved, err = router.SolveToken(ctx, TypeRecaptchaV3, nil)
+	if err != nil || solved.Provider != "accurate" {
+		t.Fatalf("got %+v, %v, want the most accurate provider", solved, err)
+	}
+
+	//the cheapest provider runs out of balance, then its prices change
+	if err := router.SetBalance("cheap", 0.15); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"cheap", "accurate"} {
+		if solved, _ = router.SolveImage(ctx, nil, nil); solved.Provider != want {
+			t.Fatalf("solved by %s, want %s", solved.Provider, want)
+		}
+	}
+	if stats := router.Stats(); stats[1].Balance < 0.049 || stats[1].Balance > 0.051 {
+		t.Fatalf("the balance was not debited: %+v", stats[1])
+	}
+	router.SetBalance("cheap", 10)
+	if err := router.SetPrices("cheap", PriceTable{TypeImage: 0.3}); err != nil {
+		t.Fatal(err)
+	}
+	if solved, _ = router.SolveImage(ctx, nil, nil); solved.Provider != "accurate" {
+		t.Fatalf("solved by %s after the price change", solved.Provider)
+	}
+	if err := router.SetPrices("missing", nil); err != ErrNoProvider {
+		t.Fatalf("got %v, want ErrNoProvider", err)
+	}
+}
+
+func TestRouterRefreshBalances(t *testing.T) {
+	client := newSandboxClient(SandboxConfig{Balance: 12.5, Rate: 0.139})
+	router := NewRouter(RouterOptions{}, Provider{Name: "dbc", Solver: client}, Provider{Name: "other", Solver: &fakeSolver{}})
+	if err := router.RefreshBalances(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	stats := router.Stats()
+	if !stats[0].BalanceKnown || stats[0].Balance != 12.5 || stats[1].BalanceKnown {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
@@ -0,0 +1,181 @@
+package godbc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//maxRedirects is how many redirects an api call follows
+const maxRedirects = 10
+
+//Route is an endpoint of the api
+type Route struct {
+	Name string
+	//Template - the path relative to ClientOptions.Endpoint, a %d is replaced by the captcha ID
+	Template string
+	//Unavailable - the error a 503 from this endpoint is returned as, ErrUnexpectedServerResponse if nil
+	Unavailable error
+	//NotFound - the error a 404 from this endpoint is returned as, the body is decoded if nil
+	NotFound error
+}
+
+//Routes of the api
+var (
+	//RouteUpload - captcha submissions
+	RouteUpload = &Route{Name: "upload", Template: "captcha", Unavailable: ErrOverloadedServer}
+	//RoutePoll - captcha polls
+	RoutePoll = &Route{Name: "poll", Template: "captcha/%d"}
+	//RouteReport - captcha reports
+	RouteReport = &Route{Name: "report", Template: "captcha/%d/report", Unavailable: ErrReportRejected}
+	//RouteUser - account information
+	RouteUser = &Route{Name: "user", Template: "user"}
+	//RouteStatus - service status
+	RouteStatus = &Route{Name: "status", Template: "status"}
+	//RouteRecent - the account's recent uploads, not exposed by every deployment of the service
+	RouteRecent = &Route{Name: "recent", Template: "captcha/recent", NotFound: ErrEndpointUnavailable}
+)
+
+var routeTable = struct {
+	sync.RWMutex
+	routes map[string]*Route
+	//idPatterns - the paths of the routes whose template has a captcha ID, matching the ID, compiled when the route is registered
+	idPatterns map[string]*regexp.Regexp
+}{routes: map[string]*Route{}, idPatterns: map[string]*regexp.Regexp{}}
+
+func init() {
+	for _, route := range []*Route{RouteUpload, RoutePoll, RouteReport, RouteUser, RouteStatus, RouteRecent} {
+		RegisterRoute(route)
+	}
+}
+
+//RegisterRoute adds an endpoint to the routing table, replacing the route of the same name. The route's Template must not change once it is registered
+func RegisterRoute(route *Route) {
+	routeTable.Lock()
+	defer routeTable.Unlock()
+	routeTable.routes[route.Name] = route
+	delete(routeTable.idPatterns, route.Name)
+	if strings.Contains(route.Template, "%d") {
+		routeTable.idPatterns[route.Name] = regexp.MustCompile(`^` + strings.Replace(regexp.QuoteMeta(route.Template), "%d", `(\d+)`, 1) + `/?$`)
+	}
+}
+
+//lookupIDPattern returns the pattern of the paths of a route matching their captcha ID, nil if the route has none
+func lookupIDPattern(name string) *regexp.Regexp {
+	routeTable.RLock()
+	defer routeTable.RUnlock()
+	return routeTable.idPatterns[name]
+}
+
+//LookupRoute returns the route of the given name, nil if none was registered
+func LookupRoute(name string) *Route {
+	routeTable.RLock()
+	defer routeTable.RUnlock()
+	return routeTable.routes[name]
+}
+
+type routeKey struct{}
+
+//routeCall is the route of a request, with the captcha it is about
+type routeCall struct {
+	route     *Route
+	captchaID int64
+}
+
+//withRoute returns a context tagging the requests made with it with the route
+func withRoute(ctx context.Context, route *Route, captchaID int64) context.Context {
+	return context.WithValue(ctx, routeKey{}, routeCall{route: route, captchaID: captchaID})
+}
+
+//routeFrom returns the route a request was built for
+func routeFrom(ctx context.Context) *Route {
+	call, _ := ctx.Value(routeKey{}).(routeCall)
+	return call.route
+}
+
+//routeCaptchaID returns the captcha a request was built for, 0 if none
+func routeCaptchaID(ctx context.Context) int64 {
+	call, _ := ctx.Value(routeKey{}).(routeCall)
+	return call.captchaID
+}
+
+//route returns the url of a route, and a context tagging the request with the route
+func (c *Client) route(ctx context.Context, route *Route, captchaID int64) (context.Context, *url.URL, error) {
+	urlReq, err := c.routeURL(route, captchaID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return withRoute(ctx, route, captchaID), urlReq, nil
+}
+
+//routeURL returns the url of a route for the given captcha
+func (c *Client) routeURL(route *Route, captchaID int64) (*url.URL, error) {
+	path := route.Template
+	if strings.Contains(path, "%d") {
+		path = fmt.Sprintf(path, captchaID)
+	}
+	return c.endpoint().Parse(path)
+}
+
+//notFound returns the error of a 404 on the route of a request, nil when the body should be decoded
+func notFound(ctx context.Context) error {
+	if route := routeFrom(ctx); route != nil {
+		return route.NotFound
+	}
+	return nil
+}
+
+//unavailable returns the error of a 503 on the route of a request
+func unavailable(ctx context.Context) error {
+	if route := routeFrom(ctx); route != nil && route.Unavailable != nil {
+		return route.Unavailable
+	}
+	return ErrUnexpectedServerResponse
+}
+
+/*checkRedirect is the redirect policy of api calls
+  Submissions are answered with a 303 to the poll url of the captcha, it is not followed: the captcha ID is read from the Location, see captchaIDFromLocation.
+  Other calls follow up to maxRedirects redirects
+*/
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if via[0].Method == `POST` {
+		return http.ErrUseLastResponse
+	}
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	return nil
+}
+
+//captchaIDFromLocation returns the ID of the captcha a Location header points to, when it is the poll url of a captcha
+func (c *Client) captchaIDFromLocation(location string) (int64, bool) {
+	if location == "" {
+		return 0, false
+	}
+	target, err := c.opts().Endpoint.Parse(location)
+	if err != nil {
+		return 0, false
+	}
+	endpoint := c.endpointOf(target)
+	if endpoint == nil {
+		return 0, false
+	}
+	pattern := lookupIDPattern(RoutePoll.Name)
+	if pattern == nil {
+		return 0, false
+	}
+	match := pattern.FindStringSubmatch(strings.TrimPrefix(target.Path, endpoint.Path))
+	if match == nil {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,43 @@
+package godbc
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestRedirectedSubmission(t *testing.T) {
+	for _, answer := range []string{"", `{"captcha": 77, "is_correct": true, "text": "", "status": 0}`} {
+		client, transport := newMockClient(nil, func(req *http.Request, body []byte) *http.Response {
+			return mockResponse(303, answer, "Location", "http://api.dbcapi.me/api/captcha/77")
+		})
+		response, err := client.CaptchaWithOptions(context.Background(), benchmarkImage(t), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if response.ID != 77 || response.PollURL != "http://api.dbcapi.me/api/captcha/77" || response.ReportURL != "http://api.dbcapi.me/api/captcha/77/report" {
+			t.Fatalf("unexpected response %+v", response)
+		}
+		if len(transport.requests) != 1 {
+			t.Fatalf("the redirect was followed: %d requests", len(transport.requests))
+		}
+	}
+}
+
+func TestRegisteredPollRoute(t *testing.T) {
+	RegisterRoute(&Route{Name: RoutePoll.Name, Template: "captchas/%d"})
+	defer RegisterRoute(RoutePoll)
+	client, _ := newMockClient(nil, func(req *http.Request, body []byte) *http.Response {
+		return mockResponse(303, "", "Location", "http://api.dbcapi.me/api/captchas/78")
+	})
+	response, err := client.CaptchaWithOptions(context.Background(), benchmarkImage(t), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.ID != 78 {
+		t.Fatalf("got captcha %d, want the ID read with the registered template", response.ID)
+	}
+	if _, ok := client.captchaIDFromLocation("http://api.dbcapi.me/api/captcha/78"); ok {
+		t.Fatal("the replaced template still matches")
+	}
+}
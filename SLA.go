@@ -0,0 +1,157 @@
+package godbc
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+/*SLAThresholds are the service levels an SLATracker checks
+  Window: the sliding window the success rate and latency are computed over, 10 minutes if 0
+  MinSuccessRate: the lowest acceptable ratio of solved captchas, between 0 and 1, 0 disables the check
+  MaxP95: the highest acceptable 95th percentile of solve latency, 0 disables the check
+  For: how long the thresholds must be breached before OnBreach is called, e.g. 10 minutes. 0 calls it on the first breach
+  MinSamples: the least number of outcomes in the window for the thresholds to be checked, 10 if 0
+*/
+type SLAThresholds struct {
+	Window         time.Duration
+	MinSuccessRate float64
+	MaxP95         time.Duration
+	For            time.Duration
+	MinSamples     int
+}
+
+//SLAReport is the service level over an SLATracker's window
+type SLAReport struct {
+	At         time.Time
+	Solved     int
+	Failed     int
+	Success    float64
+	P95        time.Duration
+	Breached   bool
+	Reasons    []string
+	Thresholds SLAThresholds
+}
+
+type slaOutcome struct {
+	at      time.Time
+	solved  bool
+	latency time.Duration
+}
+
+/*SLATracker computes the rolling success rate and p95 latency of solves, and calls back when the thresholds are breached and once they recover, e.g. to fail over to another provider
+  Feed it the client's events with ClientOptions.OnEvent: tracker.OnEvent, or outcomes from elsewhere with Observe
+*/
+type SLATracker struct {
+	thresholds SLAThresholds
+	//OnBreach - called once when the thresholds have been breached for Thresholds.For, may be nil
+	OnBreach func(SLAReport)
+	//OnRecover - called once when the thresholds are met again after a breach, may be nil
+	OnRecover func(SLAReport)
+
+	mu          sync.Mutex
+	outcomes    []slaOutcome
+	breachSince time.Time
+	breached    bool
+}
+
+//NewSLATracker returns a tracker checking the given thresholds
+func NewSLATracker(thresholds SLAThresholds, onBreach, onRecover func(SLAReport)) *SLATracker {
+	if thresholds.Window <= 0 {
+		thresholds.Window = 10 * time.Minute
+	}
+	if thresholds.MinSamples <= 0 {
+		thresholds.MinSamples = 10
+	}
+	return &SLATracker{thresholds: thresholds, OnBreach: onBreach, OnRecover: onRecover}
+}
+
+//OnEvent observes the solved and failed captchas of a client's event stream
+func (t *SLATracker) OnEvent(e Event) {
+	switch e.Type {
+	case EventSolved:
+		t.Observe(e.At, true, e.Latency)
+	case EventFailed:
+		t.Observe(e.At, false, e.Latency)
+	}
+}
+
+//Observe records the outcome of a solve, and calls OnBreach or OnRecover when the service level changes
+func (t *SLATracker) Observe(at time.Time, solved bool, latency time.Duration) {
+	t.mu.Lock()
+	t.outcomes = append(t.outcomes, slaOutcome{at: at, solved: solved, latency: latency})
+	report := t.evaluate(at)
+	var callback func(SLAReport)
+	switch {
+	case report.Breached && t.breachSince.IsZero():
+		t.breachSince = at
+		fallthrough
+	case report.Breached:
+		if !t.breached && at.Sub(t.breachSince) >= t.thresholds.For {
+			t.breached = true
+			callback = t.OnBreach
+		}
+	default:
+		t.breachSince = time.Time{}
+		if t.breached {
+			t.breached = false
+			callback = t.OnRecover
+		}
+	}
+	t.mu.Unlock()
+
+	if callback != nil {
+		callback(report)
+	}
+}
+
+//Report returns the service level over the window ending now
+func (t *SLATracker) Report() SLAReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.evaluate(time.Now())
+}
+
+//evaluate drops the outcomes out of the window ending at now, and checks the others
+func (t *SLATracker) evaluate(now time.Time) SLAReport {
+	start := now.Add(-t.thresholds.Window)
+	kept := t.outcomes[:0]
+	for _, outcome := range t.outcomes {
+		if outcome.at.After(start) {
+			kept = append(kept, outcome)
+		}
+	}
+	t.outcomes = kept
+
+	report := SLAReport{At: now, Thresholds: t.thresholds}
+	latencies := make([]time.Duration, 0, len(kept))
+	for _, outcome := range kept {
+		if !outcome.solved {
+			report.Failed++
+			continue
+		}
+		report.Solved++
+		if outcome.latency > 0 {
+			latencies = append(latencies, outcome.latency)
+		}
+	}
+	if total := report.Solved + report.Failed; total > 0 {
+		report.Success = float64(report.Solved) / float64(total)
+	}
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		report.P95 = latencies[(len(latencies)*95-1)/100]
+	}
+
+	if report.Solved+report.Failed < t.thresholds.MinSamples {
+		return report
+	}
+	if t.thresholds.MinSuccessRate > 0 && report.Success < t.thresholds.MinSuccessRate {
+		report.Reasons = append(report.Reasons, "success rate")
+	}
+	if t.thresholds.MaxP95 > 0 && report.P95 > t.thresholds.MaxP95 {
+		report.Reasons = append(report.Reasons, "p95 latency")
+	}
+	report.Breached = len(report.Reasons) > 0
+	return report
+}
@@ -0,0 +1,37 @@
+package godbc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSLATracker(t *testing.T) {
+	var breaches, recoveries []SLAReport
+	tracker := NewSLATracker(SLAThresholds{Window: 10 * time.Minute, MinSuccessRate: 0.9, For: 5 * time.Minute, MinSamples: 5},
+		func(r SLAReport) { breaches = append(breaches, r) },
+		func(r SLAReport) { recoveries = append(recoveries, r) })
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+	for i := 0; i < 5; i++ {
+		tracker.Observe(at(i), true, 10*time.Second)
+	}
+	//failing from minute 5, the breach lasts 5 minutes at minute 10
+	for i := 5; i <= 10; i++ {
+		tracker.Observe(at(i), false, 0)
+		if i < 10 && len(breaches) != 0 {
+			t.Fatalf("breach reported after %d minutes", i-5)
+		}
+	}
+	if len(breaches) != 1 || breaches[0].Success >= 0.9 || breaches[0].Reasons[0] != "success rate" {
+		t.Fatalf("unexpected breaches %+v", breaches)
+	}
+
+	//the failures leave the window
+	for i := 21; i < 30; i++ {
+		tracker.Observe(at(i), true, 10*time.Second)
+	}
+	if len(breaches) != 1 || len(recoveries) != 1 || recoveries[0].P95 != 10*time.Second {
+		t.Fatalf("unexpected breaches %+v and recoveries %+v", breaches, recoveries)
+	}
+}
@@ -0,0 +1,216 @@
+package godbc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+/*SandboxConfig makes the client run against an in-process fake of the API, to test pipelines without spending credit
+  Solve: answers image captchas, e.g. with a local OCR, defaults to returning Answer
+  Answer: the canned answer of image captchas, defaults to "sandbox"
+  Latency: time a captcha takes to be solved, Jitter is added at random on top of it
+  FailureRate: probability, between 0 and 1, for a captcha to come back unsolvable
+  Balance, Rate: returned by the `user` call
+*/
+type SandboxConfig struct {
+	Solve       func(content []byte) (string, error)
+	Answer      string
+	Latency     time.Duration
+	Jitter      time.Duration
+	FailureRate float64
+	Balance     float64
+	Rate        float64
+}
+
+type sandboxCaptcha struct {
+	text       string
+	uploadedAt time.Time
+	solvedAt   time.Time
+	failed     bool
+}
+
+//SandboxTransport is an http.RoundTripper faking the API, see SandboxConfig
+type SandboxTransport struct {
+	config SandboxConfig
+
+	mu       sync.Mutex
+	random   *rand.Rand
+	nextID   int64
+	captchas map[int64]*sandboxCaptcha
+}
+
+var (
+	sandboxCaptchaPath = regexp.MustCompile(`captcha/(\d+)(/report)?$`)
+	sandboxUserPath    = regexp.MustCompile(`user$`)
+	sandboxStatusPath  = regexp.MustCompile(`status$`)
+	sandboxUploadPath  = regexp.MustCompile(`captcha$`)
+	sandboxRecentPath  = regexp.MustCompile(`captcha/recent$`)
+)
+
+//NewSandboxTransport returns a transport faking the API
+func NewSandboxTransport(config SandboxConfig) *SandboxTransport {
+	if config.Answer == "" {
+		config.Answer = "sandbox"
+	}
+	return &SandboxTransport{
+		config:   config,
+		random:   rand.New(rand.NewSource(time.Now().UnixNano())),
+		nextID:   1,
+		captchas: map[int64]*sandboxCaptcha{},
+	}
+}
+
+//RoundTrip answers a request as the API would
+func (t *SandboxTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		defer req.Body.Close()
+	}
+
+	path := req.URL.Path
+	switch {
+	case sandboxUserPath.MatchString(path):
+		return t.respond(req, 200, map[string]interface{}{"user": 1, "rate": t.config.Rate, "balance": t.config.Balance, "is_banned": false, "status": StatusOK})
+	case sandboxStatusPath.MatchString(path):
+		return t.respond(req, 200, map[string]interface{}{"todays_accuracy": 1 - t.config.FailureRate, "solved_in": (t.config.Latency + t.config.Jitter/2).Seconds(), "is_service_overloaded": false, "status": StatusOK})
+	case req.Method == `POST` && sandboxUploadPath.MatchString(path):
+		return t.upload(req)
+	case sandboxRecentPath.MatchString(path):
+		return t.recent(req)
+	}
+
+	match := sandboxCaptchaPath.FindStringSubmatch(path)
+	if match == nil {
+		return t.respond(req, 404, map[string]interface{}{"status": StatusError, "error": "not-found"})
+	}
+	id, _ := strconv.ParseInt(match[1], 10, 64)
+
+	t.mu.Lock()
+	captcha, ok := t.captchas[id]
+	t.mu.Unlock()
+	if !ok {
+		return t.respond(req, 404, map[string]interface{}{"status": StatusError, "error": "invalid-captcha"})
+	}
+	if match[2] != "" {
+		return t.respond(req, 200, map[string]interface{}{"captcha": id, "is_correct": false, "text": captcha.text, "status": StatusOK})
+	}
+	if time.Now().Before(captcha.solvedAt) {
+		return t.respond(req, 200, map[string]interface{}{"captcha": id, "is_correct": true, "text": "", "status": StatusOK})
+	}
+	if captcha.failed {
+		return t.respond(req, 200, map[string]interface{}{"captcha": id, "is_correct": false, "text": "?", "status": StatusOK})
+	}
+	return t.respond(req, 200, map[string]interface{}{"captcha": id, "is_correct": true, "text": captcha.text, "status": StatusOK})
+}
+
+func (t *SandboxTransport) upload(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		reader := io.Reader(req.Body)
+		if req.Header.Get("Content-Encoding") == "gzip" {
+			if reader, err = gzip.NewReader(req.Body); err != nil {
+				return nil, err
+			}
+		}
+		body, err = ioutil.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	t.mu.Lock()
+	id := t.nextID
+	t.nextID++
+	delay := t.config.Latency
+	if t.config.Jitter > 0 {
+		delay += time.Duration(t.random.Int63n(int64(t.config.Jitter)))
+	}
+	failed := t.random.Float64() < t.config.FailureRate
+	t.mu.Unlock()
+
+	text := "sandbox-token-" + strconv.FormatInt(id, 10)
+	mediaType, params, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		text = t.config.Answer
+		if t.config.Solve != nil {
+			content := sandboxCaptchaFile(body, params["boundary"])
+			solved, err := t.config.Solve(content)
+			if err != nil {
+				failed = true
+			}
+			text = solved
+		}
+	}
+
+	t.mu.Lock()
+	t.captchas[id] = &sandboxCaptcha{text: text, uploadedAt: time.Now(), solvedAt: time.Now().Add(delay), failed: failed}
+	t.mu.Unlock()
+
+	return t.respond(req, 200, map[string]interface{}{"captcha": id, "is_correct": true, "text": "", "status": StatusOK})
+}
+
+//recent lists the captchas uploaded to the sandbox, most recent first
+func (t *SandboxTransport) recent(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	captchas := make([]map[string]interface{}, 0, len(t.captchas))
+	for id := t.nextID - 1; id > 0; id-- {
+		captcha, ok := t.captchas[id]
+		if !ok {
+			continue
+		}
+		listed := map[string]interface{}{"captcha": id, "is_correct": true, "text": captcha.text, "uploaded": captcha.uploadedAt.Unix()}
+		if time.Now().Before(captcha.solvedAt) {
+			listed["text"] = ""
+		} else if captcha.failed {
+			listed["is_correct"], listed["text"] = false, "?"
+		}
+		captchas = append(captchas, listed)
+	}
+	t.mu.Unlock()
+
+	return t.respond(req, 200, map[string]interface{}{"captchas": captchas, "status": StatusOK})
+}
+
+//sandboxCaptchaFile returns the captcha file of a multipart upload
+func sandboxCaptchaFile(body []byte, boundary string) []byte {
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			return nil
+		}
+		if part.FormName() == "captchafile" {
+			content, _ := ioutil.ReadAll(part)
+			return content
+		}
+	}
+}
+
+func (t *SandboxTransport) respond(req *http.Request, statusCode int, body interface{}) (*http.Response, error) {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode:    statusCode,
+		Status:        strconv.Itoa(statusCode) + " " + http.StatusText(statusCode),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/json"}, "Date": {time.Now().UTC().Format(http.TimeFormat)}},
+		Body:          ioutil.NopCloser(bytes.NewReader(encoded)),
+		ContentLength: int64(len(encoded)),
+		Request:       req,
+	}, nil
+}
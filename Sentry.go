@@ -0,0 +1,44 @@
+//go:build sentry
+
+package godbc
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/getsentry/sentry-go"
+)
+
+//SentrySink is an ErrorSink capturing errors in Sentry, available with the `sentry` build tag
+type SentrySink struct {
+	//Hub - the hub errors are captured with, sentry.CurrentHub() if nil
+	Hub *sentry.Hub
+}
+
+//Report captures the error, grouped by class and endpoint so each failure mode is one issue whatever the addresses and urls in its message
+func (s *SentrySink) Report(ctx context.Context, report ErrorReport) {
+	hub := s.Hub
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+
+	hub.WithScope(func(scope *sentry.Scope) {
+		scope.SetTag("godbc.class", report.Class)
+		scope.SetTag("godbc.endpoint", report.Endpoint)
+		if report.CaptchaType != "" {
+			scope.SetTag("godbc.captcha_type", report.CaptchaType)
+		}
+		if report.Tenant != "" {
+			scope.SetTag("godbc.tenant", report.Tenant)
+		}
+		if report.CorrelationID != "" {
+			scope.SetTag("godbc.correlation_id", report.CorrelationID)
+		}
+		scope.SetContext("godbc", map[string]interface{}{
+			"captcha_id": strconv.FormatInt(report.CaptchaID, 10),
+			"attempt":    report.Attempt,
+		})
+		scope.SetFingerprint([]string{"godbc", report.Class, report.Endpoint})
+		hub.CaptureException(report.Err)
+	})
+}
@@ -0,0 +1,67 @@
+package godbc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+//Errors the service reports in response bodies, matched by a *ServiceError with errors.Is
+var (
+	//ErrBanned - The user is banned
+	ErrBanned = errors.New("User is banned")
+	//ErrInsufficientFunds - The balance is too low to solve a captcha
+	ErrInsufficientFunds = errors.New("Insufficient funds")
+	//ErrInvalidCaptchaID - No captcha exists with this ID
+	ErrInvalidCaptchaID = errors.New("Invalid captcha ID")
+	//ErrServiceBug - The service failed on its side, or reported an error this package does not know
+	ErrServiceBug = errors.New("Service error")
+)
+
+//serviceErrors maps the error codes documented by the service to their error
+var serviceErrors = map[string]error{
+	"not-logged-in":       ErrCredentialsRejected,
+	"invalid-credentials": ErrCredentialsRejected,
+	"banned":              ErrBanned,
+	"insufficient-funds":  ErrInsufficientFunds,
+	"invalid-captcha":     ErrInvalidCaptchaID,
+	"not-found":           ErrInvalidCaptchaID,
+	"service-overload":    ErrOverloadedServer,
+	"upload-failed":       ErrCaptchaRejected,
+	"invalid-image":       ErrCaptchaRejected,
+}
+
+/*ServiceError is an error reported in the body of a response, with a status other than 0
+  The api reports its errors with StatusError (255). Other statuses are not documented, they are failures too rather than answers to use
+*/
+type ServiceError struct {
+	//Status - the status field of the response, StatusError for the errors documented by the api
+	Status int
+	//Code - the error field of the response, e.g. "insufficient-funds"
+	Code string
+	//Err - the error the code maps to, ErrServiceBug for unknown codes
+	Err error
+}
+
+func (e *ServiceError) Error() string {
+	return fmt.Sprintf("Generic error from service: %s", e.Code)
+}
+
+//Unwrap returns the error the code maps to
+func (e *ServiceError) Unwrap() error {
+	return e.Err
+}
+
+//newServiceError returns the error of a response with the given status and error code
+func newServiceError(status StatusCode, code string) error {
+	err, ok := serviceErrors[strings.ToLower(strings.TrimSpace(code))]
+	if !ok {
+		err = ErrServiceBug
+	}
+	return &ServiceError{Status: int(status), Code: code, Err: err}
+}
+
+//StatusCode returns the status of the response, e.g. StatusError
+func (e *ServiceError) StatusCode() StatusCode {
+	return StatusCode(e.Status)
+}
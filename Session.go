@@ -0,0 +1,180 @@
+package godbc
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+//sessionVersion is the format of the data of Snapshot
+const sessionVersion = 1
+
+//ErrSnapshotVersion - The snapshot was taken by an incompatible version of the package
+var ErrSnapshotVersion = errors.New("Snapshot format is not supported")
+
+//StatefulLimiter is implemented by limiters keeping their state in-process, so Snapshot saves it
+type StatefulLimiter interface {
+	Limiter
+	//SaveState returns the state of the limiter
+	SaveState() ([]byte, error)
+	//RestoreState loads a state returned by SaveState
+	RestoreState(state []byte) error
+}
+
+//sessionState is the data of Snapshot
+type sessionState struct {
+	Version int `json:"version"`
+	//Submitted - the submission times of the captchas in the report window
+	Submitted map[int64]time.Time `json:"submitted"`
+	//Pending - the captchas submitted and not solved yet
+	Pending []int64 `json:"pending"`
+	//Uploads - the idempotency tokens of the pending uploads, to captcha IDs
+	Uploads  map[string]int64 `json:"uploads,omitempty"`
+	Counters sessionCounters  `json:"counters"`
+	Reports  sessionReports   `json:"reports"`
+	Limiter  []byte           `json:"limiter,omitempty"`
+}
+
+type sessionCounters struct {
+	Submitted    int64   `json:"submitted"`
+	Solved       int64   `json:"solved"`
+	Failed       int64   `json:"failed"`
+	Reported     int64   `json:"reported"`
+	SolveLatency int64   `json:"solve_latency"`
+	Rate         float64 `json:"rate"`
+	Uploaded     int64   `json:"uploaded"`
+	Downloaded   int64   `json:"downloaded"`
+}
+
+type sessionReports struct {
+	Solved int `json:"solved"`
+	//Count - the reports of the session, for ReportRatio and ReportPolicy.MaxRatio
+	Count    int         `json:"count"`
+	Reported []time.Time `json:"reported"`
+}
+
+/*Snapshot serializes the session of the client: pending captchas, submission times, statistics, report budget, and the limiter state when it is a StatefulLimiter
+  A redeployed process resumes with Restore, then waits for the PendingCaptchas. The credentials and options are not included
+*/
+func (c *Client) Snapshot() ([]byte, error) {
+	state := sessionState{Version: sessionVersion, Submitted: map[int64]time.Time{}, Pending: []int64{}}
+
+	c.submitted.mu.Lock()
+	for id, at := range c.submitted.at {
+		state.Submitted[id] = at
+	}
+	for id := range c.submitted.pending {
+		state.Pending = append(state.Pending, id)
+	}
+	c.submitted.mu.Unlock()
+	sort.Slice(state.Pending, func(i, j int) bool { return state.Pending[i] < state.Pending[j] })
+
+	c.uploads.mu.Lock()
+	if len(c.uploads.byKey) > 0 {
+		state.Uploads = map[string]int64{}
+		for key, pending := range c.uploads.byKey {
+			state.Uploads[key] = pending.ressource.ID
+		}
+	}
+	c.uploads.mu.Unlock()
+
+	state.Counters = sessionCounters{
+		Submitted:    atomic.LoadInt64(&c.counters.submitted),
+		Solved:       atomic.LoadInt64(&c.counters.solved),
+		Failed:       atomic.LoadInt64(&c.counters.failed),
+		Reported:     atomic.LoadInt64(&c.counters.reported),
+		SolveLatency: atomic.LoadInt64(&c.counters.solveLatency),
+		Rate:         c.price(),
+		Uploaded:     atomic.LoadInt64(&c.counters.uploaded),
+		Downloaded:   atomic.LoadInt64(&c.counters.downloaded),
+	}
+
+	c.reports.mu.Lock()
+	state.Reports = sessionReports{Solved: c.reports.solved, Count: c.reports.reports, Reported: append([]time.Time{}, c.reports.reported...)}
+	c.reports.mu.Unlock()
+
+	if limiter, ok := c.opts().Limiter.(StatefulLimiter); ok {
+		saved, err := limiter.SaveState()
+		if err != nil {
+			return nil, err
+		}
+		state.Limiter = saved
+	}
+
+	return json.Marshal(state)
+}
+
+//Restore loads a session saved by Snapshot, replacing the client's. ErrSnapshotVersion is returned for data of an incompatible version
+func (c *Client) Restore(data []byte) error {
+	state := sessionState{}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	if state.Version != sessionVersion {
+		return ErrSnapshotVersion
+	}
+	if limiter, ok := c.opts().Limiter.(StatefulLimiter); ok && len(state.Limiter) > 0 {
+		if err := limiter.RestoreState(state.Limiter); err != nil {
+			return err
+		}
+	}
+
+	c.submitted.mu.Lock()
+	c.submitted.at = map[int64]time.Time{}
+	for id, at := range state.Submitted {
+		c.submitted.at[id] = at
+	}
+	c.submitted.pending, c.submitted.own = map[int64]bool{}, map[int64]bool{}
+	for _, id := range state.Pending {
+		c.submitted.pending[id] = true
+		c.submitted.own[id] = true
+	}
+	c.submitted.mu.Unlock()
+
+	c.uploads.mu.Lock()
+	c.uploads.byKey, c.uploads.byID = map[string]pendingUpload{}, map[int64]string{}
+	c.uploads.mu.Unlock()
+	for key, id := range state.Uploads {
+		c.uploads.put(key, c.pendingCaptcha(id, state.Submitted[id]), state.Submitted[id])
+	}
+
+	atomic.StoreInt64(&c.counters.submitted, state.Counters.Submitted)
+	atomic.StoreInt64(&c.counters.solved, state.Counters.Solved)
+	atomic.StoreInt64(&c.counters.failed, state.Counters.Failed)
+	atomic.StoreInt64(&c.counters.reported, state.Counters.Reported)
+	atomic.StoreInt64(&c.counters.solveLatency, state.Counters.SolveLatency)
+	atomic.StoreUint64(&c.counters.rateBits, math.Float64bits(state.Counters.Rate))
+	atomic.StoreInt64(&c.counters.uploaded, state.Counters.Uploaded)
+	atomic.StoreInt64(&c.counters.downloaded, state.Counters.Downloaded)
+
+	c.reports.mu.Lock()
+	c.reports.solved, c.reports.reports, c.reports.reported = state.Reports.Solved, state.Reports.Count, state.Reports.Reported
+	c.reports.mu.Unlock()
+	return nil
+}
+
+//PendingCaptchas returns the captchas submitted by the client, or by the session it restored, that are not solved yet. They are waited for with WaitCaptcha
+func (c *Client) PendingCaptchas() []*CaptchaResponse {
+	c.submitted.mu.Lock()
+	ids := make([]int64, 0, len(c.submitted.pending))
+	for id := range c.submitted.pending {
+		ids = append(ids, id)
+	}
+	c.submitted.mu.Unlock()
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	captchas := make([]*CaptchaResponse, 0, len(ids))
+	for _, id := range ids {
+		submittedAt, _ := c.submitted.get(id)
+		captchas = append(captchas, c.pendingCaptcha(id, submittedAt))
+	}
+	return captchas
+}
+
+//pendingCaptcha returns a captcha known by its ID only, to be waited for
+func (c *Client) pendingCaptcha(id int64, submittedAt time.Time) *CaptchaResponse {
+	return c.describe(&CaptchaResponse{ID: id, IsCorrect: true, Status: int(StatusOK), SubmittedAt: submittedAt}, "")
+}
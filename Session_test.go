@@ -0,0 +1,67 @@
+package godbc
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	sandbox := NewSandboxTransport(SandboxConfig{Answer: "abcdef"})
+	newClient := func() *Client {
+		client := NewClient("user", "password", &ClientOptions{CaptchaRetries: 5})
+		return client.WithHTTPClient(&http.Client{Transport: sandbox})
+	}
+	ctx := context.Background()
+	before := newClient()
+	ressource, err := before.CaptchaWithOptions(ctx, benchmarkImage(t), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := before.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	after := newClient()
+	if err := after.Restore(data); err != nil {
+		t.Fatal(err)
+	}
+	if after.StatsSnapshot().Submitted != 1 {
+		t.Fatalf("the statistics were not restored: %+v", after.StatsSnapshot())
+	}
+	pending := after.PendingCaptchas()
+	if len(pending) != 1 || pending[0].ID != ressource.ID || !pending[0].SubmittedAt.Equal(ressource.SubmittedAt) {
+		t.Fatalf("unexpected pending captchas %+v", pending)
+	}
+	resolved, err := pending[0].Wait(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.Text != "abcdef" || len(after.PendingCaptchas()) != 0 {
+		t.Fatalf("unexpected response %+v", resolved)
+	}
+
+	if _, err := after.ReportCaptchaWithContext(ctx, resolved); err != nil {
+		t.Fatal(err)
+	}
+	ratio := after.ReportRatio()
+	if ratio == 0 {
+		t.Fatal("the report was not counted")
+	}
+	data, err = after.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := newClient()
+	if err := restored.Restore(data); err != nil {
+		t.Fatal(err)
+	}
+	if restored.ReportRatio() != ratio {
+		t.Fatalf("got a report ratio of %g after Restore, want %g", restored.ReportRatio(), ratio)
+	}
+
+	if err := after.Restore([]byte(`{"version": 99}`)); err != ErrSnapshotVersion {
+		t.Fatalf("got %v, want ErrSnapshotVersion", err)
+	}
+}
@@ -0,0 +1,65 @@
+package godbc
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+//counters are the client statistics, updated atomically
+type counters struct {
+	submitted    int64
+	solved       int64
+	failed       int64
+	reported     int64
+	solveLatency int64
+	rateBits     uint64
+	uploaded     int64
+	downloaded   int64
+}
+
+func (c *counters) count(eventType EventType) {
+	switch eventType {
+	case EventSubmitted:
+		atomic.AddInt64(&c.submitted, 1)
+	case EventSolved:
+		atomic.AddInt64(&c.solved, 1)
+	case EventFailed:
+		atomic.AddInt64(&c.failed, 1)
+	case EventReported:
+		atomic.AddInt64(&c.reported, 1)
+	}
+}
+
+//StatsSnapshot is a point in time copy of the client statistics, for application dashboards
+type StatsSnapshot struct {
+	Submitted int64
+	Solved    int64
+	Failed    int64
+	Reported  int64
+	//AvgLatency - average time from submission to solution of the solved captchas
+	AvgLatency time.Duration
+	//SpendEstimate - solved captchas times the last rate seen by a `user` call, 0 if no call was made
+	SpendEstimate float64
+	//BytesUploaded, BytesDownloaded - the request and response bodies of the api calls, headers excluded
+	BytesUploaded   int64
+	BytesDownloaded int64
+}
+
+//StatsSnapshot returns the client statistics, safe to call concurrently with solves
+func (c *Client) StatsSnapshot() StatsSnapshot {
+	snapshot := StatsSnapshot{
+		Submitted: atomic.LoadInt64(&c.counters.submitted),
+		Solved:    atomic.LoadInt64(&c.counters.solved),
+		Failed:    atomic.LoadInt64(&c.counters.failed),
+		Reported:  atomic.LoadInt64(&c.counters.reported),
+
+		BytesUploaded:   atomic.LoadInt64(&c.counters.uploaded),
+		BytesDownloaded: atomic.LoadInt64(&c.counters.downloaded),
+	}
+	if snapshot.Solved > 0 {
+		snapshot.AvgLatency = time.Duration(atomic.LoadInt64(&c.counters.solveLatency) / snapshot.Solved)
+	}
+	snapshot.SpendEstimate = float64(snapshot.Solved) * c.price()
+
+	return snapshot
+}
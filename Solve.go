@@ -0,0 +1,28 @@
+package godbc
+
+import (
+	"context"
+	"time"
+)
+
+/*Solve uploads an image captcha and waits for its answer. The captcha is reported and uploaded again when the service could not solve it,
+  up to ClientOptions.InvalidRetries times, and when validate rejects its answer, up to ClientOptions.ValidationRetries times
+  content: the image
+  options: may be nil
+  validate: checks the answer once it passed the PostProcessors, after the validators of its profile. May be nil
+*/
+func (c *Client) Solve(ctx context.Context, content []byte, options *CaptchaOptions, validate func(text string) error) (*CaptchaResponse, error) {
+	return c.solve(ctx, content, options, validate, nil)
+}
+
+//solve is Solve, with a progress callback for WaitCaptchaWithProgress
+func (c *Client) solve(ctx context.Context, content []byte, options *CaptchaOptions, validate func(text string) error, progress func(attempt int, elapsed time.Duration)) (*CaptchaResponse, error) {
+	ressource, err := c.CaptchaWithOptions(ctx, content, options)
+	if err != nil {
+		return nil, err
+	}
+	if validate != nil {
+		ressource.validation = ressource.validation.with(validate)
+	}
+	return c.WaitCaptchaWithProgress(ctx, ressource, progress)
+}
@@ -0,0 +1,62 @@
+package godbc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestInvalidCaptchaReport(t *testing.T) {
+	for _, policy := range []ReportPolicy{{Mode: ReportAlways}, {Mode: ReportOnValidatorFailure}} {
+		uploads, reports := 0, 0
+		client, _ := newMockClient(&ClientOptions{InvalidRetries: 1, ReportPolicy: policy}, func(req *http.Request, body []byte) *http.Response {
+			switch {
+			case strings.HasSuffix(req.URL.Path, "/report"):
+				reports++
+				return mockResponse(200, `{"captcha": 1, "is_correct": false, "text": "", "status": 0}`)
+			case req.Method == `POST`:
+				uploads++
+				return mockResponse(200, `{"captcha": `+strconv.Itoa(uploads)+`, "is_correct": true, "text": "", "status": 0}`)
+			case strings.HasSuffix(req.URL.Path, "/1"):
+				return mockResponse(200, `{"captcha": 1, "is_correct": false, "text": "", "status": 0}`)
+			}
+			return mockResponse(200, `{"captcha": 2, "is_correct": true, "text": "abc", "status": 0}`)
+		})
+
+		solved, err := client.Solve(context.Background(), benchmarkImage(t), nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if solved.Text != "abc" || uploads != 2 {
+			t.Fatalf("got %+v after %d uploads, want the invalid captcha submitted again", solved, uploads)
+		}
+		if want := map[ReportMode]int{ReportAlways: 1, ReportOnValidatorFailure: 0}[policy.Mode]; reports != want {
+			t.Fatalf("mode %d: sent %d reports, want %d: the captcha was not rejected by a validator", policy.Mode, reports, want)
+		}
+	}
+}
+
+func TestSolveValidate(t *testing.T) {
+	uploads := 0
+	client, _ := newMockClient(&ClientOptions{InvalidRetries: 3, ValidationRetries: 1}, func(req *http.Request, body []byte) *http.Response {
+		switch {
+		case strings.HasSuffix(req.URL.Path, "/report"):
+			return mockResponse(200, `{"captcha": 1, "is_correct": false, "text": "", "status": 0}`)
+		case req.Method == `POST`:
+			uploads++
+			return mockResponse(200, `{"captcha": `+strconv.Itoa(uploads)+`, "is_correct": true, "text": "", "status": 0}`)
+		}
+		return mockResponse(200, `{"captcha": 1, "is_correct": true, "text": "abc", "status": 0}`)
+	})
+	rejected := errors.New("rejected")
+	_, err := client.Solve(context.Background(), benchmarkImage(t), nil, func(text string) error { return rejected })
+	if err != rejected {
+		t.Fatalf("got %v, want the error of validate", err)
+	}
+	if uploads != 2 {
+		t.Fatalf("uploaded %d times, want the captcha uploaded again once", uploads)
+	}
+}
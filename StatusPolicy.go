@@ -0,0 +1,89 @@
+package godbc
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+//defaultRateLimitBackoff is how long the Limiter holds calls back on a 429 without a Retry-After header
+const defaultRateLimitBackoff = 5 * time.Second
+
+//StatusAction is how the client handles an HTTP status of the api
+type StatusAction int
+
+const (
+	//StatusDefault - the built-in handling of the status
+	StatusDefault StatusAction = iota
+	//StatusParseBody - the body is decoded as an api response, its status field decides the error
+	StatusParseBody
+	//StatusRetry - the call fails with a retryable *HTTPStatusError, honoring a Retry-After header, see IsRetryable
+	StatusRetry
+	//StatusFail - the call fails with the rule's Err, a permanent *HTTPStatusError if nil
+	StatusFail
+)
+
+//StatusRule is the handling of an HTTP status
+type StatusRule struct {
+	Action StatusAction
+	//Err - the error of StatusFail, may be nil
+	Err error
+}
+
+/*StatusPolicy maps HTTP statuses to their handling, e.g. {502: {Action: StatusRetry}} behind a gateway answering 502 when the service is slow
+  Statuses missing from the policy keep the built-in handling
+*/
+type StatusPolicy map[int]StatusRule
+
+//HTTPStatusError is an HTTP status the StatusPolicy turned into an error
+type HTTPStatusError struct {
+	StatusCode int
+	//Retryable - the status was mapped to StatusRetry
+	Retryable bool
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("Unexpected HTTP status %d (%s)", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+//statusError returns the error of an api response's status, nil when its body should be decoded
+func (c *Client) statusError(request *http.Request, resp *http.Response) error {
+	if rule, ok := c.opts().StatusPolicy[resp.StatusCode]; ok && rule.Action != StatusDefault {
+		switch rule.Action {
+		case StatusRetry:
+			return withRetryAfter(&HTTPStatusError{StatusCode: resp.StatusCode, Retryable: true}, resp.Header)
+		case StatusFail:
+			if rule.Err != nil {
+				return rule.Err
+			}
+			return &HTTPStatusError{StatusCode: resp.StatusCode}
+		}
+		return nil
+	}
+
+	switch resp.StatusCode {
+	case 403:
+		return ErrCredentialsRejected
+	case 400:
+		return ErrCaptchaRejected
+	case 404:
+		return notFound(request.Context())
+	case 413:
+		return ErrPayloadTooLarge
+	case 429:
+		err := withRetryAfter(ErrRateLimited, resp.Header)
+		if limiter, ok := c.opts().Limiter.(LimiterBackoff); ok {
+			backoff := RetryAfter(err)
+			if backoff <= 0 {
+				backoff = defaultRateLimitBackoff
+			}
+			limiter.Backoff(time.Now().Add(backoff))
+		}
+		return err
+	case 500:
+		return ErrUnexpectedServerError
+	case 503:
+		return withRetryAfter(unavailable(request.Context()), resp.Header)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package godbc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestStatusPolicy(t *testing.T) {
+	gatewayDown := errors.New("gateway down")
+	policy := StatusPolicy{
+		502: {Action: StatusRetry},
+		504: {Action: StatusFail},
+		418: {Action: StatusFail, Err: gatewayDown},
+		500: {Action: StatusParseBody},
+	}
+	cases := []struct {
+		statusCode int
+		check      func(err error) bool
+	}{
+		{502, func(err error) bool { return IsRetryable(err) && RetryAfter(err) == 3*time.Second }},
+		{504, func(err error) bool {
+			var statusErr *HTTPStatusError
+			return errors.As(err, &statusErr) && statusErr.StatusCode == 504 && !IsRetryable(err)
+		}},
+		{418, func(err error) bool { return err == gatewayDown }},
+		{500, func(err error) bool { return IsCredentialError(err) }},
+		{403, func(err error) bool { return err == ErrCredentialsRejected }},
+	}
+	for _, tc := range cases {
+		client, _ := newMockClient(&ClientOptions{StatusPolicy: policy}, func(req *http.Request, body []byte) *http.Response {
+			return mockResponse(tc.statusCode, `{"status": 255, "error": "not-logged-in"}`, "Retry-After", "3")
+		})
+		_, err := client.StatusWithContext(context.Background())
+		if !tc.check(err) {
+			t.Errorf("status %d: unexpected error %v", tc.statusCode, err)
+		}
+	}
+}
+
+//backoffLimiter records the backoffs asked by the client
+type backoffLimiter struct {
+	until time.Time
+}
+
+func (l *backoffLimiter) Wait(ctx context.Context) error {
+	return nil
+}
+
+func (l *backoffLimiter) Backoff(until time.Time) {
+	l.until = until
+}
+
+func TestRateLimitBackoff(t *testing.T) {
+	for retryAfter, want := range map[string]time.Duration{"": defaultRateLimitBackoff, "30": 30 * time.Second} {
+		limiter := &backoffLimiter{}
+		client, _ := newMockClient(&ClientOptions{Limiter: limiter}, func(req *http.Request, body []byte) *http.Response {
+			if retryAfter == "" {
+				return mockResponse(429, "")
+			}
+			return mockResponse(429, "", "Retry-After", retryAfter)
+		})
+		start := time.Now()
+		if _, err := client.StatusWithContext(context.Background()); !errors.Is(err, ErrRateLimited) {
+			t.Fatalf("got %v, want ErrRateLimited", err)
+		}
+		if backoff := limiter.until.Sub(start); backoff < want-time.Second || backoff > want+time.Second {
+			t.Errorf("Retry-After %q: backed off for %s, want %s", retryAfter, backoff, want)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package godbc
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strconv"
+)
+
+//ErrNoTiles - Stitch was given no tiles
+var ErrNoTiles = errors.New("No tiles to stitch")
+
+/*StitchLayout is how tiles are laid out by Stitch
+  Columns: tiles per row, the columns of the smallest square grid holding the tiles if 0
+  Gap: pixels left between the tiles
+  Background: fills the gaps and the cells around smaller tiles, white if nil
+*/
+type StitchLayout struct {
+	Columns    int
+	Gap        int
+	Background color.Color
+}
+
+//Stitched is tiles composed into one grid image by Stitch
+type Stitched struct {
+	//Image - the PNG encoded grid
+	Image []byte
+	//Rows, Columns - the layout of the grid
+	Rows    int
+	Columns int
+	//Count - the number of tiles, the last row may have empty cells
+	Count int
+}
+
+//Grid returns the layout of the image as columns x rows, as sent with image group captchas, see ParseGrid
+func (s *Stitched) Grid() string {
+	return strconv.Itoa(s.Columns) + "x" + strconv.Itoa(s.Rows)
+}
+
+//Tile returns the index in the stitched tiles of a cell numbered by the service, from 1 at the top left cell row by row. False for an empty cell
+func (s *Stitched) Tile(cell int) (int, bool) {
+	if cell < 1 || cell > s.Count {
+		return 0, false
+	}
+	return cell - 1, true
+}
+
+//Tiles maps the cells of a selection to the indexes of the stitched tiles, dropping empty cells
+func (s *Stitched) Tiles(selection []int) []int {
+	tiles := make([]int, 0, len(selection))
+	for _, cell := range selection {
+		if tile, ok := s.Tile(cell); ok {
+			tiles = append(tiles, tile)
+		}
+	}
+	return tiles
+}
+
+/*Stitch composes tile images into a single grid image, each tile in a cell the size of the largest tile
+  Tiles are laid out row by row, in their order. Returns ErrInvalidFormat for a tile that can not be decoded,
+  ErrImageDimensions when a tile or the grid is over MaxImageDimension,
+  ErrInvalidGrid when the layout has more than 8 rows or columns, as the service does not take larger grids
+*/
+func Stitch(tiles [][]byte, layout StitchLayout) (*Stitched, error) {
+	if len(tiles) == 0 {
+		return nil, ErrNoTiles
+	}
+	//the layout is sized from the headers of the tiles, so no tile is decoded before the grid is known to be within bounds
+	cellWidth, cellHeight := 0, 0
+	for _, tile := range tiles {
+		info, err := InspectImage(tile)
+		if err != nil {
+			return nil, err
+		}
+		if info.Width > cellWidth {
+			cellWidth = info.Width
+		}
+		if info.Height > cellHeight {
+			cellHeight = info.Height
+		}
+	}
+
+	columns := layout.Columns
+	if columns <= 0 {
+		columns = int(math.Ceil(math.Sqrt(float64(len(tiles)))))
+	}
+	if columns > len(tiles) {
+		columns = len(tiles)
+	}
+	rows := (len(tiles) + columns - 1) / columns
+	if columns > maxGridSide || rows > maxGridSide {
+		return nil, ErrInvalidGrid
+	}
+	gap := layout.Gap
+	if gap < 0 {
+		gap = 0
+	}
+	if gap > MaxImageDimension {
+		return nil, ErrImageDimensions
+	}
+	width := columns*cellWidth + (columns-1)*gap
+	height := rows*cellHeight + (rows-1)*gap
+	if width > MaxImageDimension || height > MaxImageDimension {
+		return nil, ErrImageDimensions
+	}
+
+	decoded := make([]image.Image, len(tiles))
+	for i, tile := range tiles {
+		img, _, err := image.Decode(bytes.NewReader(tile))
+		if err != nil {
+			return nil, ErrInvalidFormat
+		}
+		decoded[i] = img
+	}
+
+	background := layout.Background
+	if background == nil {
+		background = color.White
+	}
+	grid := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(grid, grid.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	for i, img := range decoded {
+		bounds := img.Bounds()
+		//smaller tiles are centered in their cell
+		x := (i%columns)*(cellWidth+gap) + (cellWidth-bounds.Dx())/2
+		y := (i/columns)*(cellHeight+gap) + (cellHeight-bounds.Dy())/2
+		draw.Draw(grid, image.Rect(x, y, x+bounds.Dx(), y+bounds.Dy()), img, bounds.Min, draw.Over)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, grid); err != nil {
+		return nil, err
+	}
+	return &Stitched{Image: buf.Bytes(), Rows: rows, Columns: columns, Count: len(tiles)}, nil
+}
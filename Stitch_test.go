@@ -0,0 +1,118 @@
+package godbc
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func stitchTile(t *testing.T, shade uint8, size int) []byte {
+	img := image.NewGray(image.Rect(0, 0, size, size))
+	for i := range img.Pix {
+		img.Pix[i] = shade
+	}
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestStitch(t *testing.T) {
+	if _, err := Stitch(nil, StitchLayout{}); err != ErrNoTiles {
+		t.Fatalf("got %v, want ErrNoTiles", err)
+	}
+	if _, err := Stitch([][]byte{[]byte("not an image")}, StitchLayout{}); err != ErrInvalidFormat {
+		t.Fatalf("got %v, want ErrInvalidFormat", err)
+	}
+	small := [][]byte{}
+	for i := 0; i < 9; i++ {
+		small = append(small, stitchTile(t, 0, 16))
+	}
+	if _, err := Stitch(small, StitchLayout{Columns: 9}); err != ErrInvalidGrid {
+		t.Fatalf("got %v, want ErrInvalidGrid for 9 columns", err)
+	}
+	if _, err := Stitch(small, StitchLayout{Columns: 1}); err != ErrInvalidGrid {
+		t.Fatalf("got %v, want ErrInvalidGrid for 9 rows", err)
+	}
+
+	tiles := [][]byte{}
+	for i := 0; i < 5; i++ {
+		tiles = append(tiles, stitchTile(t, uint8(i*40), 40))
+	}
+	stitched, err := Stitch(tiles, StitchLayout{Columns: 3, Gap: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stitched.Grid() != "3x2" || stitched.Count != 5 {
+		t.Fatalf("unexpected layout %s of %d tiles", stitched.Grid(), stitched.Count)
+	}
+	img, err := png.Decode(bytes.NewReader(stitched.Image))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != 124 || bounds.Dy() != 82 {
+		t.Fatalf("unexpected size %v", bounds)
+	}
+	for i := 0; i < 5; i++ {
+		x, y := (i%3)*42+20, (i/3)*42+20
+		if gray := color.GrayModel.Convert(img.At(x, y)).(color.Gray); gray.Y != uint8(i*40) {
+			t.Errorf("tile %d: unexpected shade %d", i, gray.Y)
+		}
+	}
+	if gray := color.GrayModel.Convert(img.At(104, 62)).(color.Gray); gray.Y != 255 {
+		t.Errorf("empty cell is not white: %d", gray.Y)
+	}
+	if got := stitched.Tiles([]int{1, 5, 6, 0}); !reflect.DeepEqual(got, []int{0, 4}) {
+		t.Fatalf("unexpected tiles %v", got)
+	}
+
+	client, transport := newMockClient(nil, func(req *http.Request, body []byte) *http.Response {
+		if req.Method == http.MethodPost {
+			return mockResponse(200, `{"captcha": 42, "is_correct": true, "text": "", "status": 0}`)
+		}
+		return mockResponse(200, `{"captcha": 42, "is_correct": true, "text": "[2,4]", "status": 0}`)
+	})
+	result, err := client.ImageGroup(context.Background(), ImageGroupRequest{Tiles: tiles, BannerText: "Select all buses"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result.Tiles, []int{1, 3}) {
+		t.Fatalf("unexpected tiles %v", result.Tiles)
+	}
+	if upload := transport.bodies[0]; !bytes.Contains(upload, []byte("3x2")) {
+		t.Errorf("the grid of the stitched tiles was not sent")
+	}
+
+	//the grid sent reads back as the layout the cells were numbered in
+	columns, rows, err := ParseGrid(stitched.Grid())
+	if err != nil || columns != stitched.Columns || rows != stitched.Rows {
+		t.Fatalf("the grid %s reads as %d columns and %d rows", stitched.Grid(), columns, rows)
+	}
+}
+
+func TestStitchBounds(t *testing.T) {
+	//an IHDR declaring a 60000 pixels wide tile, with its checksum fixed
+	huge := stitchTile(t, 0, 16)
+	binary.BigEndian.PutUint32(huge[16:20], 60000)
+	binary.BigEndian.PutUint32(huge[29:33], crc32.ChecksumIEEE(huge[12:29]))
+	if _, err := Stitch([][]byte{huge}, StitchLayout{}); err != ErrImageDimensions {
+		t.Fatalf("got %v, want ErrImageDimensions for a huge tile", err)
+	}
+
+	large := stitchTile(t, 0, 1100)
+	if _, err := Stitch([][]byte{large, large, large, large}, StitchLayout{Columns: 4}); err != ErrImageDimensions {
+		t.Fatalf("got %v, want ErrImageDimensions for a huge grid", err)
+	}
+	if _, err := Stitch([][]byte{stitchTile(t, 0, 16), stitchTile(t, 0, 16)}, StitchLayout{Gap: math.MaxInt64 / 2}); err != ErrImageDimensions {
+		t.Fatalf("got %v, want ErrImageDimensions for a huge gap", err)
+	}
+}
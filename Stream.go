@@ -0,0 +1,54 @@
+package godbc
+
+import (
+	"io"
+	"sync"
+)
+
+//streamBody is a request body written on the fly through a pipe, by a goroutine started on the first read
+type streamBody struct {
+	write func(w io.Writer) error
+
+	once   sync.Once
+	reader *io.PipeReader
+}
+
+func newStreamBody(write func(w io.Writer) error) *streamBody {
+	return &streamBody{write: write}
+}
+
+func (b *streamBody) start() {
+	reader, writer := io.Pipe()
+	b.reader = reader
+	go func() {
+		writer.CloseWithError(b.write(writer))
+	}()
+}
+
+//Read starts writing the body on the first call
+func (b *streamBody) Read(p []byte) (int, error) {
+	b.once.Do(b.start)
+	if b.reader == nil {
+		return 0, io.ErrClosedPipe
+	}
+	return b.reader.Read(p)
+}
+
+//Close stops the writing goroutine, if it was started
+func (b *streamBody) Close() error {
+	b.once.Do(func() {})
+	if b.reader != nil {
+		return b.reader.Close()
+	}
+	return nil
+}
+
+//countingWriter counts the bytes written to it, to size a streamed body
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,133 @@
+package godbc
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"sync"
+	"time"
+)
+
+//TokenLifetime is how long a solved token (e.g. a g-recaptcha-response) stays valid
+const TokenLifetime = 120 * time.Second
+
+//Error codes returned by Consume
+var (
+	//ErrTokenExpired - The solved token expired before it was consumed
+	ErrTokenExpired = errors.New("Token has expired")
+	//ErrTokenAlreadyConsumed - The token was already handed out by Consume, tokens are single-use
+	ErrTokenAlreadyConsumed = errors.New("Token was already consumed")
+)
+
+//tokenSubmission is what was sent to get a token, so an expired token can be solved again
+type tokenSubmission struct {
+	captchaType CaptchaType
+	params      interface{}
+}
+
+//tokenLedger remembers the tokens handed out, for as long as they could still be valid
+type tokenLedger struct {
+	mu sync.Mutex
+	at map[[sha256.Size]byte]time.Time
+	//order - the tokens in the order they were claimed, so the expired ones are dropped from its front
+	order []tokenClaim
+}
+
+type tokenClaim struct {
+	key [sha256.Size]byte
+	at  time.Time
+}
+
+//claim records a token as consumed, false if it already was. Only the hash of the token is kept
+func (l *tokenLedger) claim(token string, at time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.at == nil {
+		l.at = map[[sha256.Size]byte]time.Time{}
+	}
+	expired := 0
+	for _, claimed := range l.order {
+		if at.Sub(claimed.at) < TokenLifetime {
+			break
+		}
+		delete(l.at, claimed.key)
+		expired++
+	}
+	l.order = l.order[expired:]
+
+	key := sha256.Sum256([]byte(token))
+	if _, ok := l.at[key]; ok {
+		return false
+	}
+	l.at[key] = at
+	l.order = append(l.order, tokenClaim{key: key, at: at})
+	return true
+}
+
+//IsExpired returns true if the captcha is a solved token that is no longer valid
+func (r *CaptchaResponse) IsExpired() bool {
+	return !r.ExpiresAt.IsZero() && !time.Now().Before(r.ExpiresAt)
+}
+
+//submitToken submits a token captcha, remembering the submission on the response
+func (c *Client) submitToken(ctx context.Context, captchaType CaptchaType, params interface{}) (*CaptchaResponse, error) {
+	ctx = withCaptchaType(c.correlate(ctx, ""), captchaType.String())
+	req, err := c.BuildTokenRequest(ctx, int(captchaType), params)
+	if err != nil {
+		return nil, c.correlateError(ctx, 0, err)
+	}
+
+	response, err := c.submit(ctx, req)
+	if err != nil {
+		return nil, c.correlateError(ctx, 0, err)
+	}
+	response.token = &tokenSubmission{captchaType: captchaType, params: params}
+	response.resubmit = func(ctx context.Context) (*CaptchaResponse, error) {
+		return c.submitToken(ctx, captchaType, params)
+	}
+	c.audit(ctx, AuditSubmit, response, nil, nil)
+
+	return response, nil
+}
+
+/*SolveToken submits a token captcha of any type and waits for its token, for the types this package does not model yet
+  params: the token parameters, marshalled to JSON, sent as described by the type's spec when it is registered, see RegisterType
+*/
+func (c *Client) SolveToken(ctx context.Context, captchaType CaptchaType, params interface{}) (*CaptchaResponse, error) {
+	ressource, err := c.submitToken(ctx, captchaType, params)
+	if err != nil {
+		return nil, err
+	}
+	return c.WaitCaptchaWithContext(ctx, ressource)
+}
+
+/*Consume returns the solved token to be used now
+  If the token has expired, it is solved again when the client has AutoResolve set, otherwise ErrTokenExpired is returned
+  A token is handed out once: ErrTokenAlreadyConsumed is returned when the same token is consumed again through this client
+*/
+func (c *Client) Consume(ctx context.Context, resolved *CaptchaResponse) (*CaptchaResponse, error) {
+	if !resolved.IsExpired() {
+		return c.claim(resolved)
+	}
+	if !c.opts().AutoResolve || resolved.token == nil {
+		return nil, ErrTokenExpired
+	}
+
+	ressource, err := c.submitToken(ctx, resolved.token.captchaType, resolved.token.params)
+	if err != nil {
+		return nil, err
+	}
+	fresh, err := c.WaitCaptchaWithContext(ctx, ressource)
+	if err != nil {
+		return nil, err
+	}
+	return c.claim(fresh)
+}
+
+//claim marks a token as consumed, ErrTokenAlreadyConsumed is returned if it already was
+func (c *Client) claim(resolved *CaptchaResponse) (*CaptchaResponse, error) {
+	if !c.consumed.claim(resolved.Text, time.Now()) {
+		return nil, ErrTokenAlreadyConsumed
+	}
+	return resolved, nil
+}
@@ -0,0 +1,119 @@
+package godbc
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+//tokenMinValidity is the minimum validity left on a token handed out by a TokenPool
+const tokenMinValidity = 20 * time.Second
+
+//TokenPool keeps recaptcha tokens solved in advance for one page, so they can be handed out instantly
+type TokenPool struct {
+	client  *Client
+	payload RecaptchaRequestPayload
+	tokens  chan *CaptchaResponse
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+
+	mu      sync.Mutex
+	lastErr error
+	//handedOut - the tokens returned by Get, apart from the client's ledger so they can still be consumed once
+	handedOut tokenLedger
+}
+
+/*NewTokenPool starts a pool keeping size tokens solved for the given recaptcha payload
+  Tokens about to expire are discarded and solved again. The pool runs until Close is called
+*/
+func NewTokenPool(client *Client, payload RecaptchaRequestPayload, size int) *TokenPool {
+	if size < 1 {
+		size = 1
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &TokenPool{
+		client:  client,
+		payload: payload,
+		tokens:  make(chan *CaptchaResponse),
+		cancel:  cancel,
+	}
+	for i := 0; i < size; i++ {
+		p.wg.Add(1)
+		go p.keepFresh(ctx)
+	}
+
+	return p
+}
+
+//keepFresh solves a token, offers it until it is taken or about to expire, and starts again
+func (p *TokenPool) keepFresh(ctx context.Context) {
+	defer p.wg.Done()
+	for ctx.Err() == nil {
+		token, err := p.solve(ctx)
+		if err != nil {
+			p.mu.Lock()
+			p.lastErr = err
+			p.mu.Unlock()
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(p.backoff(err)):
+			}
+			continue
+		}
+
+		stale := time.NewTimer(time.Until(token.ExpiresAt) - tokenMinValidity)
+		select {
+		case p.tokens <- token:
+		case <-stale.C:
+		case <-ctx.Done():
+		}
+		stale.Stop()
+	}
+}
+
+func (p *TokenPool) solve(ctx context.Context) (*CaptchaResponse, error) {
+	ressource, err := p.client.RecaptchaWithPayload(ctx, p.payload)
+	if err != nil {
+		return nil, err
+	}
+	return p.client.WaitCaptchaWithContext(ctx, ressource)
+}
+
+//Get returns a solved token, waiting for one if none is ready. A token is never returned twice by the pool, and is then consumed by the caller, see Client.Consume
+func (p *TokenPool) Get(ctx context.Context) (*CaptchaResponse, error) {
+	for {
+		select {
+		case token := <-p.tokens:
+			if token.IsExpired() {
+				continue
+			}
+			if !p.handedOut.claim(token.Text, time.Now()) {
+				continue
+			}
+			return token, nil
+		case <-ctx.Done():
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			if p.lastErr != nil {
+				return nil, p.lastErr
+			}
+			return nil, ctx.Err()
+		}
+	}
+}
+
+//Close stops solving tokens, tokens not handed out are dropped
+func (p *TokenPool) Close() {
+	p.cancel()
+	p.wg.Wait()
+}
+
+//backoff returns how long to wait after a failed solve
+func (p *TokenPool) backoff(err error) time.Duration {
+	if wait := RetryAfter(err); wait > time.Second {
+		return wait
+	}
+	return time.Second
+}
@@ -0,0 +1,60 @@
+package godbc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTokenReplay(t *testing.T) {
+	client := newSandboxClient(SandboxConfig{})
+	ctx := context.Background()
+	payload := RecaptchaRequestPayload{PageURL: "https://example.com", GoogleKey: "key"}
+	resolved, err := client.SolveToken(ctx, TypeRecaptchaV2, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Consume(ctx, resolved); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Consume(ctx, resolved); err != ErrTokenAlreadyConsumed {
+		t.Fatalf("got %v, want ErrTokenAlreadyConsumed", err)
+	}
+
+	pool := NewTokenPool(client, payload, 2)
+	defer pool.Close()
+	first, err := pool.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := pool.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Text == second.Text {
+		t.Fatalf("the pool handed out %s twice", first.Text)
+	}
+	if _, err := client.Consume(ctx, first); err != nil {
+		t.Fatalf("got %v, want a token from the pool consumed once", err)
+	}
+	if _, err := client.Consume(ctx, first); err != ErrTokenAlreadyConsumed {
+		t.Fatalf("got %v, want ErrTokenAlreadyConsumed", err)
+	}
+}
+
+func TestTokenLedgerExpiry(t *testing.T) {
+	ledger := &tokenLedger{}
+	now := time.Now()
+	if !ledger.claim("old", now) || !ledger.claim("new", now.Add(TokenLifetime/2)) {
+		t.Fatal("a new token was refused")
+	}
+	if ledger.claim("new", now.Add(TokenLifetime)) {
+		t.Fatal("a valid token was claimed twice")
+	}
+	if len(ledger.at) != 1 || len(ledger.order) != 1 {
+		t.Fatalf("kept %d tokens, want the expired one dropped", len(ledger.at))
+	}
+	if !ledger.claim("old", now.Add(TokenLifetime)) {
+		t.Fatal("an expired token is still remembered")
+	}
+}
@@ -0,0 +1,164 @@
+package godbc
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sync"
+	"time"
+)
+
+//RequestTrace is the timing of an api call, to find where slow solves spend their time
+type RequestTrace struct {
+	Method string
+	//URL - the url of the call, without the credentials
+	URL string
+	//Route - the name of the route of the call, empty for requests built outside the client
+	Route         string
+	CorrelationID string
+	//Reused - the call went through a kept-alive connection, DNS, Connect and TLS are then 0
+	Reused  bool
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	//TTFB - from the start of the call to the first byte of the response
+	TTFB time.Duration
+	//Total - from the start of the call to the end of the response body
+	Total      time.Duration
+	StatusCode int
+	Header     http.Header
+	Err        error
+}
+
+//TracedError is the error of an api call with the trace of the call, see ClientOptions.TraceErrors
+type TracedError struct {
+	Trace *RequestTrace
+	Err   error
+}
+
+func (e *TracedError) Error() string {
+	return fmt.Sprintf("%s (%s %s, %d after %s)", e.Err.Error(), e.Trace.Method, e.Trace.URL, e.Trace.StatusCode, e.Trace.Total)
+}
+
+//Unwrap returns the error of the call
+func (e *TracedError) Unwrap() error {
+	return e.Err
+}
+
+//TraceOf returns the trace an error is tagged with, nil if none
+func TraceOf(err error) *RequestTrace {
+	var traced *TracedError
+	if errors.As(err, &traced) {
+		return traced.Trace
+	}
+	return nil
+}
+
+type traceKey struct{}
+
+//WithRequestTrace returns a context whose api calls are traced to fn, on top of ClientOptions.OnRequestTrace
+func WithRequestTrace(ctx context.Context, fn func(RequestTrace)) context.Context {
+	return context.WithValue(ctx, traceKey{}, fn)
+}
+
+//requestTracer collects the trace of a call from its httptrace hooks
+type requestTracer struct {
+	mu    sync.Mutex
+	start time.Time
+	trace RequestTrace
+
+	dnsStart, connectStart, tlsStart time.Time
+}
+
+//traceRequest returns the request with hooks tracing it, and the tracer. The tracer is nil when the call is not traced
+func (c *Client) traceRequest(request *http.Request) (*http.Request, *requestTracer) {
+	_, traced := request.Context().Value(traceKey{}).(func(RequestTrace))
+	if !traced && c.opts().OnRequestTrace == nil && !c.opts().TraceErrors {
+		return request, nil
+	}
+
+	t := &requestTracer{start: time.Now(), trace: RequestTrace{
+		Method:        request.Method,
+		URL:           redactURL(request.URL),
+		CorrelationID: CorrelationIDFrom(request.Context()),
+	}}
+	if route := routeFrom(request.Context()); route != nil {
+		t.trace.Route = route.Name
+	}
+	ctx := httptrace.WithClientTrace(request.Context(), &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { t.mark(&t.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { t.since(&t.trace.DNS, t.dnsStart) },
+		ConnectStart: func(network, addr string) {
+			t.mark(&t.connectStart)
+		},
+		ConnectDone: func(network, addr string, err error) { t.since(&t.trace.Connect, t.connectStart) },
+		TLSHandshakeStart: func() {
+			t.mark(&t.tlsStart)
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) { t.since(&t.trace.TLS, t.tlsStart) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.trace.Reused = info.Reused
+			t.mu.Unlock()
+		},
+		GotFirstResponseByte: func() { t.since(&t.trace.TTFB, t.start) },
+	})
+	return request.WithContext(ctx), t
+}
+
+func (t *requestTracer) mark(at *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	*at = time.Now()
+}
+
+func (t *requestTracer) since(d *time.Duration, start time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !start.IsZero() {
+		*d = time.Since(start)
+	}
+}
+
+//finish completes the trace once the body was read, delivers it, and tags the error with it when the client has TraceErrors set
+func (t *requestTracer) finish(c *Client, request *http.Request, statusCode int, header http.Header, err error) error {
+	if t == nil {
+		return err
+	}
+	t.mu.Lock()
+	trace := t.trace
+	t.mu.Unlock()
+	trace.Total = time.Since(t.start)
+	trace.StatusCode = statusCode
+	trace.Header = header
+	trace.Err = err
+
+	if fn, ok := request.Context().Value(traceKey{}).(func(RequestTrace)); ok {
+		fn(trace)
+	}
+	if fn := c.opts().OnRequestTrace; fn != nil {
+		fn(trace)
+	}
+	if err != nil && c.opts().TraceErrors {
+		return &TracedError{Trace: &trace, Err: err}
+	}
+	return err
+}
+
+//redactURL returns the url without the credentials of its query
+func redactURL(u *url.URL) string {
+	redacted := *u
+	query := redacted.Query()
+	for _, key := range []string{"username", "password", "authtoken"} {
+		if query.Get(key) != "" {
+			query.Set(key, cassetteRedacted)
+		}
+	}
+	redacted.RawQuery = query.Encode()
+	redacted.User = nil
+	return redacted.String()
+}
@@ -0,0 +1,35 @@
+package godbc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRequestTrace(t *testing.T) {
+	var traces []RequestTrace
+	client, _ := newMockClient(&ClientOptions{TraceErrors: true, OnRequestTrace: func(trace RequestTrace) {
+		traces = append(traces, trace)
+	}}, func(req *http.Request, body []byte) *http.Response {
+		return mockResponse(403, "", "X-Served-By", "edge-1")
+	})
+
+	perCall := 0
+	ctx := WithRequestTrace(context.Background(), func(RequestTrace) { perCall++ })
+	_, err := client.UserWithContext(ctx)
+	if !errors.Is(err, ErrCredentialsRejected) {
+		t.Fatalf("got %v, want ErrCredentialsRejected", err)
+	}
+	trace := TraceOf(err)
+	if trace == nil || len(traces) != 1 || perCall != 1 {
+		t.Fatalf("the call was not traced: %v, %d traces, %d per call", err, len(traces), perCall)
+	}
+	if trace.Route != "user" || trace.StatusCode != 403 || trace.Header.Get("X-Served-By") != "edge-1" || trace.Total <= 0 {
+		t.Fatalf("unexpected trace %+v", trace)
+	}
+	if strings.Contains(trace.URL, "password=password") {
+		t.Fatalf("the credentials were traced: %s", trace.URL)
+	}
+}
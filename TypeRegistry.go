@@ -0,0 +1,152 @@
+package godbc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+//Error codes returned for registered captcha types
+var (
+	//ErrUnknownType - No captcha type is registered with this code, see RegisterType
+	ErrUnknownType = errors.New("Captcha type is not registered")
+	//ErrInvalidParams - The parameters do not match the schema of their captcha type
+	ErrInvalidParams = errors.New("Captcha parameters are invalid")
+)
+
+//ParamsError is returned when the parameters of a captcha miss a field its type requires, it matches ErrInvalidParams with errors.Is
+type ParamsError struct {
+	Type  CaptchaType
+	Field string
+}
+
+func (e *ParamsError) Error() string {
+	return fmt.Sprintf("Captcha parameters are invalid: type %d requires %s", e.Type, e.Field)
+}
+
+//Unwrap returns ErrInvalidParams
+func (e *ParamsError) Unwrap() error {
+	return ErrInvalidParams
+}
+
+/*TypeSpec describes a captcha type solved from JSON parameters, so new kinds can be solved with SolveType without changes to this package
+  Type: the api type code
+  Name: a readable name, e.g. "turnstile"
+  ParamsField: the form field the parameters are sent in, "token_params" if empty
+  Required: the fields the JSON parameters must have, checked before submission
+  Parse: decodes the text of the solved captcha into the value of SolveType, may be nil to keep the text
+*/
+type TypeSpec struct {
+	Type        CaptchaType
+	Name        string
+	ParamsField string
+	Required    []string
+	Parse       func(text string) (interface{}, error)
+}
+
+//TypedResult is a captcha solved with SolveType
+type TypedResult struct {
+	*CaptchaResponse
+	//Value - the text decoded by the type's Parse, the text itself without one
+	Value interface{}
+}
+
+var typeTable = struct {
+	sync.RWMutex
+	types map[CaptchaType]*TypeSpec
+}{types: map[CaptchaType]*TypeSpec{}}
+
+func init() {
+	for _, spec := range []*TypeSpec{
+		{Type: TypeRecaptchaV2, Name: "recaptcha-v2", Required: []string{"googlekey", "pageurl"}},
+		{Type: TypeRecaptchaV3, Name: "recaptcha-v3", Required: []string{"googlekey", "pageurl"}},
+		{Type: TypeFuncaptcha, Name: "funcaptcha", ParamsField: "funcaptcha_params", Required: []string{"publickey", "pageurl"}},
+		{Type: TypeHcaptcha, Name: "hcaptcha", ParamsField: "hcaptcha_params", Required: []string{"sitekey", "pageurl"}},
+		{Type: TypeGeetest, Name: "geetest", ParamsField: "geetest_params", Required: []string{"gt", "challenge", "pageurl"}},
+		{Type: TypeGeetestV4, Name: "geetest-v4", ParamsField: "geetest_params", Required: []string{"captcha_id", "pageurl"}},
+		{Type: TypeTurnstile, Name: "turnstile", ParamsField: "turnstile_params", Required: []string{"sitekey", "pageurl"}},
+		{Type: TypeAmazonWAF, Name: "amazon-waf", ParamsField: "waf_params", Required: []string{"sitekey", "pageurl"}},
+	} {
+		RegisterType(spec)
+	}
+}
+
+//RegisterType adds a captcha type to the registry, replacing the spec of the same type code
+func RegisterType(spec *TypeSpec) {
+	typeTable.Lock()
+	defer typeTable.Unlock()
+	typeTable.types[spec.Type] = spec
+}
+
+//lookupTypeNamed returns the spec registered under a name, nil if none was
+func lookupTypeNamed(name string) *TypeSpec {
+	typeTable.RLock()
+	defer typeTable.RUnlock()
+	for _, spec := range typeTable.types {
+		if spec.Name == name {
+			return spec
+		}
+	}
+	return nil
+}
+
+//LookupType returns the spec of a captcha type, nil if none was registered
+func LookupType(captchaType CaptchaType) *TypeSpec {
+	typeTable.RLock()
+	defer typeTable.RUnlock()
+	return typeTable.types[captchaType]
+}
+
+//paramsField returns the form field the parameters are sent in
+func (s *TypeSpec) paramsField() string {
+	if s == nil || s.ParamsField == "" {
+		return "token_params"
+	}
+	return s.ParamsField
+}
+
+//validate checks the parameters have the fields the type requires
+func (s *TypeSpec) validate(params interface{}) error {
+	if s == nil || len(s.Required) == 0 {
+		return nil
+	}
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return &ParamsError{Type: s.Type, Field: "a JSON object"}
+	}
+	for _, field := range s.Required {
+		if value, ok := fields[field]; !ok || value == nil || value == "" {
+			return &ParamsError{Type: s.Type, Field: field}
+		}
+	}
+	return nil
+}
+
+/*SolveType solves a captcha of a registered type and decodes its text with the type's Parse
+  params: the parameters, marshalled to JSON and checked against the type's Required fields
+*/
+func (c *Client) SolveType(ctx context.Context, captchaType CaptchaType, params interface{}) (*TypedResult, error) {
+	spec := LookupType(captchaType)
+	if spec == nil {
+		return nil, ErrUnknownType
+	}
+	resolved, err := c.SolveToken(ctx, captchaType, params)
+	if err != nil {
+		return nil, err
+	}
+	if spec.Parse == nil {
+		return &TypedResult{CaptchaResponse: resolved, Value: resolved.Text}, nil
+	}
+	value, err := spec.Parse(resolved.Text)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TypedResult{CaptchaResponse: resolved, Value: value}, nil
+}
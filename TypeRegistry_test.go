@@ -0,0 +1,82 @@
+package godbc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSolveRegisteredType(t *testing.T) {
+	custom := CaptchaType(99)
+	RegisterType(&TypeSpec{Type: custom, Name: "custom", ParamsField: "custom_params", Required: []string{"sitekey"}, Parse: func(text string) (interface{}, error) {
+		return strings.ToUpper(text), nil
+	}})
+	defer func() {
+		typeTable.Lock()
+		delete(typeTable.types, custom)
+		typeTable.Unlock()
+	}()
+
+	client := newSandboxClient(SandboxConfig{})
+	ctx := context.Background()
+	if _, err := client.SolveType(ctx, CaptchaType(98), nil); err != ErrUnknownType {
+		t.Fatalf("got %v, want ErrUnknownType", err)
+	}
+	_, err := client.SolveType(ctx, custom, map[string]string{"pageurl": "https://example.com"})
+	var paramsErr *ParamsError
+	if !errors.Is(err, ErrInvalidParams) || !errors.As(err, &paramsErr) || paramsErr.Field != "sitekey" {
+		t.Fatalf("got %v, want a ParamsError on sitekey", err)
+	}
+
+	result, err := client.SolveType(ctx, custom, map[string]string{"sitekey": "key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Value != "SANDBOX-TOKEN-1" {
+		t.Fatalf("unexpected value %v", result.Value)
+	}
+}
+
+func TestBuildRegisteredTypeRequest(t *testing.T) {
+	client := NewClient("user", "password", nil)
+	req, err := client.BuildTokenRequest(context.Background(), int(TypeHcaptcha), HcaptchaRequestPayload{PageURL: "https://example.com", SiteKey: "key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := req.ParseForm(); err != nil {
+		t.Fatal(err)
+	}
+	if req.PostForm.Get("hcaptcha_params") == "" {
+		t.Fatalf("unexpected form %v", req.PostForm)
+	}
+}
+
+func TestTypeParamsFields(t *testing.T) {
+	client := NewClient("user", "password", nil)
+	for captchaType, field := range map[CaptchaType]string{
+		TypeRecaptchaV2: "token_params",
+		TypeRecaptchaV3: "token_params",
+		TypeFuncaptcha:  "funcaptcha_params",
+		TypeHcaptcha:    "hcaptcha_params",
+		TypeGeetest:     "geetest_params",
+		TypeGeetestV4:   "geetest_params",
+		TypeTurnstile:   "turnstile_params",
+		TypeAmazonWAF:   "waf_params",
+	} {
+		params := map[string]string{}
+		for _, required := range LookupType(captchaType).Required {
+			params[required] = "value"
+		}
+		req, err := client.BuildTokenRequest(context.Background(), int(captchaType), params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := req.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		if req.PostForm.Get(field) == "" {
+			t.Errorf("type %d: no %s in the form %v", captchaType, field, req.PostForm)
+		}
+	}
+}
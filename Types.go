@@ -0,0 +1,70 @@
+package godbc
+
+import "strconv"
+
+//CaptchaType is the api type of a captcha, sent as the type field of submissions
+type CaptchaType int
+
+//Captcha types of the api
+const (
+	//TypeImage - image captcha, answered with its text
+	TypeImage CaptchaType = 0
+	//TypeCoordinates - click captcha, answered with the coordinates to click
+	TypeCoordinates CaptchaType = 2
+	//TypeImageGroup - "pick the matching images" captcha, answered with the selected tiles
+	TypeImageGroup CaptchaType = 3
+	//TypeRecaptchaV2 - recaptcha v2 by token
+	TypeRecaptchaV2 CaptchaType = 4
+	//TypeRecaptchaV3 - recaptcha v3 by token
+	TypeRecaptchaV3 CaptchaType = 5
+	//TypeFuncaptcha - funcaptcha by token
+	TypeFuncaptcha CaptchaType = 6
+	//TypeHcaptcha - hcaptcha by token
+	TypeHcaptcha CaptchaType = 7
+	//TypeGeetest - geetest v3 by token
+	TypeGeetest CaptchaType = 8
+	//TypeGeetestV4 - geetest v4 by token
+	TypeGeetestV4 CaptchaType = 9
+	//TypeText - question answered with text
+	TypeText CaptchaType = 11
+	//TypeTurnstile - cloudflare turnstile by token
+	TypeTurnstile CaptchaType = 12
+	//TypeAmazonWAF - amazon waf by token
+	TypeAmazonWAF CaptchaType = 13
+)
+
+//String returns the type as sent in the type field
+func (t CaptchaType) String() string {
+	return strconv.Itoa(int(t))
+}
+
+//StatusCode is the status field of api responses
+type StatusCode int
+
+//Status codes of the api
+const (
+	//StatusOK - the call succeeded
+	StatusOK StatusCode = 0
+	//StatusError - the call failed, the error field tells why, see ServiceError. Any other status than StatusOK is a failure too
+	StatusError StatusCode = 255
+)
+
+//StatusCode returns the status of the response, e.g. StatusOK
+func (r *CaptchaResponse) StatusCode() StatusCode {
+	return StatusCode(r.Status)
+}
+
+//StatusCode returns the status of the response, e.g. StatusOK
+func (r *StatusResponse) StatusCode() StatusCode {
+	return StatusCode(r.Status)
+}
+
+//StatusCode returns the status of the response, e.g. StatusOK
+func (r *UserResponse) StatusCode() StatusCode {
+	return StatusCode(r.Status)
+}
+
+//CaptchaType returns the api type of the profile's captchas, e.g. TypeRecaptchaV2
+func (p *Profile) CaptchaType() CaptchaType {
+	return CaptchaType(p.Type)
+}
@@ -0,0 +1,123 @@
+package godbc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/url"
+)
+
+//MaxVideoSize is the largest video accepted by CaptchaFromVideo
+const MaxVideoSize = 10 * 1024 * 1024
+
+//videoPollRetries is the poll budget of video captchas, which take minutes to solve
+const videoPollRetries = 60
+
+//Error codes returned by CaptchaFromVideo
+var (
+	//ErrUnsupportedType - No video captcha type is registered, see CaptchaFromVideo
+	ErrUnsupportedType = errors.New("Captcha type is not supported by the service")
+	//ErrVideoTooBig - The video is over MaxVideoSize
+	ErrVideoTooBig = errors.New("Video is too big (10MB max)")
+)
+
+//VideoFormat is a video format accepted by the service
+type VideoFormat int
+
+const (
+	//VideoUnknown - the content is not in an accepted format
+	VideoUnknown VideoFormat = iota
+	//VideoMP4 - MP4 video
+	VideoMP4
+	//VideoWebM - WebM video
+	VideoWebM
+)
+
+//DetectVideoFormat sniffs the format of a video from its magic bytes
+func DetectVideoFormat(content []byte) VideoFormat {
+	switch {
+	case len(content) >= 8 && bytes.Equal(content[4:8], []byte("ftyp")):
+		return VideoMP4
+	case bytes.HasPrefix(content, []byte{26, 69, 223, 163}):
+		return VideoWebM
+	}
+	return VideoUnknown
+}
+
+//String returns the name of the format
+func (f VideoFormat) String() string {
+	switch f {
+	case VideoMP4:
+		return "mp4"
+	case VideoWebM:
+		return "webm"
+	}
+	return "unknown"
+}
+
+//ContentType returns the MIME type of the format
+func (f VideoFormat) ContentType() string {
+	switch f {
+	case VideoMP4:
+		return "video/mp4"
+	case VideoWebM:
+		return "video/webm"
+	}
+	return "application/octet-stream"
+}
+
+//Extension returns the file extension of the format, with its dot
+func (f VideoFormat) Extension() string {
+	if f == VideoUnknown {
+		return ""
+	}
+	return "." + f.String()
+}
+
+//VideoTypeName is the name the video captcha type is registered under, see CaptchaFromVideo
+const VideoTypeName = "video"
+
+/*CaptchaFromVideo submits a video captcha, WaitCaptcha then polls it for longer than image captchas
+  The api does not document a video type yet: once the service exposes one, its code is registered with RegisterType(&TypeSpec{Type: code, Name: VideoTypeName}).
+  ErrUnsupportedType is returned until then
+  format: the format of the video, it must match the content
+*/
+func (c *Client) CaptchaFromVideo(ctx context.Context, content []byte, format VideoFormat) (*CaptchaResponse, error) {
+	spec := lookupTypeNamed(VideoTypeName)
+	if spec == nil {
+		return nil, ErrUnsupportedType
+	}
+	if len(content) > MaxVideoSize {
+		return nil, ErrVideoTooBig
+	}
+	if format == VideoUnknown || DetectVideoFormat(content) != format {
+		return nil, ErrInvalidFormat
+	}
+
+	return c.submitVideo(ctx, spec.Type, content, format)
+}
+
+//submitVideo uploads a video captcha, the response remembers how to upload it again
+func (c *Client) submitVideo(ctx context.Context, captchaType CaptchaType, content []byte, format VideoFormat) (*CaptchaResponse, error) {
+	ctx = withCaptchaType(c.correlate(ctx, ""), captchaType.String())
+	fields := url.Values{}
+	fields.Set("type", captchaType.String())
+	req, err := c.buildUploadRequest(ctx, content, "captcha"+format.Extension(), format.ContentType(), fields, nil)
+	if err != nil {
+		return nil, c.correlateError(ctx, 0, err)
+	}
+	response, err := c.submit(ctx, req)
+	if err != nil {
+		return nil, c.correlateError(ctx, 0, err)
+	}
+	response.retries = videoPollRetries
+	c.audit(ctx, AuditSubmit, response, content, nil)
+	if c.opts().Privacy {
+		return response, nil
+	}
+	response.resubmit = func(ctx context.Context) (*CaptchaResponse, error) {
+		return c.submitVideo(ctx, captchaType, content, format)
+	}
+
+	return response, nil
+}
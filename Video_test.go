@@ -0,0 +1,62 @@
+package godbc
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestVideoCapability(t *testing.T) {
+	video := append([]byte{0, 0, 0, 24}, []byte("ftypmp42 video")...)
+	ctx := context.Background()
+
+	client := newSandboxClient(SandboxConfig{Answer: "video"})
+	if _, err := client.CaptchaFromVideo(ctx, video, VideoMP4); err != ErrUnsupportedType {
+		t.Fatalf("got %v, want ErrUnsupportedType without a registered video type", err)
+	}
+
+	const videoType CaptchaType = 99
+	RegisterType(&TypeSpec{Type: videoType, Name: VideoTypeName})
+	defer func() {
+		typeTable.Lock()
+		delete(typeTable.types, videoType)
+		typeTable.Unlock()
+	}()
+	if _, err := client.CaptchaFromVideo(ctx, video, VideoWebM); err != ErrInvalidFormat {
+		t.Fatalf("got %v, want ErrInvalidFormat", err)
+	}
+	ressource, err := client.CaptchaFromVideo(ctx, video, VideoMP4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolved, err := client.WaitCaptchaWithContext(ctx, ressource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.Text != "video" {
+		t.Fatalf("unexpected response %+v", resolved)
+	}
+
+	mock, transport := newMockClient(nil, func(req *http.Request, body []byte) *http.Response {
+		return mockResponse(200, `{"captcha": 42, "is_correct": true, "text": "", "status": 0}`)
+	})
+	if _, err := mock.CaptchaFromVideo(ctx, video, VideoMP4); err != nil {
+		t.Fatal(err)
+	}
+	if _, body := transport.last(t); !strings.Contains(string(body), "name=\"type\"\r\n\r\n99\r\n") {
+		t.Fatal("the video was not submitted with the registered type code")
+	}
+
+	private, _ := newMockClient(&ClientOptions{Privacy: true}, func(req *http.Request, body []byte) *http.Response {
+		return mockResponse(200, `{"captcha": 42, "is_correct": true, "text": "", "status": 0}`)
+	})
+	original := append([]byte(nil), video...)
+	if _, err := private.CaptchaFromVideo(ctx, video, VideoMP4); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(video, original) {
+		t.Fatal("the caller's video was changed")
+	}
+}
@@ -0,0 +1,40 @@
+//go:build aws
+
+package credentials
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+//AWSSecretsManager loads credentials from an AWS Secrets Manager secret holding a JSON object with the keys username, password and authtoken
+type AWSSecretsManager struct {
+	Client *secretsmanager.Client
+	//SecretID - the name or ARN of the secret
+	SecretID string
+	//VersionStage - AWSCURRENT if empty
+	VersionStage string
+}
+
+//Load reads the secret
+func (a AWSSecretsManager) Load(ctx context.Context) (Credentials, error) {
+	input := &secretsmanager.GetSecretValueInput{SecretId: aws.String(a.SecretID)}
+	if a.VersionStage != "" {
+		input.VersionStage = aws.String(a.VersionStage)
+	}
+	out, err := a.Client.GetSecretValue(ctx, input)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	payload := out.SecretBinary
+	if out.SecretString != nil {
+		payload = []byte(*out.SecretString)
+	}
+	creds := Credentials{}
+	err = json.Unmarshal(payload, &creds)
+	return creds, err
+}
@@ -0,0 +1,40 @@
+/*
+Package credentials loads the DBC credentials from secret stores, so they are never kept in plain text on disk
+*/
+package credentials
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bask058/godbc"
+)
+
+//ErrMissingCredentials is returned when a store holds no username or password
+var ErrMissingCredentials = errors.New("Credentials are missing a username or password")
+
+//Credentials of a DBC account
+type Credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	//AuthToken - the account's API token, if the store has one
+	AuthToken string `json:"authtoken,omitempty"`
+}
+
+//Provider loads credentials from a secret store
+type Provider interface {
+	Load(ctx context.Context) (Credentials, error)
+}
+
+//NewClient loads the credentials from provider and returns a client using them
+func NewClient(ctx context.Context, provider Provider, options *godbc.ClientOptions) (*godbc.Client, error) {
+	creds, err := provider.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if creds.Username == "" || creds.Password == "" {
+		return nil, ErrMissingCredentials
+	}
+
+	return godbc.NewClient(creds.Username, creds.Password, options), nil
+}
@@ -0,0 +1,78 @@
+package credentials
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+)
+
+//ErrInvalidKey is returned for keys that are not 32 bytes long
+var ErrInvalidKey = errors.New("Key must be 32 bytes long")
+
+//ErrCorruptFile is returned when an encrypted file can not be decrypted with the key
+var ErrCorruptFile = errors.New("Encrypted file is corrupt or the key is wrong")
+
+/*EncryptedFile loads credentials from a file encrypted with AES-256-GCM, written by WriteEncryptedFile
+  The key is typically read from the environment or a mounted secret, not stored next to the file
+*/
+type EncryptedFile struct {
+	Path string
+	//Key - 32 bytes
+	Key []byte
+}
+
+//Load decrypts the file
+func (f EncryptedFile) Load(ctx context.Context) (Credentials, error) {
+	aead, err := newAEAD(f.Key)
+	if err != nil {
+		return Credentials{}, err
+	}
+	sealed, err := ioutil.ReadFile(f.Path)
+	if err != nil {
+		return Credentials{}, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return Credentials{}, ErrCorruptFile
+	}
+
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return Credentials{}, ErrCorruptFile
+	}
+	creds := Credentials{}
+	err = json.Unmarshal(plain, &creds)
+	return creds, err
+}
+
+//WriteEncryptedFile encrypts credentials with a 32 bytes key to a file readable by EncryptedFile
+func WriteEncryptedFile(path string, key []byte, creds Credentials) error {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+	plain, err := json.Marshal(creds)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, aead.Seal(nonce, nonce, plain, nil), 0600)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, ErrInvalidKey
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
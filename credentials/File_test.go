@@ -0,0 +1,52 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials")
+	key := bytes.Repeat([]byte{7}, 32)
+	creds := Credentials{Username: "user", Password: "secretpassword", AuthToken: "token"}
+	if err := WriteEncryptedFile(path, key, creds); err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sealed, []byte("secretpassword")) {
+		t.Fatal("the password was written in plain text")
+	}
+
+	loaded, err := EncryptedFile{Path: path, Key: key}.Load(context.Background())
+	if err != nil || loaded != creds {
+		t.Fatalf("got %+v, %v, want %+v", loaded, err, creds)
+	}
+	if _, err := (EncryptedFile{Path: path, Key: bytes.Repeat([]byte{8}, 32)}).Load(context.Background()); err != ErrCorruptFile {
+		t.Fatalf("got %v with the wrong key, want ErrCorruptFile", err)
+	}
+	if _, err := (EncryptedFile{Path: path, Key: key[:16]}).Load(context.Background()); err != ErrInvalidKey {
+		t.Fatalf("got %v with a short key, want ErrInvalidKey", err)
+	}
+}
+
+type staticProvider Credentials
+
+func (p staticProvider) Load(ctx context.Context) (Credentials, error) {
+	return Credentials(p), nil
+}
+
+func TestNewClient(t *testing.T) {
+	if _, err := NewClient(context.Background(), staticProvider{Username: "user"}, nil); err != ErrMissingCredentials {
+		t.Fatalf("got %v without a password, want ErrMissingCredentials", err)
+	}
+	client, err := NewClient(context.Background(), staticProvider{Username: "user", Password: "password"}, nil)
+	if err != nil || client == nil {
+		t.Fatalf("got %v, %v", client, err)
+	}
+}
@@ -0,0 +1,30 @@
+//go:build gcp
+
+package credentials
+
+import (
+	"context"
+	"encoding/json"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+)
+
+//GCPSecretManager loads credentials from a GCP Secret Manager secret holding a JSON object with the keys username, password and authtoken
+type GCPSecretManager struct {
+	Client *secretmanager.Client
+	//Name - the secret version, e.g. projects/my-project/secrets/dbc/versions/latest
+	Name string
+}
+
+//Load reads the secret
+func (g GCPSecretManager) Load(ctx context.Context) (Credentials, error) {
+	resp, err := g.Client.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{Name: g.Name})
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	creds := Credentials{}
+	err = json.Unmarshal(resp.GetPayload().GetData(), &creds)
+	return creds, err
+}
@@ -0,0 +1,46 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+//ErrKeychainUnsupported is returned on systems without a supported keychain
+var ErrKeychainUnsupported = errors.New("Keychain is not supported on this system")
+
+/*Keychain loads the password of Username from the OS keychain: the login keychain on macOS (security), the Secret Service on Linux (secret-tool)
+  The password is stored as a generic password of service Service and account Username
+*/
+type Keychain struct {
+	Service  string
+	Username string
+}
+
+//Load reads the password from the keychain
+func (k Keychain) Load(ctx context.Context) (Credentials, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.CommandContext(ctx, "security", "find-generic-password", "-s", k.Service, "-a", k.Username, "-w")
+	case "linux", "freebsd", "openbsd":
+		cmd = exec.CommandContext(ctx, "secret-tool", "lookup", "service", k.Service, "account", k.Username)
+	default:
+		return Credentials{}, ErrKeychainUnsupported
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if stderr.Len() > 0 {
+			return Credentials{}, errors.New(strings.TrimSpace(stderr.String()))
+		}
+		return Credentials{}, err
+	}
+
+	return Credentials{Username: k.Username, Password: strings.TrimRight(string(out), "\r\n")}, nil
+}
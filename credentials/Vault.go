@@ -0,0 +1,70 @@
+package credentials
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+/*Vault loads credentials from a HashiCorp Vault KV secret holding the keys username, password and authtoken
+  Both KV version 1 and 2 mounts are supported, for version 2 Path includes the data segment, e.g. "secret/data/dbc"
+*/
+type Vault struct {
+	//Address - e.g. https://vault.example.com:8200
+	Address string
+	Token   string
+	Path    string
+	//HTTPClient - http.DefaultClient if nil
+	HTTPClient *http.Client
+}
+
+type vaultSecret struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []string        `json:"errors"`
+}
+
+//Load reads the secret
+func (v Vault) Load(ctx context.Context) (Credentials, error) {
+	client := v.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	url := strings.TrimRight(v.Address, "/") + "/v1/" + strings.TrimLeft(v.Path, "/")
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return Credentials{}, err
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return Credentials{}, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	secret := vaultSecret{}
+	if err = json.Unmarshal(body, &secret); err != nil {
+		return Credentials{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Credentials{}, fmt.Errorf("Vault returned %d: %s", resp.StatusCode, strings.Join(secret.Errors, ", "))
+	}
+
+	//KV version 2 nests the secret in a second data object
+	nested := struct {
+		Data *Credentials `json:"data"`
+	}{}
+	if err = json.Unmarshal(secret.Data, &nested); err == nil && nested.Data != nil {
+		return *nested.Data, nil
+	}
+	creds := Credentials{}
+	err = json.Unmarshal(secret.Data, &creds)
+	return creds, err
+}
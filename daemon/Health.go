@@ -0,0 +1,115 @@
+/*
+Package daemon implements the building blocks of a solver service sharing one DBC account
+*/
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bask058/godbc"
+)
+
+//Breaker is a circuit breaker whose state is reflected in readiness
+type Breaker interface {
+	//IsOpen returns true when calls are currently refused
+	IsOpen() bool
+}
+
+/*Health serves the /healthz and /readyz endpoints of the service, for orchestrators to restart or drain unhealthy solver pods
+  /healthz fails when DBC is unreachable
+  /readyz also fails when credentials are rejected, the balance is under MinBalance, or the breaker is open
+*/
+type Health struct {
+	Client *godbc.Client
+	//MinBalance - balance under which the service is not ready
+	MinBalance float64
+	//Breaker - may be nil
+	Breaker Breaker
+	//CacheFor - how long a check result is reused, defaults to 10 seconds
+	CacheFor time.Duration
+
+	mu        sync.Mutex
+	lastCheck *godbc.HealthReport
+}
+
+type healthBody struct {
+	OK          bool                `json:"ok"`
+	Reason      string              `json:"reason,omitempty"`
+	BreakerOpen bool                `json:"breaker_open"`
+	Report      *godbc.HealthReport `json:"report"`
+}
+
+//Handler returns a handler serving /healthz and /readyz
+func (h *Health) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", h.Healthz)
+	mux.HandleFunc("/readyz", h.Readyz)
+	return mux
+}
+
+//Healthz serves the liveness check
+func (h *Health) Healthz(w http.ResponseWriter, r *http.Request) {
+	report := h.check(r.Context())
+	body := healthBody{OK: report.Reachable, Report: report}
+	if !body.OK {
+		body.Reason = "DBC is unreachable"
+	}
+	h.write(w, body)
+}
+
+//Readyz serves the readiness check
+func (h *Health) Readyz(w http.ResponseWriter, r *http.Request) {
+	report := h.check(r.Context())
+	body := healthBody{OK: true, Report: report, BreakerOpen: h.Breaker != nil && h.Breaker.IsOpen()}
+	switch {
+	case !report.Reachable:
+		body.Reason = "DBC is unreachable"
+	case !report.CredentialsValid:
+		body.Reason = "Credentials were rejected"
+	case report.IsBanned:
+		body.Reason = "User is banned"
+	case report.Balance < h.MinBalance || !report.HasCreditLeft:
+		body.Reason = "Balance is too low"
+	case body.BreakerOpen:
+		body.Reason = "Circuit breaker is open"
+	}
+	body.OK = body.Reason == ""
+	h.write(w, body)
+}
+
+func (h *Health) check(ctx context.Context) *godbc.HealthReport {
+	cacheFor := h.CacheFor
+	if cacheFor == 0 {
+		cacheFor = 10 * time.Second
+	}
+
+	h.mu.Lock()
+	last := h.lastCheck
+	h.mu.Unlock()
+	if last != nil && time.Since(last.CheckedAt) < cacheFor {
+		return last
+	}
+
+	//the lock is not held while pinging, so a slow DBC does not block the other probes
+	report, _ := h.Client.Ping(ctx)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.lastCheck == nil || !report.CheckedAt.Before(h.lastCheck.CheckedAt) {
+		h.lastCheck = report
+	}
+	return report
+}
+
+func (h *Health) write(w http.ResponseWriter, body healthBody) {
+	w.Header().Set("Content-Type", "application/json")
+	if body.OK {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(body)
+}
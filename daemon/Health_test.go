@@ -0,0 +1,117 @@
+package daemon
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bask058/godbc"
+)
+
+type openBreaker bool
+
+func (b openBreaker) IsOpen() bool {
+	return bool(b)
+}
+
+//newDBCServer returns a client of a fake DBC api reporting the given balance
+func newDBCServer(t *testing.T, balance string) *godbc.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/user") {
+			w.Write([]byte(`{"user": 1, "rate": 0.139, "balance": ` + balance + `, "is_banned": false, "status": 0}`))
+			return
+		}
+		w.Write([]byte(`{"status": 0, "is_service_overloaded": false}`))
+	}))
+	t.Cleanup(server.Close)
+	endpoint, _ := url.Parse(server.URL + "/api/")
+	return godbc.NewClient("user", "password", &godbc.ClientOptions{Endpoint: endpoint})
+}
+
+func TestHealth(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		health  *Health
+		healthz int
+		readyz  int
+		reason  string
+	}{
+		{"ready", &Health{Client: newDBCServer(t, "12.5"), MinBalance: 1}, 200, 200, ""},
+		{"low balance", &Health{Client: newDBCServer(t, "0.5"), MinBalance: 1}, 200, 503, "Balance is too low"},
+		{"breaker", &Health{Client: newDBCServer(t, "12.5"), Breaker: openBreaker(true)}, 200, 503, "Circuit breaker is open"},
+	} {
+		handler := tc.health.Handler()
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/healthz", nil))
+		if recorder.Code != tc.healthz {
+			t.Errorf("%s: /healthz answered %d, want %d", tc.name, recorder.Code, tc.healthz)
+		}
+
+		recorder = httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/readyz", nil))
+		var body healthBody
+		if err := json.NewDecoder(recorder.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if recorder.Code != tc.readyz || body.Reason != tc.reason || body.OK != (tc.readyz == 200) {
+			t.Errorf("%s: /readyz answered %d %+v, want %d %q", tc.name, recorder.Code, body, tc.readyz, tc.reason)
+		}
+	}
+}
+
+func TestHealthUnreachable(t *testing.T) {
+	endpoint, _ := url.Parse("http://127.0.0.1:1/api/")
+	health := &Health{Client: godbc.NewClient("user", "password", &godbc.ClientOptions{Endpoint: endpoint})}
+	recorder := httptest.NewRecorder()
+	health.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/healthz", nil))
+	if recorder.Code != http.StatusServiceUnavailable || !strings.Contains(recorder.Body.String(), "DBC is unreachable") {
+		t.Fatalf("/healthz answered %d %s", recorder.Code, recorder.Body)
+	}
+}
+
+func TestHealthSlowPing(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		first := false
+		once.Do(func() { first = true })
+		if first {
+			close(started)
+			<-release
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/user") {
+			w.Write([]byte(`{"user": 1, "rate": 0.139, "balance": 12.5, "is_banned": false, "status": 0}`))
+			return
+		}
+		w.Write([]byte(`{"status": 0, "is_service_overloaded": false}`))
+	}))
+	defer server.Close()
+	defer close(release)
+	endpoint, _ := url.Parse(server.URL + "/api/")
+	health := &Health{Client: godbc.NewClient("user", "password", &godbc.ClientOptions{Endpoint: endpoint})}
+	handler := health.Handler()
+
+	go handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/healthz", nil))
+	<-started
+	done := make(chan int)
+	go func() {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/readyz", nil))
+		done <- recorder.Code
+	}()
+	select {
+	case code := <-done:
+		if code != http.StatusOK {
+			t.Fatalf("/readyz answered %d", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("a slow ping blocked the other probes")
+	}
+}
@@ -0,0 +1,153 @@
+package daemon
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bask058/godbc"
+)
+
+//Error codes returned by the KeyStore
+var (
+	//ErrInvalidKey - the API key is unknown, malformed or revoked
+	ErrInvalidKey = errors.New("API key is invalid")
+	//ErrUnknownKey - no key was issued with this ID
+	ErrUnknownKey = errors.New("API key is unknown")
+)
+
+//APIKey describes a key issued by a KeyStore
+type APIKey struct {
+	ID string `json:"id"`
+	//Owner - the team or user the key was issued to
+	Owner     string            `json:"owner"`
+	Quota     godbc.TenantQuota `json:"quota"`
+	CreatedAt time.Time         `json:"created_at"`
+	Revoked   bool              `json:"revoked"`
+
+	mac []byte
+}
+
+/*KeyStore issues the daemon's own API keys, so teams share the solver without the DBC credentials
+  Keys are never kept, only their HMAC under the store's secret. Each key is its own tenant of Quotas, named after its ID
+*/
+type KeyStore struct {
+	secret []byte
+	quotas *godbc.Quotas
+
+	mu   sync.Mutex
+	keys map[string]*APIKey
+}
+
+type keyContextKey struct{}
+
+/*NewKeyStore returns an empty key store
+  secret: the HMAC secret, keep it out of the key listing
+  quotas: where the quota of each key is set, may be nil
+*/
+func NewKeyStore(secret []byte, quotas *godbc.Quotas) *KeyStore {
+	return &KeyStore{secret: secret, quotas: quotas, keys: map[string]*APIKey{}}
+}
+
+//Issue creates a key for owner, the returned key is shown only once
+func (s *KeyStore) Issue(owner string, quota godbc.TenantQuota) (string, APIKey, error) {
+	random := make([]byte, 24)
+	if _, err := rand.Read(random); err != nil {
+		return "", APIKey{}, err
+	}
+	id, token := hex.EncodeToString(random[:8]), hex.EncodeToString(random[8:])
+
+	key := &APIKey{ID: id, Owner: owner, Quota: quota, CreatedAt: time.Now(), mac: s.mac(id, token)}
+	s.mu.Lock()
+	s.keys[id] = key
+	s.mu.Unlock()
+	if s.quotas != nil {
+		s.quotas.Set(id, quota)
+	}
+
+	return id + "." + token, *key, nil
+}
+
+//Revoke revokes the key with the given ID
+func (s *KeyStore) Revoke(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.keys[id]
+	if !ok {
+		return ErrUnknownKey
+	}
+	key.Revoked = true
+	return nil
+}
+
+//Keys lists the issued keys, by creation date
+func (s *KeyStore) Keys() []APIKey {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]APIKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, *key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys
+}
+
+//Authenticate returns the key matching the given one, or ErrInvalidKey
+func (s *KeyStore) Authenticate(key string) (APIKey, error) {
+	parts := strings.SplitN(key, ".", 2)
+	if len(parts) != 2 {
+		return APIKey{}, ErrInvalidKey
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	issued, ok := s.keys[parts[0]]
+	if !ok || issued.Revoked || !hmac.Equal(issued.mac, s.mac(parts[0], parts[1])) {
+		return APIKey{}, ErrInvalidKey
+	}
+	return *issued, nil
+}
+
+/*Middleware rejects requests without a valid key with a 401
+  The key is read from the "Authorization: Bearer" header, or the X-API-Key header
+*/
+func (s *KeyStore) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw := r.Header.Get("X-API-Key")
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			raw = strings.TrimPrefix(auth, "Bearer ")
+		}
+		key, err := s.Authenticate(raw)
+		if err != nil {
+			writeJSON(w, http.StatusUnauthorized, solveBody{Error: err.Error()})
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), keyContextKey{}, key)))
+	})
+}
+
+//Tenant returns the ID of the key a request was authenticated with, for Solver.Tenant
+func (s *KeyStore) Tenant(r *http.Request) string {
+	key, _ := KeyFrom(r.Context())
+	return key.ID
+}
+
+//KeyFrom returns the key a request was authenticated with by Middleware
+func KeyFrom(ctx context.Context) (APIKey, bool) {
+	key, ok := ctx.Value(keyContextKey{}).(APIKey)
+	return key, ok
+}
+
+func (s *KeyStore) mac(id, token string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(id + "." + token))
+	return mac.Sum(nil)
+}
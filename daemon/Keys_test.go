@@ -0,0 +1,76 @@
+package daemon
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bask058/godbc"
+)
+
+func TestKeyStore(t *testing.T) {
+	store := NewKeyStore([]byte("secret"), godbc.NewQuotas(godbc.TenantQuota{}))
+	raw, issued, err := store.Issue("team", godbc.TenantQuota{SolvesPerHour: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(raw, issued.ID+".") {
+		t.Fatalf("the key %q does not start with its ID %q", raw, issued.ID)
+	}
+
+	key, err := store.Authenticate(raw)
+	if err != nil || key.ID != issued.ID || key.Owner != "team" {
+		t.Fatalf("got %+v, %v for the issued key", key, err)
+	}
+	for _, invalid := range []string{"", issued.ID, issued.ID + ".forged", "unknown." + strings.SplitN(raw, ".", 2)[1]} {
+		if _, err := store.Authenticate(invalid); err != ErrInvalidKey {
+			t.Errorf("%q: got %v, want ErrInvalidKey", invalid, err)
+		}
+	}
+	if _, err := NewKeyStore([]byte("other secret"), nil).Authenticate(raw); err != ErrInvalidKey {
+		t.Errorf("a key was accepted by a store it was not issued by: %v", err)
+	}
+
+	if err := store.Revoke(issued.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Authenticate(raw); err != ErrInvalidKey {
+		t.Fatalf("got %v for a revoked key, want ErrInvalidKey", err)
+	}
+	if err := store.Revoke("unknown"); err != ErrUnknownKey {
+		t.Fatalf("got %v, want ErrUnknownKey", err)
+	}
+	if keys := store.Keys(); len(keys) != 1 || !keys[0].Revoked {
+		t.Fatalf("unexpected keys %+v", keys)
+	}
+}
+
+func TestKeyStoreMiddleware(t *testing.T) {
+	store := NewKeyStore([]byte("secret"), nil)
+	raw, issued, err := store.Issue("team", godbc.TenantQuota{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tenant string
+	handler := store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant = store.Tenant(r)
+	}))
+
+	for _, header := range [][2]string{{"Authorization", "Bearer " + raw}, {"X-API-Key", raw}} {
+		tenant = ""
+		req := httptest.NewRequest("POST", "/solve", nil)
+		req.Header.Set(header[0], header[1])
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		if recorder.Code != http.StatusOK || tenant != issued.ID {
+			t.Errorf("%s: answered %d for the tenant %q", header[0], recorder.Code, tenant)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/solve", nil))
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("answered %d without a key, want 401", recorder.Code)
+	}
+}
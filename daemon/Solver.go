@@ -0,0 +1,93 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/bask058/godbc"
+)
+
+/*Solver serves image captcha solving through a Pool shared by several tenants
+  POST /solve solves the image sent as body, with the X-Priority: interactive header to jump ahead of batch captchas
+  GET /quota returns the usage statistics of the caller's tenant
+*/
+type Solver struct {
+	Pool *godbc.Pool
+	//Quotas - the quotas of the pool, for /quota, may be nil
+	Quotas *godbc.Quotas
+	//Tenant - returns the tenant of a request, defaults to the X-Tenant header
+	Tenant func(r *http.Request) string
+}
+
+type solveBody struct {
+	ID    int64  `json:"id,omitempty"`
+	Text  string `json:"text,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+//Handler returns a handler serving /solve and /quota
+func (s *Solver) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/solve", s.Solve)
+	mux.HandleFunc("/quota", s.Quota)
+	return mux
+}
+
+//Solve serves a captcha solve
+func (s *Solver) Solve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, solveBody{Error: "POST an image"})
+		return
+	}
+	content, err := ioutil.ReadAll(io.LimitReader(r.Body, godbc.MaxContentSize+1))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, solveBody{Error: err.Error()})
+		return
+	}
+	if len(content) > godbc.MaxContentSize {
+		writeJSON(w, http.StatusRequestEntityTooLarge, solveBody{Error: godbc.ErrContentTooBig.Error()})
+		return
+	}
+
+	priority := godbc.PriorityBatch
+	if r.Header.Get("X-Priority") == "interactive" {
+		priority = godbc.PriorityInteractive
+	}
+	ctx := godbc.WithTenant(r.Context(), s.tenant(r))
+	response, err := s.Pool.SolveWithPriority(ctx, content, nil, priority)
+	switch {
+	case err == nil:
+		writeJSON(w, http.StatusOK, solveBody{ID: response.ID, Text: response.Text})
+	case errors.Is(err, godbc.ErrQuotaExceeded):
+		writeJSON(w, http.StatusTooManyRequests, solveBody{Error: err.Error()})
+	case errors.Is(err, godbc.ErrInvalidFormat), errors.Is(err, godbc.ErrImageDimensions), errors.Is(err, godbc.ErrContentTooShort), errors.Is(err, godbc.ErrContentTooBig), errors.Is(err, godbc.ErrTooManyFrames):
+		writeJSON(w, http.StatusBadRequest, solveBody{Error: err.Error()})
+	default:
+		writeJSON(w, http.StatusBadGateway, solveBody{Error: err.Error()})
+	}
+}
+
+//Quota serves the usage statistics of the caller's tenant
+func (s *Solver) Quota(w http.ResponseWriter, r *http.Request) {
+	if s.Quotas == nil {
+		writeJSON(w, http.StatusNotFound, solveBody{Error: "Quotas are not enabled"})
+		return
+	}
+	writeJSON(w, http.StatusOK, s.Quotas.Stats(s.tenant(r)))
+}
+
+func (s *Solver) tenant(r *http.Request) string {
+	if s.Tenant != nil {
+		return s.Tenant(r)
+	}
+	return r.Header.Get("X-Tenant")
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,92 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bask058/godbc"
+)
+
+func captchaImage(t *testing.T) []byte {
+	img := image.NewGray(image.Rect(0, 0, 120, 40))
+	for i := range img.Pix {
+		img.Pix[i] = byte(i * 7919 % 251)
+	}
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestSolver(t *testing.T) {
+	client := godbc.NewClient("user", "password", &godbc.ClientOptions{Sandbox: &godbc.SandboxConfig{Answer: "abcdef"}})
+	quotas := godbc.NewQuotas(godbc.TenantQuota{})
+	quotas.Set("limited", godbc.TenantQuota{SolvesPerHour: 1})
+	pool := godbc.NewPool(client, godbc.PoolOptions{Quotas: quotas})
+	defer pool.Close()
+	handler := (&Solver{Pool: pool, Quotas: quotas}).Handler()
+
+	solve := func(method, tenant string, content []byte) (int, solveBody) {
+		req := httptest.NewRequest(method, "/solve", bytes.NewReader(content))
+		req.Header.Set("X-Tenant", tenant)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		var body solveBody
+		json.NewDecoder(recorder.Body).Decode(&body)
+		return recorder.Code, body
+	}
+
+	content := captchaImage(t)
+	if code, body := solve("POST", "limited", content); code != http.StatusOK || body.Text != "abcdef" || body.ID == 0 {
+		t.Fatalf("answered %d %+v", code, body)
+	}
+	if code, body := solve("POST", "limited", content); code != http.StatusTooManyRequests || body.Error != godbc.ErrQuotaExceeded.Error() {
+		t.Fatalf("answered %d %+v over the quota, want 429", code, body)
+	}
+	for _, tc := range []struct {
+		method  string
+		content []byte
+		want    int
+	}{
+		{"GET", nil, http.StatusMethodNotAllowed},
+		{"POST", []byte("not an image at all"), http.StatusBadRequest},
+		{"POST", make([]byte, godbc.MaxContentSize+1), http.StatusRequestEntityTooLarge},
+	} {
+		if code, body := solve(tc.method, "team", tc.content); code != tc.want || body.Error == "" {
+			t.Errorf("%s of %d bytes: answered %d %+v, want %d", tc.method, len(tc.content), code, body, tc.want)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/quota", nil)
+	req.Header.Set("X-Tenant", "limited")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	var stats godbc.TenantStats
+	if err := json.NewDecoder(recorder.Body).Decode(&stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Solves != 1 || stats.Rejected != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestSolverWrappedErrors(t *testing.T) {
+	rejectImage := func(content []byte) ([]byte, error) {
+		return nil, &godbc.CorrelationError{CorrelationID: "id", Err: godbc.ErrImageDimensions}
+	}
+	client := godbc.NewClient("user", "password", &godbc.ClientOptions{Sandbox: &godbc.SandboxConfig{}, Preprocessors: []godbc.Preprocessor{rejectImage}})
+	pool := godbc.NewPool(client, godbc.PoolOptions{})
+	defer pool.Close()
+
+	recorder := httptest.NewRecorder()
+	(&Solver{Pool: pool}).Handler().ServeHTTP(recorder, httptest.NewRequest("POST", "/solve", bytes.NewReader(captchaImage(t))))
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("answered %d %s for a wrapped ErrImageDimensions, want 400", recorder.Code, recorder.Body)
+	}
+}
@@ -0,0 +1,50 @@
+package examples_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/bask058/godbc"
+	"github.com/bask058/godbc/daemon"
+)
+
+//Share one account between teams with the daemon, each team calling it with its own API key and quota
+func Example_daemon() {
+	client := godbc.NewClient("user", "password", &godbc.ClientOptions{
+		Sandbox:        &godbc.SandboxConfig{Answer: "abcdef"},
+		CaptchaRetries: 5,
+	})
+	quotas := godbc.NewQuotas(godbc.TenantQuota{})
+	pool := godbc.NewPool(client, godbc.PoolOptions{Workers: 2, Quotas: quotas})
+	defer pool.Close()
+
+	keys := daemon.NewKeyStore([]byte("secret"), quotas)
+	key, _, err := keys.Issue("search-team", godbc.TenantQuota{SolvesPerHour: 100})
+	if err != nil {
+		panic(err)
+	}
+	solver := &daemon.Solver{Pool: pool, Quotas: quotas, Tenant: keys.Tenant}
+	server := httptest.NewServer(keys.Middleware(solver.Handler()))
+	defer server.Close()
+
+	post := func(key string) {
+		req, _ := http.NewRequest(`POST`, server.URL+"/solve", bytes.NewReader(captchaImage()))
+		req.Header.Set("Authorization", "Bearer "+key)
+		req.Header.Set("X-Priority", "interactive")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			panic(err)
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		fmt.Printf("%d %s", resp.StatusCode, body)
+	}
+	post(key)
+	post("unknown.key")
+	// Output:
+	// 200 {"id":1,"text":"abcdef"}
+	// 401 {"error":"API key is invalid"}
+}
@@ -0,0 +1,8 @@
+/*
+Package examples holds runnable examples of the godbc APIs
+
+The examples run against the client's sandbox, an in-process fake of the API, so they
+need neither credentials nor credit, and their output is checked by go test. Replace the
+Sandbox option with real credentials to run them against the service
+*/
+package examples
@@ -0,0 +1,57 @@
+package examples_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+
+	"github.com/bask058/godbc"
+)
+
+//captchaImage returns a png standing for a captcha
+func captchaImage() []byte {
+	img := image.NewGray(image.Rect(0, 0, 200, 70))
+	for i := range img.Pix {
+		img.Pix[i] = byte(i * 7919 % 251)
+	}
+	buf := &bytes.Buffer{}
+	png.Encode(buf, img)
+	return buf.Bytes()
+}
+
+//Solve an image captcha, then report it when the website rejects the answer
+func Example_imageCaptcha() {
+	client := godbc.NewClient("user", "password", &godbc.ClientOptions{
+		Sandbox:        &godbc.SandboxConfig{Answer: " Ab5dE "},
+		CaptchaRetries: 5,
+		PostProcessors: []godbc.PostProcessor{godbc.TrimSpace(), godbc.Lowercase()},
+	})
+	ctx := context.Background()
+
+	ressource, err := client.CaptchaWithOptions(ctx, captchaImage(), &godbc.CaptchaOptions{MinLength: 4})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("submitted a %s image as captcha %d\n", ressource.Format, ressource.ID)
+
+	resolved, err := client.WaitCaptchaWithContext(ctx, ressource)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("solved as %q after %d attempt\n", resolved.Text, resolved.Attempts)
+
+	websiteAccepted := false
+	if !websiteAccepted {
+		_, err = client.ReportCaptchaWithContext(ctx, resolved)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println("reported")
+	}
+	// Output:
+	// submitted a png image as captcha 1
+	// solved as "ab5de" after 1 attempt
+	// reported
+}
@@ -0,0 +1,47 @@
+package examples_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bask058/godbc"
+)
+
+//Solve a batch of captchas with a pool of workers, interactive captchas jumping ahead of the batch
+func Example_pool() {
+	client := godbc.NewClient("user", "password", &godbc.ClientOptions{
+		Sandbox:        &godbc.SandboxConfig{Answer: "abcdef"},
+		CaptchaRetries: 5,
+	})
+	pool := godbc.NewPool(client, godbc.PoolOptions{Workers: 4, TargetLatency: 30 * time.Second, Retries: 1})
+	defer pool.Close()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	texts := make([]string, 6)
+	for i := range texts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resolved, err := pool.Solve(ctx, captchaImage(), nil)
+			if err != nil {
+				panic(err)
+			}
+			texts[i] = resolved.Text
+		}(i)
+	}
+
+	resolved, err := pool.SolveWithPriority(ctx, captchaImage(), nil, godbc.PriorityInteractive)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("interactive: %s\n", resolved.Text)
+
+	wg.Wait()
+	fmt.Printf("batch: %v\n", texts)
+	// Output:
+	// interactive: abcdef
+	// batch: [abcdef abcdef abcdef abcdef abcdef abcdef]
+}
@@ -0,0 +1,166 @@
+/*
+Package integration implements helpers to solve the captchas of pages driven by a headless browser
+
+The helpers extract the sitekey and page url from the live page, solve the captcha
+through a godbc client, then inject the solution in the page and trigger its callback.
+Browsers are reached through the Page interface, adapters for chromedp and rod are
+available with the `chromedp` and `rod` build tags, and WebDriver sessions such as Selenium's
+are reached through the webdriver subpackage
+*/
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bask058/godbc"
+)
+
+//ErrNoCaptcha - No captcha was found in the page
+var ErrNoCaptcha = errors.New("No captcha found in the page")
+
+//Captcha kinds found in pages
+const (
+	//KindRecaptcha - Google reCAPTCHA
+	KindRecaptcha = "recaptcha"
+	//KindHcaptcha - hCaptcha
+	KindHcaptcha = "hcaptcha"
+)
+
+//Page is a live browser page
+type Page interface {
+	//Evaluate evaluates a javascript expression in the page, and unmarshals its JSON value into result
+	Evaluate(ctx context.Context, expression string, result interface{}) error
+}
+
+//Target is a captcha found in a page
+type Target struct {
+	Kind    string `json:"kind"`
+	SiteKey string `json:"sitekey"`
+	PageURL string `json:"pageurl"`
+}
+
+const extractScript = `(function() {
+	var found = function(kind, sitekey) { return {kind: kind, sitekey: sitekey, pageurl: location.href}; };
+	var el = document.querySelector('.g-recaptcha[data-sitekey]');
+	if (el) { return found('recaptcha', el.getAttribute('data-sitekey')); }
+	el = document.querySelector('.h-captcha[data-sitekey]');
+	if (el) { return found('hcaptcha', el.getAttribute('data-sitekey')); }
+	var frames = document.querySelectorAll('iframe[src]');
+	for (var i = 0; i < frames.length; i++) {
+		var src = new URL(frames[i].src, location.href);
+		if (/recaptcha\/(api2|enterprise)\/anchor/.test(src.pathname) && src.searchParams.get('k')) {
+			return found('recaptcha', src.searchParams.get('k'));
+		}
+		if (/hcaptcha/.test(src.hostname)) {
+			var key = src.searchParams.get('sitekey') || new URLSearchParams(src.hash.slice(1)).get('sitekey');
+			if (key) { return found('hcaptcha', key); }
+		}
+	}
+	el = document.querySelector('[data-sitekey]');
+	if (el) { return found('recaptcha', el.getAttribute('data-sitekey')); }
+	return {kind: '', sitekey: '', pageurl: location.href};
+})()`
+
+const injectScript = `(function(kind, token) {
+	var fields = kind === 'hcaptcha' ? ['h-captcha-response', 'g-recaptcha-response'] : ['g-recaptcha-response'];
+	fields.forEach(function(name) {
+		document.querySelectorAll('textarea[name="' + name + '"], #' + name).forEach(function(el) {
+			el.value = token;
+			el.innerHTML = token;
+		});
+	});
+	var el = document.querySelector('[data-callback]');
+	var callback = el && el.getAttribute('data-callback');
+	if (callback && typeof window[callback] === 'function') {
+		window[callback](token);
+		return true;
+	}
+	if (kind === 'recaptcha' && window.___grecaptcha_cfg) {
+		// the client objects reference the page (DOM nodes, window), the search skips the objects already seen and stops 8 levels down
+		var clients = window.___grecaptcha_cfg.clients || {};
+		var seen = new Set();
+		for (var id in clients) {
+			var stack = [[clients[id], 0]];
+			while (stack.length) {
+				var entry = stack.pop(), node = entry[0], depth = entry[1];
+				if (!node || typeof node !== 'object' || seen.has(node) || node === window || node.nodeType) { continue; }
+				seen.add(node);
+				if (typeof node.callback === 'function') {
+					node.callback(token);
+					return true;
+				}
+				if (depth >= 8) { continue; }
+				for (var k in node) {
+					try {
+						if (node[k] && typeof node[k] === 'object') { stack.push([node[k], depth + 1]); }
+					} catch (e) {}
+				}
+			}
+		}
+	}
+	return false;
+})(%s, %s)`
+
+//Extract returns the captcha found in the page
+func Extract(ctx context.Context, page Page) (*Target, error) {
+	target := &Target{}
+	err := page.Evaluate(ctx, extractScript, target)
+	if err != nil {
+		return nil, err
+	}
+	if target.Kind == "" || target.SiteKey == "" {
+		return nil, ErrNoCaptcha
+	}
+
+	return target, nil
+}
+
+//Inject sets the solved token in the page's response fields, and calls the captcha callback if one is found. Returns whether a callback was called
+func Inject(ctx context.Context, page Page, kind, token string) (bool, error) {
+	script, err := formatInjectScript(kind, token)
+	if err != nil {
+		return false, err
+	}
+
+	called := false
+	err = page.Evaluate(ctx, script, &called)
+	return called, err
+}
+
+//Solve extracts the captcha of the page, solves it through the client and injects the solution. Returns the solved captcha
+func Solve(ctx context.Context, client *godbc.Client, page Page) (*godbc.CaptchaResponse, error) {
+	target, err := Extract(ctx, page)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := solveTarget(ctx, client, target)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = Inject(ctx, page, target.Kind, resolved.Text)
+	if err != nil {
+		return nil, err
+	}
+	return resolved, nil
+}
+
+//solveTarget solves the captcha found in a page and waits for its token
+func solveTarget(ctx context.Context, client *godbc.Client, target *Target) (*godbc.CaptchaResponse, error) {
+	var ressource *godbc.CaptchaResponse
+	var err error
+	switch target.Kind {
+	case KindRecaptcha:
+		ressource, err = client.RecaptchaWithPayload(ctx, godbc.RecaptchaRequestPayload{PageURL: target.PageURL, GoogleKey: target.SiteKey})
+	case KindHcaptcha:
+		ressource, err = client.Hcaptcha(ctx, godbc.HcaptchaRequestPayload{PageURL: target.PageURL, SiteKey: target.SiteKey})
+	default:
+		return nil, fmt.Errorf("Captcha kind %s is not supported by the client", target.Kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return client.WaitCaptchaWithContext(ctx, ressource)
+}
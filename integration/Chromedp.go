@@ -0,0 +1,17 @@
+//go:build chromedp
+
+package integration
+
+import (
+	"context"
+
+	"github.com/chromedp/chromedp"
+)
+
+//ChromedpPage is the page of a chromedp context: Evaluate must be called with a context created by chromedp.NewContext
+type ChromedpPage struct{}
+
+//Evaluate evaluates a javascript expression in the page of the chromedp context
+func (ChromedpPage) Evaluate(ctx context.Context, expression string, result interface{}) error {
+	return chromedp.Run(ctx, chromedp.Evaluate(expression, result))
+}
@@ -0,0 +1,69 @@
+//go:build colly
+
+package integration
+
+import (
+	"context"
+
+	"github.com/bask058/godbc"
+	"github.com/gocolly/colly/v2"
+)
+
+//collySolvedKey marks the colly requests already replayed with a solved token
+const collySolvedKey = "godbc-solved"
+
+/*CollyMiddleware solves the reCAPTCHA interstitials met by a colly collector, and replays the original request with the solution
+  Interstitial responses are still passed to the collector's other callbacks
+*/
+type CollyMiddleware struct {
+	Client *godbc.Client
+	//Detect returns the sitekey of the interstitial captcha, defaults to DetectRecaptcha on the body
+	Detect func(r *colly.Response) (string, bool)
+	//Replay sends the original request again with the solved token, defaults to posting it as g-recaptcha-response to the page url
+	Replay func(r *colly.Response, token string) error
+	//OnError is called when an interstitial could not be solved or replayed, may be nil
+	OnError func(r *colly.Response, err error)
+}
+
+//Attach registers the middleware on the collector
+func (m *CollyMiddleware) Attach(c *colly.Collector) {
+	c.OnResponse(m.handle)
+	c.OnError(func(r *colly.Response, _ error) {
+		m.handle(r)
+	})
+}
+
+func (m *CollyMiddleware) handle(r *colly.Response) {
+	if r == nil || r.Request == nil || r.Ctx.Get(collySolvedKey) != "" {
+		return
+	}
+
+	detect := m.Detect
+	if detect == nil {
+		detect = func(r *colly.Response) (string, bool) {
+			return DetectRecaptcha(r.Body)
+		}
+	}
+	sitekey, ok := detect(r)
+	if !ok {
+		return
+	}
+
+	ressource, err := m.Client.RecaptchaWithoutProxy(r.Request.URL.String(), sitekey)
+	if err == nil {
+		ressource, err = m.Client.WaitCaptchaWithContext(context.Background(), ressource)
+	}
+	if err == nil {
+		r.Ctx.Put(collySolvedKey, "1")
+		replay := m.Replay
+		if replay == nil {
+			replay = func(r *colly.Response, token string) error {
+				return r.Request.Post(r.Request.URL.String(), map[string]string{"g-recaptcha-response": token})
+			}
+		}
+		err = replay(r, ressource.Text)
+	}
+	if err != nil && m.OnError != nil {
+		m.OnError(r, err)
+	}
+}
@@ -0,0 +1,26 @@
+package integration
+
+import (
+	"github.com/bask058/godbc"
+)
+
+//DetectRecaptcha returns the sitekey of the reCAPTCHA found in an html page
+func DetectRecaptcha(body []byte) (string, bool) {
+	return detectSiteKey(body, godbc.TypeRecaptchaV2, godbc.TypeRecaptchaV3)
+}
+
+//DetectHcaptcha returns the sitekey of the hCaptcha found in an html page
+func DetectHcaptcha(body []byte) (string, bool) {
+	return detectSiteKey(body, godbc.TypeHcaptcha)
+}
+
+func detectSiteKey(body []byte, captchaTypes ...godbc.CaptchaType) (string, bool) {
+	for _, detection := range godbc.Detect(body, "") {
+		for _, captchaType := range captchaTypes {
+			if detection.Type == captchaType {
+				return detection.SiteKey, true
+			}
+		}
+	}
+	return "", false
+}
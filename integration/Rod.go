@@ -0,0 +1,23 @@
+//go:build rod
+
+package integration
+
+import (
+	"context"
+
+	"github.com/go-rod/rod"
+)
+
+//RodPage is a rod page
+type RodPage struct {
+	Page *rod.Page
+}
+
+//Evaluate evaluates a javascript expression in the rod page
+func (p RodPage) Evaluate(ctx context.Context, expression string, result interface{}) error {
+	obj, err := p.Page.Context(ctx).Eval(`() => (` + expression + `)`)
+	if err != nil {
+		return err
+	}
+	return obj.Value.Unmarshal(result)
+}
@@ -0,0 +1,193 @@
+package integration
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+//ErrUnknownTransformer - No transformer is registered with this name, see RegisterTransformer
+var ErrUnknownTransformer = errors.New("Token transformer is not registered")
+
+//Names of the built-in transformers
+const (
+	//TransformForm - the token as the response form fields of the captcha kind
+	TransformForm = "form"
+	//TransformScript - the token as a javascript snippet setting the response fields and calling the captcha callback
+	TransformScript = "script"
+	//TransformCookie - the token as a cookie named after the response field
+	TransformCookie = "cookie"
+	//TransformHeader - the token as a header named after the response field
+	TransformHeader = "header"
+)
+
+/*Injection is a solved token formatted for the target site, a transformer fills the parts it produces
+  Fields: form fields to submit with the protected form
+  Script: a javascript expression to evaluate in the page, see Page.Evaluate
+  Cookies: cookies to send with the next requests
+  Header: headers to send with the next requests
+*/
+type Injection struct {
+	Fields  url.Values
+	Script  string
+	Cookies []*http.Cookie
+	Header  http.Header
+}
+
+//Apply sets the header and cookies of the injection on the request. Fields and Script are left to the caller, as they depend on how the request is built
+func (i *Injection) Apply(request *http.Request) {
+	for name, values := range i.Header {
+		for _, value := range values {
+			request.Header.Add(name, value)
+		}
+	}
+	for _, cookie := range i.Cookies {
+		request.AddCookie(cookie)
+	}
+}
+
+//Transformer formats a solved token of a captcha found in a page
+type Transformer interface {
+	Transform(target Target, token string) (*Injection, error)
+}
+
+//TransformerFunc adapts a function to the Transformer interface
+type TransformerFunc func(target Target, token string) (*Injection, error)
+
+//Transform calls f
+func (f TransformerFunc) Transform(target Target, token string) (*Injection, error) {
+	return f(target, token)
+}
+
+//FormTransformer sets the token in the response fields of the captcha kind
+type FormTransformer struct{}
+
+//Transform returns the response fields set to the token
+func (FormTransformer) Transform(target Target, token string) (*Injection, error) {
+	fields := url.Values{}
+	for _, name := range responseFields(target.Kind) {
+		fields.Set(name, token)
+	}
+	return &Injection{Fields: fields}, nil
+}
+
+//ScriptTransformer returns the script Inject evaluates, for pages driven outside of the Page interface
+type ScriptTransformer struct{}
+
+//Transform returns the script setting the response fields and calling the captcha callback, it evaluates to whether a callback was called
+func (ScriptTransformer) Transform(target Target, token string) (*Injection, error) {
+	script, err := formatInjectScript(target.Kind, token)
+	if err != nil {
+		return nil, err
+	}
+	return &Injection{Script: script}, nil
+}
+
+/*CookieTransformer sets the token in a cookie
+  Name: the cookie name, the first response field of the captcha kind if empty
+  Domain: the cookie domain, the host of the page url if empty
+  Path: the cookie path, "/" if empty
+*/
+type CookieTransformer struct {
+	Name   string
+	Domain string
+	Path   string
+}
+
+//Transform returns the cookie set to the token
+func (t CookieTransformer) Transform(target Target, token string) (*Injection, error) {
+	cookie := &http.Cookie{Name: t.Name, Value: token, Domain: t.Domain, Path: t.Path}
+	if cookie.Name == "" {
+		cookie.Name = responseFields(target.Kind)[0]
+	}
+	if cookie.Domain == "" && target.PageURL != "" {
+		pageURL, err := url.Parse(target.PageURL)
+		if err != nil {
+			return nil, err
+		}
+		cookie.Domain = pageURL.Hostname()
+	}
+	if cookie.Path == "" {
+		cookie.Path = "/"
+	}
+	return &Injection{Cookies: []*http.Cookie{cookie}}, nil
+}
+
+/*HeaderTransformer sets the token in a header
+  Name: the header name, the first response field of the captcha kind if empty
+  Prefix: prepended to the token, e.g. "Bearer "
+*/
+type HeaderTransformer struct {
+	Name   string
+	Prefix string
+}
+
+//Transform returns the header set to the token
+func (t HeaderTransformer) Transform(target Target, token string) (*Injection, error) {
+	name := t.Name
+	if name == "" {
+		name = responseFields(target.Kind)[0]
+	}
+	header := http.Header{}
+	header.Set(name, t.Prefix+token)
+	return &Injection{Header: header}, nil
+}
+
+var transformerTable = struct {
+	sync.RWMutex
+	transformers map[string]Transformer
+}{transformers: map[string]Transformer{}}
+
+func init() {
+	RegisterTransformer(TransformForm, FormTransformer{})
+	RegisterTransformer(TransformScript, ScriptTransformer{})
+	RegisterTransformer(TransformCookie, CookieTransformer{})
+	RegisterTransformer(TransformHeader, HeaderTransformer{})
+}
+
+//RegisterTransformer adds a transformer to the registry, replacing the transformer of the same name. Sites expecting the token in their own cookie or header register a configured CookieTransformer or HeaderTransformer
+func RegisterTransformer(name string, transformer Transformer) {
+	transformerTable.Lock()
+	defer transformerTable.Unlock()
+	transformerTable.transformers[name] = transformer
+}
+
+//LookupTransformer returns the transformer registered with the name
+func LookupTransformer(name string) (Transformer, bool) {
+	transformerTable.RLock()
+	defer transformerTable.RUnlock()
+	transformer, ok := transformerTable.transformers[name]
+	return transformer, ok
+}
+
+//Transform formats the token of the target with the transformer registered with the name
+func Transform(name string, target Target, token string) (*Injection, error) {
+	transformer, ok := LookupTransformer(name)
+	if !ok {
+		return nil, ErrUnknownTransformer
+	}
+	return transformer.Transform(target, token)
+}
+
+//responseFields returns the form fields the captcha kind submits its token in, the first one being its own
+func responseFields(kind string) []string {
+	if kind == KindHcaptcha {
+		return []string{"h-captcha-response", "g-recaptcha-response"}
+	}
+	return []string{"g-recaptcha-response"}
+}
+
+func formatInjectScript(kind, token string) (string, error) {
+	kindJSON, err := json.Marshal(kind)
+	if err != nil {
+		return "", err
+	}
+	tokenJSON, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(injectScript, kindJSON, tokenJSON), nil
+}
@@ -0,0 +1,55 @@
+package integration
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTransformers(t *testing.T) {
+	target := Target{Kind: KindHcaptcha, SiteKey: "key", PageURL: "https://shop.example.com/checkout"}
+
+	injection, err := Transform(TransformForm, target, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if injection.Fields.Get("h-captcha-response") != "token" || injection.Fields.Get("g-recaptcha-response") != "token" {
+		t.Errorf("unexpected fields %v", injection.Fields)
+	}
+
+	injection, err = Transform(TransformScript, target, `to"ken`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(injection.Script, `"to\"ken"`) || !strings.Contains(injection.Script, `"hcaptcha"`) {
+		t.Errorf("the token was not quoted in the script %s", injection.Script)
+	}
+
+	injection, err = Transform(TransformCookie, target, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cookie := injection.Cookies[0]; cookie.Name != "h-captcha-response" || cookie.Domain != "shop.example.com" || cookie.Path != "/" {
+		t.Errorf("unexpected cookie %+v", cookie)
+	}
+
+	RegisterTransformer("bearer", HeaderTransformer{Name: "Authorization", Prefix: "Bearer "})
+	defer func() {
+		transformerTable.Lock()
+		delete(transformerTable.transformers, "bearer")
+		transformerTable.Unlock()
+	}()
+	injection, err = Transform("bearer", Target{Kind: KindRecaptcha}, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := httptest.NewRequest("POST", "/", nil)
+	injection.Apply(request)
+	if request.Header.Get("Authorization") != "Bearer token" {
+		t.Errorf("unexpected header %v", request.Header)
+	}
+
+	if _, err := Transform("unknown", target, "token"); err != ErrUnknownTransformer {
+		t.Fatalf("got %v, want ErrUnknownTransformer", err)
+	}
+}
@@ -0,0 +1,301 @@
+package integration
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bask058/godbc"
+)
+
+//Defaults of the challenge transport
+const (
+	defaultChallengeBody = 1 << 20
+	defaultClearanceTTL  = 2 * time.Minute
+)
+
+/*Challenge is the signature of a challenge page
+  StatusCodes: the statuses the challenge is served with, any status if empty
+  Body: matched against the response body, any body if nil
+  Kind: the captcha kind to look for, KindRecaptcha or KindHcaptcha
+  Detect: returns the sitekey of the challenge, defaults to DetectRecaptcha or DetectHcaptcha by kind
+*/
+type Challenge struct {
+	StatusCodes []int
+	Body        *regexp.Regexp
+	Kind        string
+	Detect      func(body []byte) (string, bool)
+}
+
+//DefaultChallenges are the signatures used by a ChallengeTransport without any: reCAPTCHA and hCaptcha widgets served with 403, 429 or 503
+var DefaultChallenges = []Challenge{
+	{StatusCodes: []int{http.StatusForbidden, http.StatusTooManyRequests, http.StatusServiceUnavailable}, Kind: KindRecaptcha},
+	{StatusCodes: []int{http.StatusForbidden, http.StatusTooManyRequests, http.StatusServiceUnavailable}, Kind: KindHcaptcha},
+}
+
+//match returns the target of the challenge, pageURL being the url of the request the response answers
+func (ch *Challenge) match(resp *http.Response, body []byte, pageURL string) (*Target, bool) {
+	if len(ch.StatusCodes) > 0 {
+		found := false
+		for _, code := range ch.StatusCodes {
+			found = found || code == resp.StatusCode
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	if ch.Body != nil && !ch.Body.Match(body) {
+		return nil, false
+	}
+
+	kind := ch.Kind
+	if kind == "" {
+		kind = KindRecaptcha
+	}
+	detect := ch.Detect
+	if detect == nil {
+		detect = DetectRecaptcha
+		if kind == KindHcaptcha {
+			detect = DetectHcaptcha
+		}
+	}
+	sitekey, ok := detect(body)
+	if !ok {
+		return nil, false
+	}
+	return &Target{Kind: kind, SiteKey: sitekey, PageURL: pageURL}, true
+}
+
+//clearance is the token and cookies a domain accepted
+type clearance struct {
+	header  http.Header
+	cookies []*http.Cookie
+	expires time.Time
+}
+
+/*ChallengeTransport is a http.RoundTripper solving the captcha challenges of the responses, and replaying the original request with the token
+  The header and cookies of the accepted replay are kept as the clearance of its domain and sent with the next requests to it,
+  until they expire or the domain challenges again. The transport can be set on a http.Client, or on a colly collector with WithTransport
+*/
+type ChallengeTransport struct {
+	Client *godbc.Client
+	//Base sends the requests, defaults to http.DefaultTransport
+	Base http.RoundTripper
+	//Challenges are the signatures of the challenge pages, defaults to DefaultChallenges
+	Challenges []Challenge
+	//Transformer attaches the token to the replayed request, defaults to FormTransformer. Form fields are merged into url-encoded POST bodies, other requests get them as headers
+	Transformer Transformer
+	//ClearanceTTL is how long the clearance of a domain is kept, defaults to 2 minutes
+	ClearanceTTL time.Duration
+	//MaxBody is the size of the response body read to detect challenges, defaults to 1MB
+	MaxBody int64
+
+	mu         sync.Mutex
+	clearances map[string]*clearance
+}
+
+//RoundTrip sends the request, and replays it once with a solved token if its response is a challenge
+func (t *ChallengeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req, err := t.rewindable(req)
+	if err != nil {
+		return nil, err
+	}
+
+	cleared := t.clearance(req.URL.Host)
+	resp, err := t.send(req, cleared)
+	if err != nil {
+		return nil, err
+	}
+	target, err := t.detect(req, resp)
+	if err != nil || target == nil {
+		return resp, err
+	}
+	if cleared != nil {
+		t.forget(req.URL.Host)
+	}
+
+	resolved, err := solveTarget(req.Context(), t.Client, target)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	transformer := t.Transformer
+	if transformer == nil {
+		transformer = FormTransformer{}
+	}
+	injection, err := transformer.Transform(*target, resolved.Text)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	replay, header, err := t.replay(req, injection)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+
+	cleared = &clearance{header: header, cookies: injection.Cookies}
+	resp, err = t.send(replay, cleared)
+	if err != nil {
+		return nil, err
+	}
+	if target, err = t.detect(replay, resp); err != nil || target != nil {
+		return resp, err
+	}
+	cleared.cookies = append(cleared.cookies, resp.Cookies()...)
+	t.keep(req.URL.Host, cleared)
+	return resp, nil
+}
+
+//rewindable returns a copy of the request whose body can be read again for the replay
+func (t *ChallengeTransport) rewindable(req *http.Request) (*http.Request, error) {
+	req = req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody != nil {
+		return req, nil
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	return req, nil
+}
+
+func (t *ChallengeTransport) send(req *http.Request, cleared *clearance) (*http.Response, error) {
+	if cleared != nil {
+		req = req.Clone(req.Context())
+		(&Injection{Header: cleared.header, Cookies: cleared.cookies}).Apply(req)
+	}
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
+
+//detect returns the challenge of the response to the request, nil if it is not one. The body read is put back in the response
+func (t *ChallengeTransport) detect(req *http.Request, resp *http.Response) (*Target, error) {
+	limit := t.MaxBody
+	if limit <= 0 {
+		limit = defaultChallengeBody
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+
+	challenges := t.Challenges
+	if challenges == nil {
+		challenges = DefaultChallenges
+	}
+	for i := range challenges {
+		if target, ok := challenges[i].match(resp, body, req.URL.String()); ok {
+			return target, nil
+		}
+	}
+	return nil, nil
+}
+
+/*replay returns the request to send again with the form fields of the injection, and the header to send with it and the next requests
+  The fields are merged into the body of url-encoded POST requests. Other requests are replayed as they were, as rewriting them to a form
+  would change their method or drop their body, and get the fields as headers instead
+*/
+func (t *ChallengeTransport) replay(req *http.Request, injection *Injection) (*http.Request, http.Header, error) {
+	replay := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, nil, err
+		}
+		replay.Body = body
+	}
+	header := injection.Header
+	if len(injection.Fields) == 0 {
+		return replay, header, nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(replay.Header.Get("Content-Type"))
+	if replay.Method != http.MethodPost || mediaType != "application/x-www-form-urlencoded" {
+		header = header.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+		for name, values := range injection.Fields {
+			for _, value := range values {
+				header.Add(name, value)
+			}
+		}
+		return replay, header, nil
+	}
+
+	form := url.Values{}
+	if replay.Body != nil {
+		body, err := ioutil.ReadAll(replay.Body)
+		replay.Body.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+		if form, err = url.ParseQuery(string(body)); err != nil {
+			return nil, nil, err
+		}
+	}
+	for name, values := range injection.Fields {
+		form[name] = values
+	}
+	encoded := form.Encode()
+	replay.ContentLength = int64(len(encoded))
+	replay.Body = ioutil.NopCloser(strings.NewReader(encoded))
+	replay.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader(encoded)), nil
+	}
+	return replay, header, nil
+}
+
+func (t *ChallengeTransport) clearance(host string) *clearance {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	cleared := t.clearances[host]
+	if cleared == nil || time.Now().After(cleared.expires) {
+		delete(t.clearances, host)
+		return nil
+	}
+	return cleared
+}
+
+func (t *ChallengeTransport) keep(host string, cleared *clearance) {
+	ttl := t.ClearanceTTL
+	if ttl <= 0 {
+		ttl = defaultClearanceTTL
+	}
+	cleared.expires = time.Now().Add(ttl)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.clearances == nil {
+		t.clearances = map[string]*clearance{}
+	}
+	t.clearances[host] = cleared
+}
+
+func (t *ChallengeTransport) forget(host string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.clearances, host)
+}
@@ -0,0 +1,128 @@
+package integration
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bask058/godbc"
+)
+
+const challengePage = `<html><body><form><div class="g-recaptcha" data-sitekey="6Lc_aXkUAAAAAL5kWAK-sitekey"></div></form></body></html>`
+
+//challengeServer challenges the requests without a token or the clearance cookie, and echoes the method and body of the others
+type challengeServer struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	challenges int
+}
+
+func newChallengeServer() *challengeServer {
+	s := &challengeServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		token := r.Header.Get("g-recaptcha-response")
+		if r.Header.Get("Content-Type") == "application/x-www-form-urlencoded" {
+			r.Body = ioutil.NopCloser(strings.NewReader(string(body)))
+			r.ParseForm()
+			token = r.PostForm.Get("g-recaptcha-response")
+		}
+		if _, err := r.Cookie("cleared"); err != nil && !strings.HasPrefix(token, "sandbox-token-") {
+			s.mu.Lock()
+			s.challenges++
+			s.mu.Unlock()
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(challengePage))
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "cleared", Value: "yes"})
+		w.Write([]byte(r.Method + " " + string(body)))
+	}))
+	return s
+}
+
+func newChallengeTransport() *ChallengeTransport {
+	client := godbc.NewClient("user", "password", &godbc.ClientOptions{Sandbox: &godbc.SandboxConfig{}})
+	return &ChallengeTransport{Client: client}
+}
+
+func roundTrip(t *testing.T, transport http.RoundTripper, method, url, contentType, body string) string {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	answer, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("%s %s: got %d %s", method, contentType, resp.StatusCode, answer)
+	}
+	return string(answer)
+}
+
+func TestChallengeTransportClearance(t *testing.T) {
+	server := newChallengeServer()
+	defer server.Close()
+	transport := newChallengeTransport()
+
+	if answer := roundTrip(t, transport, http.MethodGet, server.URL+"/page", "", ""); answer != "GET " {
+		t.Fatalf("the challenged GET was replayed as %q", answer)
+	}
+	if answer := roundTrip(t, transport, http.MethodGet, server.URL+"/other", "", ""); answer != "GET " {
+		t.Fatalf("unexpected answer %q", answer)
+	}
+	if server.challenges != 1 {
+		t.Fatalf("got %d challenges, want the clearance of the domain kept", server.challenges)
+	}
+}
+
+func TestChallengeTransportReplay(t *testing.T) {
+	server := newChallengeServer()
+	defer server.Close()
+
+	answer := roundTrip(t, newChallengeTransport(), http.MethodPost, server.URL, "application/x-www-form-urlencoded", "name=value")
+	if !strings.HasPrefix(answer, "POST ") || !strings.Contains(answer, "name=value") || !strings.Contains(answer, "g-recaptcha-response=sandbox-token-") {
+		t.Fatalf("the form was replayed as %q", answer)
+	}
+
+	json := `{"name": "value"}`
+	if answer := roundTrip(t, newChallengeTransport(), http.MethodPut, server.URL, "application/json", json); answer != "PUT "+json {
+		t.Fatalf("the json request was replayed as %q", answer)
+	}
+	if server.challenges != 2 {
+		t.Fatalf("got %d challenges, want 2", server.challenges)
+	}
+}
+
+//requestlessTransport returns responses without their Request, as custom transports may
+type requestlessTransport struct{}
+
+func (requestlessTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if resp != nil {
+		resp.Request = nil
+	}
+	return resp, err
+}
+
+func TestChallengeTransportBase(t *testing.T) {
+	server := newChallengeServer()
+	defer server.Close()
+	transport := newChallengeTransport()
+	transport.Base = requestlessTransport{}
+
+	if answer := roundTrip(t, transport, http.MethodGet, server.URL, "", ""); answer != "GET " {
+		t.Fatalf("unexpected answer %q", answer)
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Package webdriver solves the captchas of pages driven through the W3C WebDriver protocol, e.g. by Selenium
+
+A Session is an integration.Page evaluating scripts with the `execute/sync` command of a running
+session, so the reCAPTCHA and hCaptcha widgets and iframes of the page are found, solved and injected
+as with the chromedp and rod adapters, without a WebDriver client library
+*/
+package webdriver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/bask058/godbc"
+	"github.com/bask058/godbc/integration"
+)
+
+//Error is an error returned by the WebDriver remote
+type Error struct {
+	StatusCode int
+	Code       string `json:"error"`
+	Message    string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("WebDriver error %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+/*Session is a running WebDriver session
+  Remote: the url of the WebDriver remote, e.g. http://localhost:4444 or http://localhost:4444/wd/hub
+  ID: the session id, as returned by the remote when the session was created
+  HTTPClient: sends the commands, defaults to http.DefaultClient
+*/
+type Session struct {
+	Remote     string
+	ID         string
+	HTTPClient *http.Client
+}
+
+//Evaluate evaluates a javascript expression in the current browsing context of the session
+func (s *Session) Evaluate(ctx context.Context, expression string, result interface{}) error {
+	value, err := s.Execute(ctx, "return "+expression+";")
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(value, result)
+}
+
+//Execute runs a script with the `execute/sync` command, and returns the JSON value it returns
+func (s *Session) Execute(ctx context.Context, script string, args ...interface{}) (json.RawMessage, error) {
+	if args == nil {
+		args = []interface{}{}
+	}
+	body, err := json.Marshal(map[string]interface{}{"script": script, "args": args})
+	if err != nil {
+		return nil, err
+	}
+	endpoint := strings.TrimRight(s.Remote, "/") + "/session/" + s.ID + "/execute/sync"
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	httpClient := s.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var reply struct {
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(respBody, &reply); err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		remoteErr := &Error{StatusCode: resp.StatusCode}
+		json.Unmarshal(reply.Value, remoteErr)
+		return nil, remoteErr
+	}
+	return reply.Value, nil
+}
+
+//Solve extracts the captcha of the session's page, solves it through the client and injects the solution. Returns the solved captcha
+func Solve(ctx context.Context, client *godbc.Client, session *Session) (*godbc.CaptchaResponse, error) {
+	return integration.Solve(ctx, client, session)
+}
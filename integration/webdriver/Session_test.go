@@ -0,0 +1,76 @@
+package webdriver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bask058/godbc"
+)
+
+//newRemote returns a WebDriver remote answering the scripts of session "s1" with answer, and keeps the scripts
+func newRemote(t *testing.T, answer func(script string) interface{}) (*httptest.Server, *[]string) {
+	var mu sync.Mutex
+	scripts := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != "POST" || r.URL.Path != "/wd/hub/session/s1/execute/sync" {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{"value": map[string]string{"error": "invalid session id", "message": r.URL.Path}})
+			return
+		}
+		var command struct {
+			Script string        `json:"script"`
+			Args   []interface{} `json:"args"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&command); err != nil || command.Args == nil {
+			t.Errorf("unexpected command %+v, %v", command, err)
+		}
+		mu.Lock()
+		scripts = append(scripts, command.Script)
+		mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{"value": answer(command.Script)})
+	}))
+	t.Cleanup(server.Close)
+	return server, &scripts
+}
+
+func TestEvaluate(t *testing.T) {
+	server, scripts := newRemote(t, func(script string) interface{} { return 42 })
+	session := &Session{Remote: server.URL + "/wd/hub/", ID: "s1"}
+	var result int
+	if err := session.Evaluate(context.Background(), "6 * 7", &result); err != nil {
+		t.Fatal(err)
+	}
+	if result != 42 || (*scripts)[0] != "return 6 * 7;" {
+		t.Fatalf("got %d for the script %q", result, (*scripts)[0])
+	}
+
+	session.ID = "gone"
+	var remoteErr *Error
+	if err := session.Evaluate(context.Background(), "1", nil); !errors.As(err, &remoteErr) || remoteErr.StatusCode != 404 || remoteErr.Code != "invalid session id" {
+		t.Fatalf("got %v, want the remote error", err)
+	}
+}
+
+func TestSolve(t *testing.T) {
+	server, scripts := newRemote(t, func(script string) interface{} {
+		if strings.Contains(script, "data-sitekey") {
+			return map[string]string{"kind": "recaptcha", "sitekey": "key", "pageurl": "https://example.com"}
+		}
+		return true
+	})
+	client := godbc.NewClient("user", "password", &godbc.ClientOptions{Sandbox: &godbc.SandboxConfig{}, CaptchaRetries: 5})
+	resolved, err := Solve(context.Background(), client, &Session{Remote: server.URL + "/wd/hub", ID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.Text == "" || len(*scripts) != 2 || !strings.Contains((*scripts)[1], `"`+resolved.Text+`"`) {
+		t.Fatalf("got %+v after the scripts %q", resolved, *scripts)
+	}
+}
@@ -0,0 +1,68 @@
+//go:build !windows
+
+package limiter
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"syscall"
+	"time"
+)
+
+//File is a limiter whose state is a file locked with flock, shared by the processes of one host
+type File struct {
+	//Path - the state file, created if missing
+	Path     string
+	Interval time.Duration
+	Burst    int
+}
+
+//Wait books a call slot in the state file and waits for it
+func (f *File) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	wait, err := f.reserve()
+	if err != nil {
+		return err
+	}
+	return sleep(ctx, wait)
+}
+
+//Backoff holds the calls of every process back until the given time
+func (f *File) Backoff(until time.Time) {
+	f.update(func(tat time.Time) (time.Time, time.Duration) {
+		return holdUntil(tat, until, f.Interval, f.Burst), 0
+	})
+}
+
+func (f *File) reserve() (time.Duration, error) {
+	return f.update(func(tat time.Time) (time.Time, time.Duration) {
+		return reserve(tat, time.Now(), f.Interval, f.Burst)
+	})
+}
+
+//update changes the theoretical arrival time stored in the state file, under the file lock
+func (f *File) update(change func(tat time.Time) (time.Time, time.Duration)) (time.Duration, error) {
+	file, err := os.OpenFile(f.Path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	if err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		return 0, err
+	}
+	defer syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+
+	state := make([]byte, 8)
+	tat := time.Time{}
+	if n, _ := file.ReadAt(state, 0); n == len(state) {
+		tat = time.Unix(0, int64(binary.BigEndian.Uint64(state)))
+	}
+
+	tat, wait := change(tat)
+	binary.BigEndian.PutUint64(state, uint64(tat.UnixNano()))
+	_, err = file.WriteAt(state, 0)
+	return wait, err
+}
@@ -0,0 +1,36 @@
+//go:build !windows
+
+package limiter
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileShared(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "limiter")
+	//two processes of the host, sharing the state file
+	first := &File{Path: path, Interval: time.Minute, Burst: 2}
+	second := &File{Path: path, Interval: time.Minute, Burst: 2}
+
+	for i, limiter := range []*File{first, second} {
+		if wait, err := limiter.reserve(); err != nil || wait > 0 {
+			t.Fatalf("call %d of the burst waits %s: %v", i, wait, err)
+		}
+	}
+	if wait, err := first.reserve(); err != nil || wait < 59*time.Second {
+		t.Fatalf("got a wait of %s past the burst, want a minute: %v", wait, err)
+	}
+
+	second.Backoff(time.Now().Add(time.Hour))
+	if wait, err := first.reserve(); err != nil || wait < 59*time.Minute {
+		t.Fatalf("got a wait of %s, want the backoff of the other process: %v", wait, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := second.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("got %v, want the context deadline", err)
+	}
+}
@@ -0,0 +1,50 @@
+/*
+Package limiter implements godbc.Limiter on state shared by several processes using one account
+Both limiters space calls Interval apart, letting up to Burst calls through at once after a quiet period
+*/
+package limiter
+
+import (
+	"context"
+	"time"
+)
+
+//reserve books the next call slot, returning the new theoretical arrival time and how long to wait for the slot
+func reserve(tat, now time.Time, interval time.Duration, burst int) (time.Time, time.Duration) {
+	if burst < 1 {
+		burst = 1
+	}
+	if tat.Before(now) {
+		tat = now
+	}
+	tat = tat.Add(interval)
+
+	return tat, tat.Add(-interval * time.Duration(burst)).Sub(now)
+}
+
+//holdUntil returns the theoretical arrival time making the next call wait until the given time
+func holdUntil(tat, until time.Time, interval time.Duration, burst int) time.Time {
+	if burst < 1 {
+		burst = 1
+	}
+	held := until.Add(interval * time.Duration(burst-1))
+	if held.After(tat) {
+		return held
+	}
+	return tat
+}
+
+//sleep waits for d, or for the context to be done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,41 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestReserve(t *testing.T) {
+	now := time.Now()
+	tat := time.Time{}
+	var waits []time.Duration
+	for i := 0; i < 4; i++ {
+		var wait time.Duration
+		tat, wait = reserve(tat, now, time.Second, 2)
+		waits = append(waits, wait)
+	}
+	//the burst goes through at once, the next calls are spaced an interval apart
+	if waits[0] > 0 || waits[1] > 0 || waits[2] != time.Second || waits[3] != 2*time.Second {
+		t.Fatalf("unexpected waits %v", waits)
+	}
+
+	held := holdUntil(time.Time{}, now.Add(time.Minute), time.Second, 2)
+	if _, wait := reserve(held, now, time.Second, 2); wait != time.Minute {
+		t.Fatalf("got a wait of %s after a backoff of a minute", wait)
+	}
+	if holdUntil(now.Add(time.Hour), now.Add(time.Minute), time.Second, 2) != now.Add(time.Hour) {
+		t.Fatal("a backoff brought the calls forward")
+	}
+}
+
+func TestSleep(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := sleep(ctx, time.Hour); err != context.Canceled {
+		t.Fatalf("got %v, want the context error", err)
+	}
+	if err := sleep(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,68 @@
+//go:build redis
+
+package limiter
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+//redisReserve is reserve run atomically in redis, on microseconds. KEYS[1]: the state, ARGV: now, interval, burst
+var redisReserve = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local burst = tonumber(ARGV[3])
+local tat = tonumber(redis.call("GET", KEYS[1]) or "0")
+if tat < now then
+	tat = now
+end
+tat = tat + interval
+redis.call("SET", KEYS[1], tat, "PX", math.ceil((tat - now) / 1000) + 1000)
+return tat - interval * burst - now
+`)
+
+//redisHold is holdUntil run atomically in redis, on microseconds. KEYS[1]: the state, ARGV: now, the time to hold until, interval, burst
+var redisHold = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local held = tonumber(ARGV[2]) + tonumber(ARGV[3]) * (tonumber(ARGV[4]) - 1)
+local tat = tonumber(redis.call("GET", KEYS[1]) or "0")
+if held > tat then
+	redis.call("SET", KEYS[1], held, "PX", math.ceil((held - now) / 1000) + 1000)
+end
+return 0
+`)
+
+//Redis is a limiter whose state is a redis key, shared by all the processes using the account
+type Redis struct {
+	Client redis.Scripter
+	//Key - the state key, e.g. "godbc:limiter:<username>"
+	Key      string
+	Interval time.Duration
+	Burst    int
+}
+
+//Wait books a call slot in redis and waits for it
+func (r *Redis) Wait(ctx context.Context) error {
+	burst := r.Burst
+	if burst < 1 {
+		burst = 1
+	}
+	now := time.Now().UnixNano() / int64(time.Microsecond)
+	wait, err := redisReserve.Run(ctx, r.Client, []string{r.Key}, now, r.Interval.Microseconds(), burst).Int64()
+	if err != nil {
+		return err
+	}
+	return sleep(ctx, time.Duration(wait)*time.Microsecond)
+}
+
+//Backoff holds the calls of every process back until the given time
+func (r *Redis) Backoff(until time.Time) {
+	burst := r.Burst
+	if burst < 1 {
+		burst = 1
+	}
+	now := time.Now().UnixNano() / int64(time.Microsecond)
+	redisHold.Run(context.Background(), r.Client, []string{r.Key}, now, until.UnixNano()/int64(time.Microsecond), r.Interval.Microseconds(), burst)
+}
@@ -0,0 +1,196 @@
+/*
+Package godbc implements deathbycaptcha's API, version 2 of the library surface
+
+Every call takes a context, responses are exposed through interfaces so their
+representation can evolve without breaking callers, and the client is configured
+through functional options. It is built on top of the v1 package, which keeps
+compiling and working unchanged
+*/
+package godbc
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	v1 "github.com/bask058/godbc"
+)
+
+//Captcha is a submitted captcha
+type Captcha interface {
+	ID() int64
+	Text() string
+	IsCorrect() bool
+	SubmittedAt() time.Time
+}
+
+//Account is the account information of the client's user
+type Account interface {
+	ID() int64
+	Balance() float64
+	Rate() float64
+	IsBanned() bool
+	HasCreditLeft() bool
+}
+
+//ServiceStatus is the status of the service
+type ServiceStatus interface {
+	TodaysAccuracy() float64
+	SolvedIn() time.Duration
+	IsOverloaded() bool
+}
+
+//Option configures a client
+type Option func(options *v1.ClientOptions, client *clientConfig)
+
+type clientConfig struct {
+	httpClient *http.Client
+}
+
+//WithEndpoint sets the api endpoint
+func WithEndpoint(endpoint *url.URL) Option {
+	return func(options *v1.ClientOptions, _ *clientConfig) {
+		options.Endpoint = endpoint
+	}
+}
+
+//WithHTTPTimeout sets the timeout of http calls
+func WithHTTPTimeout(timeout time.Duration) Option {
+	return func(options *v1.ClientOptions, _ *clientConfig) {
+		options.HTTPTimeout = &timeout
+	}
+}
+
+//WithTLSHandshakeTimeout sets the timeout of TLS handshakes
+func WithTLSHandshakeTimeout(timeout time.Duration) Option {
+	return func(options *v1.ClientOptions, _ *clientConfig) {
+		options.TLSHandshakeTimeout = &timeout
+	}
+}
+
+//WithCaptchaRetries sets how many times a captcha is polled before giving up
+func WithCaptchaRetries(retries int) Option {
+	return func(options *v1.ClientOptions, _ *clientConfig) {
+		options.CaptchaRetries = retries
+	}
+}
+
+//WithHTTPClient sets the http client, library defaults are layered where it leaves them unset
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(_ *v1.ClientOptions, config *clientConfig) {
+		config.httpClient = httpClient
+	}
+}
+
+//Client is the DBC client
+type Client struct {
+	client *v1.Client
+}
+
+//New returns a DBC client, options not given take the v1 default values
+func New(username, password string, opts ...Option) *Client {
+	options := &v1.ClientOptions{}
+	config := &clientConfig{}
+	for _, opt := range opts {
+		opt(options, config)
+	}
+
+	client := v1.NewClient(username, password, options)
+	if config.httpClient != nil {
+		client.WithHTTPClient(config.httpClient)
+	}
+	return &Client{client: client}
+}
+
+//V1 returns the underlying v1 client, for features not yet exposed in v2
+func (c *Client) V1() *v1.Client {
+	return c.client
+}
+
+//Submit submits an image captcha. options may be nil
+func (c *Client) Submit(ctx context.Context, content []byte, options *v1.CaptchaOptions) (Captcha, error) {
+	response, err := c.client.CaptchaWithOptions(ctx, content, options)
+	if err != nil {
+		return nil, err
+	}
+	return captcha{response}, nil
+}
+
+//Poll polls a captcha once
+func (c *Client) Poll(ctx context.Context, submitted Captcha) (Captcha, error) {
+	response, err := c.client.PollCaptchaWithContext(ctx, toV1(submitted))
+	if err != nil {
+		return nil, err
+	}
+	return captcha{response}, nil
+}
+
+//Wait waits for a captcha to be solved
+func (c *Client) Wait(ctx context.Context, submitted Captcha) (Captcha, error) {
+	response, err := c.client.WaitCaptchaWithContext(ctx, toV1(submitted))
+	if err != nil {
+		return nil, err
+	}
+	return captcha{response}, nil
+}
+
+//Report reports a captcha as incorrectly solved
+func (c *Client) Report(ctx context.Context, solved Captcha) error {
+	_, err := c.client.ReportCaptchaWithContext(ctx, toV1(solved))
+	return err
+}
+
+//Account retrieves the account information
+func (c *Client) Account(ctx context.Context) (Account, error) {
+	response, err := c.client.UserWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return account{response}, nil
+}
+
+//Status retrieves the service status
+func (c *Client) Status(ctx context.Context) (ServiceStatus, error) {
+	response, err := c.client.StatusWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return status{response}, nil
+}
+
+func toV1(c Captcha) *v1.CaptchaResponse {
+	if wrapped, ok := c.(captcha); ok {
+		return wrapped.response
+	}
+	return &v1.CaptchaResponse{ID: c.ID(), Text: c.Text(), IsCorrect: c.IsCorrect(), SubmittedAt: c.SubmittedAt()}
+}
+
+type captcha struct {
+	response *v1.CaptchaResponse
+}
+
+func (c captcha) ID() int64              { return c.response.ID }
+func (c captcha) Text() string           { return c.response.Text }
+func (c captcha) IsCorrect() bool        { return c.response.IsCorrect }
+func (c captcha) SubmittedAt() time.Time { return c.response.SubmittedAt }
+
+type account struct {
+	response *v1.UserResponse
+}
+
+func (a account) ID() int64           { return a.response.ID }
+func (a account) Balance() float64    { return a.response.Balance }
+func (a account) Rate() float64       { return a.response.Rate }
+func (a account) IsBanned() bool      { return a.response.IsBanned }
+func (a account) HasCreditLeft() bool { return a.response.HasCreditLeft() }
+
+type status struct {
+	response *v1.StatusResponse
+}
+
+func (s status) TodaysAccuracy() float64 { return s.response.TodaysAccuracy }
+func (s status) SolvedIn() time.Duration {
+	return time.Duration(s.response.SolvedIn * float64(time.Second))
+}
+func (s status) IsOverloaded() bool { return s.response.IsServiceOverloaded }
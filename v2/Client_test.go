@@ -0,0 +1,90 @@
+package godbc
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"net/http"
+	"testing"
+	"time"
+
+	v1 "github.com/bask058/godbc"
+)
+
+func newSandboxClient(config v1.SandboxConfig) *Client {
+	return New("user", "password", WithCaptchaRetries(5), WithHTTPClient(&http.Client{Transport: v1.NewSandboxTransport(config)}))
+}
+
+func captchaImage(t *testing.T) []byte {
+	img := image.NewGray(image.Rect(0, 0, 120, 40))
+	for i := range img.Pix {
+		img.Pix[i] = byte(i * 7919 % 251)
+	}
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+//storedCaptcha is a captcha kept by the caller, e.g. read back from a database
+type storedCaptcha struct {
+	id int64
+}
+
+func (c storedCaptcha) ID() int64              { return c.id }
+func (c storedCaptcha) Text() string           { return "" }
+func (c storedCaptcha) IsCorrect() bool        { return true }
+func (c storedCaptcha) SubmittedAt() time.Time { return time.Now() }
+
+func TestSolve(t *testing.T) {
+	client := newSandboxClient(v1.SandboxConfig{Answer: "abcdef"})
+	ctx := context.Background()
+	submitted, err := client.Submit(ctx, captchaImage(t), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if submitted.ID() == 0 || submitted.SubmittedAt().IsZero() {
+		t.Fatalf("unexpected captcha %+v", submitted)
+	}
+	solved, err := client.Wait(ctx, submitted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if solved.Text() != "abcdef" || solved.ID() != submitted.ID() {
+		t.Fatalf("unexpected captcha %+v", solved)
+	}
+	if err := client.Report(ctx, solved); err != nil {
+		t.Fatal(err)
+	}
+
+	polled, err := client.Poll(ctx, storedCaptcha{id: submitted.ID()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if polled.Text() != "abcdef" {
+		t.Fatalf("the captcha implemented by the caller was polled as %+v", polled)
+	}
+}
+
+func TestAccountAndStatus(t *testing.T) {
+	client := newSandboxClient(v1.SandboxConfig{Balance: 12.5, Rate: 0.139})
+	account, err := client.Account(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Balance() != 12.5 || account.Rate() != 0.139 || account.IsBanned() || !account.HasCreditLeft() {
+		t.Fatalf("unexpected account %+v", account)
+	}
+	status, err := client.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.IsOverloaded() {
+		t.Fatalf("unexpected status %+v", status)
+	}
+	if client.V1() == nil {
+		t.Fatal("the v1 client is nil")
+	}
+}